@@ -0,0 +1,107 @@
+// Package blobstore reads and writes archives held in cloud object stores.
+//
+// Objects are read with HTTP range requests through httpzip, so listing a
+// remote archive or extracting a few of its entries downloads only the
+// bytes involved. Stores are addressed by URLs such as s3://bucket/key.zip
+// and gs://bucket/key.zip; see Parse.
+package blobstore
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Object is an object opened for ranged reads.
+type Object interface {
+	io.ReaderAt
+	// Size returns the size of the object in bytes.
+	Size() int64
+}
+
+// BlobStore is an object store that archives can be read from and
+// written to.
+type BlobStore interface {
+	// Open opens the object at key for ranged reads.
+	Open(key string) (Object, error)
+	// Put uploads size bytes read from r as the object at key, replacing
+	// any existing object.
+	Put(key string, r io.Reader, size int64) error
+}
+
+// IsURL reports whether name is an object store URL that Parse accepts.
+func IsURL(name string) bool {
+	return strings.HasPrefix(name, "s3://") || strings.HasPrefix(name, "gs://")
+}
+
+// Parse returns the store and object key named by an s3://bucket/key or
+// gs://bucket/key URL. Stores are configured from the environment, as
+// described for NewS3FromEnv and NewGCSFromEnv.
+func Parse(rawURL string) (BlobStore, string, error) {
+	scheme, rest, _ := strings.Cut(rawURL, "://")
+	bucket, key, ok := strings.Cut(rest, "/")
+	if !ok || bucket == "" || key == "" {
+		return nil, "", fmt.Errorf("blobstore: invalid URL %q: want scheme://bucket/key", rawURL)
+	}
+	switch scheme {
+	case "s3":
+		return NewS3FromEnv(bucket), key, nil
+	case "gs":
+		return NewGCSFromEnv(bucket), key, nil
+	default:
+		return nil, "", fmt.Errorf("blobstore: unsupported scheme %q: must be s3 or gs", scheme)
+	}
+}
+
+// transport is a RoundTripper that lets sign modify each request before
+// sending it with base.
+type transport struct {
+	base http.RoundTripper
+	sign func(*http.Request) error
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := t.sign(req); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req) //nolint:wrapcheck // Errors pass through unchanged, as from any transport.
+}
+
+// signingClient returns a client that signs requests with sign before
+// sending them through client, or http.DefaultClient if client is nil.
+func signingClient(client *http.Client, sign func(*http.Request) error) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := *client
+	c.Transport = &transport{base: base, sign: sign}
+	return &c
+}
+
+// put uploads size bytes from r to url with client.
+func put(client *http.Client, url string, r io.Reader, size int64, header http.Header) error {
+	req, err := http.NewRequest(http.MethodPut, url, r) //nolint:noctx // The BlobStore interface has no context.
+	if err != nil {
+		return fmt.Errorf("blobstore: %w", err)
+	}
+	req.ContentLength = size
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("blobstore: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("blobstore: PUT %s: %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
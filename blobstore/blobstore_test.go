@@ -0,0 +1,108 @@
+package blobstore
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore is an in-memory object store serving PUT and ranged GET
+// requests. It records the Authorization header of the last request.
+type fakeStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	auth    string
+}
+
+func (f *fakeStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestStoresRoundTrip(t *testing.T) {
+	fake := &fakeStore{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	stores := map[string]struct {
+		store BlobStore
+		auth  string
+	}{
+		"s3":  {&S3{Bucket: "b", Region: "us-east-1", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: srv.URL}, "AWS4-HMAC-SHA256 Credential=AKID/"},
+		"gcs": {&GCS{Bucket: "b", Token: "tok", Endpoint: strings.TrimPrefix(srv.URL, "http://")}, "Bearer tok"},
+	}
+	for name, tt := range stores {
+		t.Run(name, func(t *testing.T) {
+			data := []byte("PK archive bytes for " + name)
+			if err := tt.store.Put(name+"/a.zip", bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if !strings.HasPrefix(fake.auth, tt.auth) {
+				t.Errorf("Put Authorization = %q, want prefix %q", fake.auth, tt.auth)
+			}
+
+			obj, err := tt.store.Open(name + "/a.zip")
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if obj.Size() != int64(len(data)) {
+				t.Errorf("Size = %d, want %d", obj.Size(), len(data))
+			}
+			got := make([]byte, 7)
+			if _, err := obj.ReadAt(got, 3); err != nil {
+				t.Fatalf("ReadAt: %v", err)
+			}
+			if string(got) != string(data[3:10]) {
+				t.Errorf("ReadAt = %q, want %q", got, data[3:10])
+			}
+
+			if _, err := tt.store.Open(name + "/missing.zip"); err == nil {
+				t.Error("Open of a missing object succeeded")
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url     string
+		key     string
+		wantErr bool
+	}{
+		{"s3://bucket/dir/a.zip", "dir/a.zip", false},
+		{"gs://bucket/a.zip", "a.zip", false},
+		{"s3://bucket", "", true},
+		{"s3:///a.zip", "", true},
+		{"ftp://bucket/a.zip", "", true},
+	}
+	for _, tt := range tests {
+		store, key, err := Parse(tt.url)
+		if (err != nil) != tt.wantErr || key != tt.key {
+			t.Errorf("Parse(%q) = %v, %q, %v", tt.url, store, key, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package blobstore
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jaeyeom/gozip/httpzip"
+)
+
+// defaultGCSEndpoint is the Cloud Storage XML API, which serves objects
+// with range support and accepts plain PUT uploads.
+const defaultGCSEndpoint = "https://storage.googleapis.com"
+
+// GCS is a Google Cloud Storage bucket. Requests carry Token as an OAuth 2
+// bearer token when it is set, and are sent anonymously otherwise.
+type GCS struct {
+	Bucket string
+	// Token is an OAuth 2 access token, e.g. from
+	// "gcloud auth print-access-token".
+	Token string
+	// Endpoint is the base URL of the service. If empty, the Cloud
+	// Storage XML API is used.
+	Endpoint string
+	// Client sends the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// NewGCSFromEnv returns the bucket authorized by the access token in
+// GOOGLE_OAUTH_ACCESS_TOKEN. STORAGE_EMULATOR_HOST, if set, overrides the
+// endpoint.
+func NewGCSFromEnv(bucket string) *GCS {
+	return &GCS{
+		Bucket:   bucket,
+		Token:    os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		Endpoint: os.Getenv("STORAGE_EMULATOR_HOST"),
+	}
+}
+
+// Open implements BlobStore.
+func (g *GCS) Open(key string) (Object, error) {
+	return httpzip.Open(g.objectURL(key), signingClient(g.Client, g.sign))
+}
+
+// Put implements BlobStore.
+func (g *GCS) Put(key string, r io.Reader, size int64) error {
+	header := http.Header{"Content-Type": {"application/zip"}}
+	return put(signingClient(g.Client, g.sign), g.objectURL(key), r, size, header)
+}
+
+// objectURL returns the URL of the object at key.
+func (g *GCS) objectURL(key string) string {
+	endpoint := g.Endpoint
+	switch {
+	case endpoint == "":
+		endpoint = defaultGCSEndpoint
+	case !strings.Contains(endpoint, "://"):
+		// Emulators are usually given as host:port.
+		endpoint = "http://" + endpoint
+	}
+	return strings.TrimSuffix(endpoint, "/") + "/" + g.Bucket + "/" + escapePath(key)
+}
+
+func (g *GCS) sign(req *http.Request) error {
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	return nil
+}
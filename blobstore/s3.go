@@ -0,0 +1,173 @@
+package blobstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jaeyeom/gozip/httpzip"
+)
+
+// emptySHA256 is the hex SHA-256 of an empty payload.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3 is an Amazon S3 bucket, or a bucket of an S3-compatible service.
+// Requests are signed with AWS Signature Version 4 when credentials are
+// set, and sent anonymously otherwise.
+type S3 struct {
+	Bucket string
+	// Region is the region of the bucket, e.g. "us-east-1".
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials.
+	SessionToken string
+	// Endpoint, if set, is the base URL of an S3-compatible service such
+	// as MinIO. Objects are then addressed with path-style URLs.
+	Endpoint string
+	// Client sends the requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	now func() time.Time // for tests
+}
+
+// NewS3FromEnv returns the bucket configured with the standard AWS
+// environment variables: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN, AWS_REGION (or AWS_DEFAULT_REGION, defaulting to
+// us-east-1) and AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL).
+func NewS3FromEnv(bucket string) *S3 {
+	return &S3{
+		Bucket:          bucket,
+		Region:          firstEnv("us-east-1", "AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Endpoint:        firstEnv("", "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+	}
+}
+
+// firstEnv returns the first of the environment variables that is set, or
+// def if none is.
+func firstEnv(def string, names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return def
+}
+
+// Open implements BlobStore.
+func (s *S3) Open(key string) (Object, error) {
+	return httpzip.Open(s.objectURL(key), signingClient(s.Client, s.sign))
+}
+
+// Put implements BlobStore. The payload is sent unsigned, as S3 allows
+// over HTTPS, so that it can be streamed.
+func (s *S3) Put(key string, r io.Reader, size int64) error {
+	header := http.Header{"X-Amz-Content-Sha256": {"UNSIGNED-PAYLOAD"}}
+	return put(signingClient(s.Client, s.sign), s.objectURL(key), r, size, header)
+}
+
+// objectURL returns the URL of the object at key.
+func (s *S3) objectURL(key string) string {
+	if s.Endpoint != "" {
+		return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket + "/" + escapePath(key)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.Region, escapePath(key))
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req.
+func (s *S3) sign(req *http.Request) error {
+	if s.AccessKeyID == "" {
+		return nil
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = emptySHA256
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		if lk := strings.ToLower(k); lk == "range" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// escapePath percent-encodes key as AWS expects in canonical URIs: every
+// byte except unreserved characters and "/" is escaped.
+func escapePath(key string) string {
+	var b strings.Builder
+	for i := range len(key) {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
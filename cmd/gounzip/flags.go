@@ -0,0 +1,322 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+
+	"github.com/jaeyeom/gozip/internal/report"
+	"github.com/jaeyeom/gozip/ziplib"
+	"github.com/spf13/cobra"
+)
+
+// unzipFlags holds the command line flags of gounzip.
+type unzipFlags struct {
+	list      bool
+	totals    bool
+	pipe      bool
+	overwrite bool
+	outputDir string
+	junkPaths bool
+	keepSuid  bool
+	owners    bool
+	order     string
+	priority  []string
+	excludes  []string
+	syntax    string
+	charset   string
+	execCmd   string
+	execJobs  int
+	fileMode  string
+	dirMode   string
+	umask     bool
+	copyLinks bool
+	keepBad   bool
+	force     bool
+	dryRun    bool
+	keepGoing bool
+	maxFiles  int
+	maxSize   int64
+	maxDepth  int
+	maxPath   int
+	outFormat string
+	logFormat string
+	outFile   string
+	since     string
+	until     string
+	strip     int
+	conflict  string
+	backup    bool
+	freshen   bool
+	update    bool
+	noCase    bool
+	noMacMeta bool
+	reserved  string
+	caseColl  string
+	mmap      bool
+	rateLimit int64
+	keepNewer bool
+	restore   bool
+	progress  bool
+	threads   int
+	test      bool
+	quiet     int
+	verbose   bool
+	info      bool
+	names     bool
+	short     bool
+	medium    bool
+	never     bool
+	textConv  int
+	maxBytes  int64
+}
+
+// register adds the flags to cmd.
+func (f *unzipFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVarP(&f.list, "list", "l", false, "List archive contents; with -Z, use the long format, adding compressed sizes")
+	flags.BoolVarP(&f.verbose, "verbose", "v", false, "List archive contents with method, compressed size, ratio and CRC-32")
+	flags.BoolVarP(&f.info, "zipinfo", "Z", false, "List archive contents like zipinfo, with modes, host systems and methods")
+	flags.BoolVarP(&f.names, "names-only", "1", false, "With -Z, list only entry names, one per line")
+	flags.BoolVarP(&f.short, "short", "s", false, "With -Z, use the short format (default)")
+	flags.BoolVarP(&f.medium, "medium", "m", false, "With -Z, use the medium format, adding compression factors")
+	flags.BoolVar(&f.totals, "totals", false, "With -l or -v, also print compression statistics of the whole archive")
+	flags.BoolVarP(&f.overwrite, "overwrite", "o", false, "Overwrite existing files")
+	flags.BoolVarP(&f.never, "never-overwrite", "n", false, "Never overwrite existing files; skip their entries silently (--on-conflict skip)")
+	flags.BoolVarP(&f.freshen, "freshen", "f", false, "Replace only existing files that are older than the archived copy")
+	flags.BoolVar(&f.keepNewer, "keep-newer", false, "Never replace existing files that are newer than the archived copy")
+	flags.BoolVar(&f.restore, "restore", false, "Treat every argument as an archive of an incremental backup chain and restore them in order")
+	flags.BoolVarP(&f.update, "update", "u", false, "Like --freshen, but also extract files that do not exist")
+	flags.BoolVarP(&f.backup, "backup", "B", false, "Rename files about to be overwritten to name~ first")
+	flags.StringVar(&f.conflict, "on-conflict", "error", "When a file exists: error, overwrite, skip, or rename to name~N")
+	flags.CountVarP(&f.quiet, "quiet", "q", "Do not print a line for each file extracted; -qq also hides warnings")
+	flags.BoolVarP(&f.test, "test", "t", false, "Test entries by decompressing them and checking their CRC-32, without extracting")
+	flags.BoolVarP(&f.pipe, "pipe", "p", false, "Extract files to stdout, with no messages")
+	flags.StringVarP(&f.outputDir, "directory", "d", ".", "Extract files into directory")
+	flags.BoolVarP(&f.junkPaths, "junk-paths", "j", false, "Junk (ignore) directory paths")
+	flags.BoolVar(&f.noMacMeta, "no-mac-metadata", false, "Skip __MACOSX, .DS_Store and ._* AppleDouble entries")
+	flags.StringVar(&f.caseColl, "case-collisions", "error", "On case-insensitive filesystems, when names differ only by case: error, rename, or last-wins")
+	flags.StringVar(&f.reserved, "reserved-names", "sanitize", "On Windows, prefix device names like CON and NUL with _ (sanitize) or fail (error)")
+	flags.IntVar(&f.strip, "strip-components", 0, "Remove this many leading path elements from entry names")
+	flags.StringArrayVarP(&f.excludes, "exclude", "x", nil, "Exclude entries matching pattern; as in unzip, every argument after -x is a pattern")
+	flags.BoolVarP(&f.owners, "restore-owner", "X", false, "Restore UID/GID info (requires root)")
+	flags.BoolVarP(&f.keepSuid, "keep-setuid", "K", false, "Keep setuid/setgid file attributes")
+	flags.StringVar(&f.order, "order", "archive", "Extraction order: archive or smallest")
+	flags.StringArrayVar(&f.priority, "priority", nil, "Extract files matching pattern first")
+	flags.StringVarP(&f.charset, "charset", "O", "", "Decode non-UTF-8 names from charset (e.g. cp949, cp437, shift-jis, gbk)")
+	flags.CountVarP(&f.textConv, "convert-text", "a", "Convert line endings of entries marked as text to this system's, and their characters from the -O charset to UTF-8; -aa converts every file")
+	flags.StringVar(&f.execCmd, "exec", "", "Run command for each extracted file; {} is replaced by its path")
+	flags.IntVar(&f.threads, "threads", runtime.GOMAXPROCS(0), "Number of entries extracted at once; 1 extracts them one at a time, in order")
+	flags.IntVar(&f.execJobs, "exec-jobs", 0, "Maximum concurrent --exec commands (default: number of CPUs)")
+	flags.StringVar(&f.fileMode, "file-mode", "0644", "Octal mode for files without stored Unix permissions")
+	flags.StringVar(&f.dirMode, "dir-mode", "0755", "Octal mode for created directories")
+	flags.BoolVar(&f.umask, "honor-umask", false, "Apply the process umask to restored permissions")
+	flags.BoolVar(&f.copyLinks, "materialize-symlinks", false, "Extract symlinks as copies of their targets")
+	flags.BoolVar(&f.keepBad, "keep-corrupt", false, "Keep files that fail CRC verification")
+	flags.BoolVar(&f.mmap, "mmap", false, "Read the archive through a memory mapping (64-bit Unix only)")
+	flags.Int64Var(&f.rateLimit, "rate-limit", 0, "Read the archive at most this many bytes per second (0: unlimited)")
+	flags.BoolVar(&f.force, "force", false, "Extract even if the destination lacks free space")
+	flags.BoolVar(&f.keepGoing, "continue-on-error", false, "Skip entries that fail to extract and report them at the end")
+	flags.BoolVar(&f.progress, "progress", false, "Show a progress bar with throughput and ETA on standard error")
+	flags.BoolVar(&f.dryRun, "dry-run", false, "Show what would be extracted, skipped or overwritten without writing anything")
+	flags.Int64Var(&f.maxBytes, "max-archive-size", 0, "Refuse archives larger than this many bytes, including standard input (0: unlimited)")
+	flags.IntVar(&f.maxFiles, "max-entries", 0, "Refuse archives with more entries than this (0: unlimited)")
+	flags.Int64Var(&f.maxSize, "max-entry-size", 0, "Refuse entries larger than this many bytes (0: unlimited)")
+	flags.IntVar(&f.maxDepth, "max-depth", 0, "Refuse entries nested deeper than this (0: unlimited)")
+	flags.IntVar(&f.maxPath, "max-path-length", 0, "Refuse entry names longer than this many bytes (0: unlimited)")
+	flags.StringVar(&f.outFormat, "output", "text", "Result format: text, or json for a summary document")
+	flags.StringVar(&f.outFile, "output-file", "", "Write the json result to file (default: stderr)")
+	flags.StringVar(&f.logFormat, "log-format", "text", "Status message format: text, or json for one object per entry")
+	flags.StringVar(&f.since, "since", "", "List only entries modified on or after date (YYYY-MM-DD or RFC 3339)")
+	flags.StringVar(&f.until, "until", "", "List only entries modified before date (YYYY-MM-DD or RFC 3339)")
+	flags.BoolVarP(&f.noCase, "case-insensitive", "C", false, "Match file patterns regardless of case")
+	flags.StringVar(&f.syntax, "match", "glob", "Pattern syntax: glob, doublestar, regexp or gitignore")
+}
+
+// check rejects flags that cannot be combined with each other or with the
+// archive at zipPath.
+func (f *unzipFlags) check(zipPath string) error {
+	if f.threads < 1 {
+		return errors.New("--threads must be at least 1")
+	}
+	if zipPath == "-" && (f.list || f.verbose || f.info || f.pipe || f.test) {
+		return errors.New("-l, -v, -Z, -p and -t cannot read standard input")
+	}
+	return f.checkListing()
+}
+
+// checkListing rejects the flags of listings without the listing that
+// honors them, rather than extracting with them silently ignored.
+func (f *unzipFlags) checkListing() error {
+	switch {
+	case (f.names || f.short || f.medium) && !f.info:
+		return errors.New("-1, -s and -m need -Z")
+	case (f.since != "" || f.until != "") && !f.list && !f.verbose && !f.info:
+		return errors.New("--since and --until need -l, -v or -Z")
+	}
+	return nil
+}
+
+// listOptions returns the options of the listings of -l, -v and -Z. The
+// manifest of an incremental backup is left out unless args name it.
+func (f *unzipFlags) listOptions(args []string) (ziplib.ListOptions, error) {
+	opts := ziplib.ListOptions{Encoding: f.charset, Mmap: f.mmap, SkipManifest: !namesManifest(args)}
+	var err error
+	if opts.Since, err = parseDate(f.since); err != nil {
+		return opts, err
+	}
+	if opts.Until, err = parseDate(f.until); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// namesManifest reports whether patterns name the manifest of an
+// incremental backup, which gounzip otherwise leaves out as bookkeeping.
+func namesManifest(patterns []string) bool {
+	return slices.Contains(patterns, ziplib.ManifestName)
+}
+
+// unzipOptions returns the options of Unzip selected by the flags, to
+// extract the entries matching patterns.
+func (f *unzipFlags) unzipOptions(patterns []string) (ziplib.UnzipOptions, error) {
+	onConflict, err := f.conflictPolicy()
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	reservedNames, err := ziplib.ParseReservedNamePolicy(f.reserved)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	caseCollisions, err := ziplib.ParseCaseCollisionPolicy(f.caseColl)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	extractOrder, err := parseOrder(f.order)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	matchSyntax, err := ziplib.ParseMatchSyntax(f.syntax)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	logger, err := report.NewLogger(f.logFormat, os.Stdout)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	fmode, err := parseMode(f.fileMode)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+	dmode, err := parseMode(f.dirMode)
+	if err != nil {
+		return ziplib.UnzipOptions{}, err
+	}
+
+	opts := ziplib.UnzipOptions{
+		OutputDir:           f.outputDir,
+		Overwrite:           f.overwrite,
+		OnConflict:          onConflict,
+		Backup:              f.backup,
+		Freshen:             f.freshen,
+		Update:              f.update,
+		KeepNewer:           f.keepNewer,
+		JunkPaths:           f.junkPaths,
+		StripComponents:     f.strip,
+		ReservedNames:       reservedNames,
+		CaseCollisions:      caseCollisions,
+		FilePatterns:        patterns,
+		ExcludePatterns:     f.excludes,
+		MatchSyntax:         matchSyntax,
+		CaseInsensitive:     f.noCase,
+		ExcludeMacMetadata:  f.noMacMeta,
+		SkipManifest:        !namesManifest(patterns),
+		Encoding:            f.charset,
+		ConvertText:         f.textConversion(),
+		ExecCommand:         f.execCmd,
+		ExecParallel:        f.execJobs,
+		Concurrency:         f.threads,
+		FileMode:            fmode,
+		DirMode:             dmode,
+		HonorUmask:          f.umask,
+		MaterializeSymlinks: f.copyLinks,
+		KeepCorrupt:         f.keepBad,
+		Mmap:                f.mmap,
+		RateLimit:           f.rateLimit,
+		Force:               f.force,
+		DryRun:              f.dryRun,
+		MaxArchiveSize:      f.maxBytes,
+		MaxEntries:          f.maxFiles,
+		MaxEntrySize:        f.maxSize,
+		MaxPathDepth:        f.maxDepth,
+		MaxPathLength:       f.maxPath,
+		Order:               extractOrder,
+		PriorityPatterns:    f.priority,
+		AllowSetuid:         f.keepSuid,
+		RestoreOwnership:    f.owners,
+		Verbosity:           f.verbosity(),
+		Logger:              logger,
+		Output:              os.Stdout,
+	}
+	if f.prompts(onConflict) {
+		opts.ConflictPrompt = conflictPrompt(os.Stdin, os.Stdout)
+	}
+	if f.keepGoing {
+		opts.OnError = skipFailed
+	}
+	return opts, nil
+}
+
+// conflictPolicy returns the policy for existing files selected by
+// --on-conflict, or by -n.
+func (f *unzipFlags) conflictPolicy() (ziplib.ConflictPolicy, error) {
+	onConflict, err := ziplib.ParseConflictPolicy(f.conflict)
+	if err != nil {
+		return 0, err
+	}
+	if !f.never {
+		return onConflict, nil
+	}
+	if f.overwrite || onConflict != ziplib.ConflictError {
+		return 0, errors.New("-n cannot be combined with -o or --on-conflict")
+	}
+	return ziplib.ConflictSkip, nil
+}
+
+// prompts reports whether to ask on the terminal what to do with each
+// existing file, as unzip does when nothing else decides it.
+func (f *unzipFlags) prompts(onConflict ziplib.ConflictPolicy) bool {
+	return !f.overwrite && onConflict == ziplib.ConflictError && !f.freshen && !f.update && !f.dryRun && isTerminal(os.Stdin)
+}
+
+// verbosity returns the verbosity selected by -q or -qq.
+func (f *unzipFlags) verbosity() ziplib.Verbosity {
+	switch {
+	case f.quiet == 1:
+		return ziplib.VerbosityQuiet
+	case f.quiet > 1:
+		return ziplib.VerbositySilent
+	}
+	return ziplib.VerbosityNormal
+}
+
+// textConversion returns the conversion of text entries selected by -a
+// or -aa.
+func (f *unzipFlags) textConversion() ziplib.TextConversion {
+	switch {
+	case f.textConv == 1:
+		return ziplib.TextConvertMarked
+	case f.textConv > 1:
+		return ziplib.TextConvertAll
+	}
+	return ziplib.TextConvertNone
+}
+
+// skipFailed reports an entry that failed to extract and skips it, for
+// --continue-on-error.
+func skipFailed(entry string, err error) ziplib.ErrorAction {
+	fmt.Fprintf(os.Stderr, "gounzip: skipping %s: %v\n", entry, err)
+	return ziplib.ErrorSkip
+}
@@ -2,68 +2,256 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jaeyeom/gozip/internal/buildinfo"
+	"github.com/jaeyeom/gozip/internal/report"
+	"github.com/jaeyeom/gozip/internal/shellwords"
 	"github.com/jaeyeom/gozip/ziplib"
 	"github.com/spf13/cobra"
 )
 
 func main() {
-	var (
-		list      bool
-		overwrite bool
-		outputDir string
-		junkPaths bool
-	)
-
+	var f unzipFlags
 	rootCmd := &cobra.Command{
 		Use:   "gounzip [flags] zipfile [file ...]",
 		Short: "Extract zip archives",
-		Long:  "gounzip extracts zip archives, compatible with standard unzip.",
-		Args:  cobra.MinimumNArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			zipPath := args[0]
-			filePatterns := args[1:]
-
-			if list {
-				return listArchive(zipPath)
-			}
-
-			opts := ziplib.UnzipOptions{
-				OutputDir:    outputDir,
-				Overwrite:    overwrite,
-				JunkPaths:    junkPaths,
-				FilePatterns: filePatterns,
-				Output:       os.Stdout,
-			}
-
-			return ziplib.Unzip(zipPath, opts)
-		},
+		Long: "gounzip extracts zip archives, compatible with standard unzip.\n" +
+			"zipfile may be an http(s), s3:// or gs:// URL; only the bytes needed are downloaded.\n" +
+			"zipfile \"-\" reads the archive from standard input, which is first copied to a\n" +
+			"temporary file as the entries are listed at its end; --max-archive-size bounds the copy.",
+		Args:         cobra.MinimumNArgs(1),
+		RunE:         f.run,
 		SilenceUsage: true,
 	}
+	f.register(rootCmd)
 
-	rootCmd.Flags().BoolVarP(&list, "list", "l", false, "List archive contents")
-	rootCmd.Flags().BoolVarP(&overwrite, "overwrite", "o", false, "Overwrite existing files")
-	rootCmd.Flags().StringVarP(&outputDir, "directory", "d", ".", "Extract files into directory")
-	rootCmd.Flags().BoolVarP(&junkPaths, "junk-paths", "j", false, "Junk (ignore) directory paths")
+	buildinfo.AddTo(rootCmd, "gounzip")
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
+	env, err := envOptions(rootCmd, "GOUNZIP_OPTS", os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	rootCmd.SetArgs(append(expandExcludes(env), expandExcludes(os.Args[1:])...))
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
-func listArchive(zipPath string) error {
-	entries, err := ziplib.List(zipPath)
+// run lists, tests, pipes or extracts the archive args[0], limited to the
+// entries matching args[1:] if any.
+func (f *unzipFlags) run(_ *cobra.Command, args []string) error {
+	zipPath, patterns := args[0], args[1:]
+	if err := f.check(zipPath); err != nil {
+		return err
+	}
+	switch {
+	case f.info:
+		return f.showZipinfo(zipPath, patterns)
+	case f.list || f.verbose:
+		return f.showList(zipPath, patterns)
+	case f.test:
+		return f.testArchive(zipPath, patterns)
+	case f.pipe:
+		return pipeArchive(zipPath, patterns, f.excludes, f.syntax, f.noCase, f.noMacMeta)
+	}
+	return f.extract(args)
+}
+
+// showZipinfo lists the archive at zipPath like zipinfo, for -Z; args
+// may name the manifest to list it too.
+func (f *unzipFlags) showZipinfo(zipPath string, args []string) error {
+	format, err := zipinfoFormatOf(f.names, f.short, f.medium, f.list)
+	if err != nil {
+		return err
+	}
+	opts, err := f.listOptions(args)
+	if err != nil {
+		return err
+	}
+	return zipinfo(zipPath, format, opts)
+}
+
+// showList lists the archive at zipPath like unzip -l or -v, followed by
+// its statistics with --totals; args may name the manifest to list it too.
+func (f *unzipFlags) showList(zipPath string, args []string) error {
+	opts, err := f.listOptions(args)
 	if err != nil {
-		return fmt.Errorf("listing archive: %w", err)
+		return err
 	}
+	listFn := listArchive
+	if f.verbose {
+		listFn = listVerbose
+	}
+	if err := listFn(zipPath, opts); err != nil || !f.totals {
+		return err
+	}
+	s, err := stats(zipPath)
+	if err != nil {
+		return err
+	}
+	fmt.Println()
+	report.WriteStats(os.Stdout, s)
+	return nil
+}
 
-	fmt.Printf("  Length      Date    Time    Name\n")
-	fmt.Printf("---------  ---------- -----   ----\n")
+// testArchive checks the entries of the archive at zipPath matching
+// patterns, for -t.
+func (f *unzipFlags) testArchive(zipPath string, patterns []string) error {
+	if isRemote(zipPath) {
+		return errors.New("-t cannot read remote archives")
+	}
+	matchSyntax, err := ziplib.ParseMatchSyntax(f.syntax)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Archive:  %s\n", zipPath)
+	return ziplib.Verify(zipPath, ziplib.VerifyOptions{
+		FilePatterns:    patterns,
+		ExcludePatterns: f.excludes,
+		MatchSyntax:     matchSyntax,
+		CaseInsensitive: f.noCase,
+		Encoding:        f.charset,
+		Output:          os.Stdout,
+	})
+}
+
+// extract extracts the archive args[0], or with --restore every archive
+// of args in order.
+func (f *unzipFlags) extract(args []string) error {
+	zipPath := args[0]
+	jsonReport, err := report.ParseFormat(f.outFormat)
+	if err != nil {
+		return err
+	}
+	opts, err := f.unzipOptions(args[1:])
+	if err != nil {
+		return err
+	}
+	run := func() error { return unzip(zipPath, opts) }
+	if f.restore {
+		if run, err = restoreChain(args, &opts); err != nil {
+			return err
+		}
+	}
+	if f.progress && !f.dryRun {
+		run = f.withProgress(&opts, run)
+	}
+	if !jsonReport {
+		return run()
+	}
+	var res ziplib.Result
+	opts.Result = &res
+	start := time.Now()
+	err = run()
+	r := report.New("gounzip", zipPath, res, time.Since(start), err)
+	return errors.Join(err, report.Write(f.outFile, r))
+}
+
+// restoreChain returns the function restoring the incremental backup
+// chain of archives with opts.
+func restoreChain(archives []string, opts *ziplib.UnzipOptions) (func() error, error) {
+	for _, p := range archives {
+		if p == "-" || isRemote(p) {
+			return nil, errors.New("--restore needs local archive files")
+		}
+	}
+	// Every argument is an archive of the chain.
+	opts.FilePatterns = nil
+	return func() error { return ziplib.Restore(archives, *opts) }, nil
+}
+
+// withProgress makes run show a progress bar on standard error, with
+// status messages printed above it.
+func (f *unzipFlags) withProgress(opts *ziplib.UnzipOptions, run func() error) func() error {
+	bar := report.NewProgress(os.Stderr, 0)
+	opts.OnProgress = bar.Update
+	opts.Output = bar.Above(os.Stdout)
+	if opts.Logger != nil {
+		opts.Logger, _ = report.NewLogger(f.logFormat, opts.Output)
+	}
+	return func() error {
+		defer bar.Finish()
+		return run()
+	}
+}
+
+func parseOrder(s string) (ziplib.ExtractOrder, error) {
+	switch s {
+	case "archive":
+		return ziplib.OrderArchive, nil
+	case "smallest":
+		return ziplib.OrderSmallestFirst, nil
+	default:
+		return 0, fmt.Errorf("invalid order %q: must be archive or smallest", s)
+	}
+}
+
+func parseMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m > 0o777 {
+		return 0, fmt.Errorf("invalid mode %q: must be octal permissions like 0755", s)
+	}
+	return os.FileMode(m), nil
+}
+
+// zipinfoFormatOf returns the -Z format selected by -1, -s, -m or -l, of
+// which at most one may be given.
+func zipinfoFormatOf(names, short, medium, long bool) (zipinfoFormat, error) {
+	format, n := zipinfoShort, 0
+	for _, f := range []struct {
+		set    bool
+		format zipinfoFormat
+	}{{names, zipinfoNames}, {short, zipinfoShort}, {medium, zipinfoMedium}, {long, zipinfoLong}} {
+		if f.set {
+			format = f.format
+			n++
+		}
+	}
+	if n > 1 {
+		return 0, errors.New("-1, -s, -m and -l cannot be combined")
+	}
+	return format, nil
+}
+
+// parseDate parses a date in local time or an RFC 3339 timestamp. An empty
+// string yields the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
 
+// listArchive prints the entries of the archive at zipPath as they are
+// read, so that huge archives are listed in constant memory.
+func listArchive(zipPath string, opts ziplib.ListOptions) error {
+	header := false
 	var totalSize uint64
-	for _, e := range entries {
+	var count int
+	fmt.Printf("Archive:  %s\n", zipPath)
+	for e, err := range entries(zipPath, opts) {
+		if err != nil {
+			return fmt.Errorf("listing archive: %w", err)
+		}
+		if !header {
+			printListHeader()
+			header = true
+		}
 		mod := e.Modified
 		fmt.Printf("%9d  %04d-%02d-%02d %02d:%02d   %s\n",
 			e.UncompressedSize,
@@ -72,10 +260,111 @@ func listArchive(zipPath string) error {
 			e.Name,
 		)
 		totalSize += e.UncompressedSize
+		count++
+	}
+	if !header {
+		printListHeader()
 	}
 
 	fmt.Printf("---------                     -------\n")
-	fmt.Printf("%9d                     %d files\n", totalSize, len(entries))
+	fmt.Printf("%9d                     %d files\n", totalSize, count)
+
+	return nil
+}
+
+// printListHeader prints the column headings of listArchive.
+func printListHeader() {
+	fmt.Printf("  Length      Date    Time    Name\n")
+	fmt.Printf("---------  ---------- -----   ----\n")
+}
 
+// pipeArchive writes the contents of the file entries matching patterns,
+// or of all file entries if there are none, to standard output in archive
+// order. macOS metadata entries are left out if noMacMeta is set, and the
+// manifest of an incremental backup unless patterns name it. Of a
+// remote archive, only the central directory and those entries are
+// fetched.
+func pipeArchive(zipPath string, patterns, excludes []string, syntax string, caseInsensitive, noMacMeta bool) error {
+	match, err := pipeMatcher(patterns, excludes, syntax, caseInsensitive, noMacMeta)
+	if err != nil {
+		return err
+	}
+	r, closeArchive, err := openArchive(zipPath)
+	if err != nil {
+		return err
+	}
+	defer closeArchive() //nolint:errcheck // Only read from.
+	n, err := ziplib.ExtractMatchingToReader(r, r.Size(), match, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if n == 0 && len(patterns) > 0 {
+		return fmt.Errorf("filename not matched: %s", strings.Join(patterns, " "))
+	}
 	return nil
 }
+
+// pipeMatcher returns the function selecting the entries pipeArchive
+// writes.
+func pipeMatcher(patterns, excludes []string, syntax string, caseInsensitive, noMacMeta bool) (func(string) bool, error) {
+	matchSyntax, err := ziplib.ParseMatchSyntax(syntax)
+	if err != nil {
+		return nil, err
+	}
+	newMatcher := ziplib.NewMatcher
+	if caseInsensitive {
+		newMatcher = ziplib.NewCaseInsensitiveMatcher
+	}
+	include, err := newMatcher(matchSyntax, patterns)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := newMatcher(matchSyntax, excludes)
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		if len(patterns) > 0 && !include.Match(name, false) {
+			return false
+		}
+		if name == ziplib.ManifestName && !namesManifest(patterns) {
+			return false
+		}
+		return !exclude.Match(name, false) && !(noMacMeta && ziplib.IsMacMetadata(name))
+	}, nil
+}
+
+// envOptions returns the default flags held by the environment variable
+// name for the command line args. The version subcommand has flags of its
+// own and gets none.
+func envOptions(root *cobra.Command, name string, args []string) ([]string, error) {
+	root.InitDefaultHelpCmd()
+	if cmd, _, err := root.Find(args); err == nil && cmd != root {
+		return nil, nil
+	}
+	return shellwords.FromEnv(name)
+}
+
+// expandExcludes rewrites the unzip form "-x pattern1 pattern2 ..." into
+// one -x flag per pattern, so that every argument after a bare -x up to the
+// next flag is an exclude pattern rather than a member to extract.
+func expandExcludes(args []string) []string {
+	out := make([]string, 0, len(args))
+	excluding := false
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return append(out, args[i:]...)
+		case arg == "-x" || arg == "--exclude":
+			excluding = true
+			continue
+		case excluding && !strings.HasPrefix(arg, "-"):
+			out = append(out, "-x", arg)
+			continue
+		default:
+			excluding = false
+		}
+		out = append(out, arg)
+	}
+	return out
+}
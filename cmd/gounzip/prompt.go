@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// conflictPrompt returns a ConflictPrompt that asks about each existing
+// file like unzip does, reading answers from in. End of input is taken
+// as [N]one.
+func conflictPrompt(in io.Reader, out io.Writer) func(string) ziplib.ConflictDecision {
+	r := bufio.NewReader(in)
+	return func(path string) ziplib.ConflictDecision {
+		for {
+			fmt.Fprintf(out, "replace %s? [y]es, [n]o, [A]ll, [N]one, [r]ename: ", path)
+			line, err := r.ReadString('\n')
+			switch strings.TrimSpace(line) {
+			case "y":
+				return ziplib.DecisionOverwrite
+			case "n":
+				return ziplib.DecisionSkip
+			case "A":
+				return ziplib.DecisionOverwriteAll
+			case "N":
+				return ziplib.DecisionSkipAll
+			case "r":
+				return ziplib.DecisionRename
+			}
+			if err != nil {
+				fmt.Fprintln(out, "(EOF or read error, treating as \"[N]one\")")
+				return ziplib.DecisionSkipAll
+			}
+			fmt.Fprintf(out, "error:  invalid response [%s]\n", strings.TrimSpace(line))
+		}
+	}
+}
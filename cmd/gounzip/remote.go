@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"os"
+
+	"github.com/jaeyeom/gozip/blobstore"
+	"github.com/jaeyeom/gozip/httpzip"
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// isRemote reports whether zipPath names a remote archive: an http or
+// https URL, or an s3:// or gs:// object store URL.
+func isRemote(zipPath string) bool {
+	return httpzip.IsURL(zipPath) || blobstore.IsURL(zipPath)
+}
+
+// openRemote opens the remote archive at zipPath for ranged reads.
+func openRemote(zipPath string) (blobstore.Object, error) {
+	if httpzip.IsURL(zipPath) {
+		r, err := httpzip.Open(zipPath, nil)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	store, key, err := blobstore.Parse(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	return store.Open(key) //nolint:wrapcheck // Store errors are already prefixed.
+}
+
+// openArchive opens the archive at zipPath, which may be remote, for
+// ranged reads. The returned function releases it.
+func openArchive(zipPath string) (blobstore.Object, func() error, error) {
+	if isRemote(zipPath) {
+		r, err := openRemote(zipPath)
+		return r, func() error { return nil }, err
+	}
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open archive: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("open archive: %w", err)
+	}
+	return io.NewSectionReader(f, 0, fi.Size()), f.Close, nil
+}
+
+// unzip extracts the archive at zipPath, which may be remote (see
+// isRemote) or "-" for standard input.
+func unzip(zipPath string, opts ziplib.UnzipOptions) error {
+	if zipPath == "-" {
+		return ziplib.UnzipStream(os.Stdin, opts)
+	}
+	if !isRemote(zipPath) {
+		return ziplib.Unzip(zipPath, opts)
+	}
+	r, err := openRemote(zipPath)
+	if err != nil {
+		return err
+	}
+	return ziplib.UnzipReader(r, r.Size(), opts)
+}
+
+// entries returns an iterator over the entries of the archive at
+// zipPath, which may be remote. Only the central directory of a remote
+// archive is fetched.
+func entries(zipPath string, opts ziplib.ListOptions) iter.Seq2[ziplib.ListEntry, error] {
+	if !isRemote(zipPath) {
+		return ziplib.EntriesWithOptions(zipPath, opts)
+	}
+	return func(yield func(ziplib.ListEntry, error) bool) {
+		r, err := openRemote(zipPath)
+		if err != nil {
+			yield(ziplib.ListEntry{}, err)
+			return
+		}
+		ziplib.EntriesReaderWithOptions(r, r.Size(), opts)(yield)
+	}
+}
+
+// stats returns the statistics of the archive at zipPath, which may be
+// remote.
+func stats(zipPath string) (ziplib.ArchiveStats, error) {
+	if !isRemote(zipPath) {
+		return ziplib.Stats(zipPath)
+	}
+	r, err := openRemote(zipPath)
+	if err != nil {
+		return ziplib.ArchiveStats{}, err
+	}
+	return ziplib.StatsReader(r, r.Size())
+}
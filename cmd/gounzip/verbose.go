@@ -0,0 +1,103 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// listVerbose prints the contents of the archive at zipPath with their
+// method, compressed size, compression factor and CRC-32, in the format
+// of unzip -v.
+func listVerbose(zipPath string, opts ziplib.ListOptions) error {
+	var usize, csize uint64
+	var count int
+	fmt.Printf("Archive:  %s\n", zipPath)
+	fmt.Printf(" Length   Method    Size  Cmpr    Date    Time   CRC-32   Name\n")
+	fmt.Printf("--------  ------  ------- ---- ---------- ----- --------  ----\n")
+	for e, err := range entries(zipPath, opts) {
+		if err != nil {
+			return fmt.Errorf("listing archive: %w", err)
+		}
+		mod := e.Modified
+		fmt.Printf("%8d  %-6s %8d %4s %04d-%02d-%02d %02d:%02d %08x  %s\n",
+			e.UncompressedSize, methodLabel(e.Method, e.Flags), e.CompressedSize,
+			compressionFactor(e.UncompressedSize, e.CompressedSize),
+			mod.Year(), mod.Month(), mod.Day(), mod.Hour(), mod.Minute(),
+			e.CRC32, e.Name,
+		)
+		usize += e.UncompressedSize
+		csize += e.CompressedSize
+		count++
+	}
+	fmt.Printf("--------          -------  ---                            -------\n")
+	fmt.Printf("%8d         %8d %4s                            %s\n",
+		usize, csize, compressionFactor(usize, csize), pluralFiles(count))
+	return nil
+}
+
+// methodLabel returns the name unzip -v shows for a compression method:
+// deflate is followed by its level class, N, X, F or S, from flags.
+func methodLabel(method, flags uint16) string {
+	level := "NXFS"[flags>>1&3]
+	switch method {
+	case zip.Store:
+		return "Stored"
+	case zip.Deflate:
+		return fmt.Sprintf("Defl:%c", level)
+	case 9:
+		return fmt.Sprintf("Def64%c", level)
+	case 12:
+		return "BZip2"
+	case 14:
+		return "LZMA"
+	case 93:
+		return "Zstd"
+	case 95:
+		return "XZ"
+	case 98:
+		return "PPMd"
+	case 99:
+		return "AES"
+	default:
+		return fmt.Sprintf("Unk:%03d", method)
+	}
+}
+
+// compressionFactor returns the space saved by compressing usize bytes
+// to csize bytes as unzip -v shows it, e.g. " 85%", "100%" or "-12%",
+// rounded like unzip does. A growth that rounds to zero shows as " 0%".
+func compressionFactor(usize, csize uint64) string {
+	if usize == 0 {
+		return " 0%"
+	}
+	sign, saved := ' ', usize-csize
+	if csize > usize {
+		sign, saved = '-', csize-usize
+	}
+	// Per mille, scaled down first for large sizes to avoid overflow.
+	var permille uint64
+	if usize > 2000000 {
+		denom := usize / 1000
+		permille = (saved + denom/2) / denom
+	} else {
+		permille = (1000*saved + usize/2) / usize
+	}
+	percent := (permille + 5) / 10
+	switch {
+	case percent == 0:
+		return " 0%"
+	case sign == ' ' && percent == 100:
+		return "100%"
+	}
+	return fmt.Sprintf("%c%d%%", sign, percent)
+}
+
+// pluralFiles returns "1 file" or "n files".
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
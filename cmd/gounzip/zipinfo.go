@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// zipinfoFormat is the listing format of -Z, as selected by zipinfo's
+// -1, -s, -m and -l options.
+type zipinfoFormat int
+
+const (
+	zipinfoNames  zipinfoFormat = iota // -1: names only
+	zipinfoShort                       // -s: the default
+	zipinfoMedium                      // -m: with the compression factor
+	zipinfoLong                        // -l: with the compressed size
+)
+
+// Host systems of the "version made by" field with attributes of their
+// own; the others are shown like Unix.
+const (
+	hostFAT  = 0
+	hostHPFS = 6
+	hostNTFS = 11
+)
+
+// hostNames are the names zipinfo shows for the host systems of the
+// "version made by" field, by number.
+var hostNames = []string{
+	"fat", "ami", "vms", "unx", "cms", "atr", "hpf", "mac", "zzz", "cpm",
+	"t20", "ntf", "qds", "aco", "vft", "mvs", "be ", "nsk", "ths", "osx",
+}
+
+// zipinfo prints the contents of the archive at zipPath, which may be
+// remote, in the given format of zipinfo.
+func zipinfo(zipPath string, format zipinfoFormat, opts ziplib.ListOptions) error {
+	r, closeArchive, err := openArchive(zipPath)
+	if err != nil {
+		return err
+	}
+	defer closeArchive() //nolint:errcheck // Only read from.
+	list, err := ziplib.ListReaderWithOptions(r, r.Size(), opts)
+	if err != nil {
+		return fmt.Errorf("listing archive: %w", err)
+	}
+	if format == zipinfoNames {
+		for _, e := range list {
+			fmt.Println(e.Name)
+		}
+		if len(list) == 0 {
+			fmt.Println("Empty zipfile.")
+		}
+		return nil
+	}
+
+	fmt.Printf("Archive:  %s\n", zipPath)
+	fmt.Printf("Zip file size: %d bytes, number of entries: %d\n", r.Size(), len(list))
+	if len(list) == 0 {
+		fmt.Println("Empty zipfile.")
+		return nil
+	}
+	var usize, csize uint64
+	for _, e := range list {
+		var column string
+		switch format {
+		case zipinfoMedium:
+			column = fmt.Sprintf("%3d%%", (zipinfoRatio(e.UncompressedSize, e.CompressedSize)+5)/10)
+		case zipinfoLong:
+			column = fmt.Sprintf(" %8d", e.CompressedSize)
+		}
+		host := e.CreatorVersion >> 8
+		fmt.Printf("%-10s %2d.%d %s %8d %s%s %s %s %s\n",
+			modeString(e), e.CreatorVersion&0xff/10, e.CreatorVersion&0xff%10, hostName(host),
+			e.UncompressedSize, entryKind(e), column, methodAbbrev(e.Method, e.Flags),
+			e.Modified.Format("06-Jan-02 15:04"), e.Name,
+		)
+		usize += e.UncompressedSize
+		csize += e.CompressedSize
+	}
+	ratio := zipinfoRatio(usize, csize)
+	sign := ""
+	if ratio < 0 {
+		sign, ratio = "-", -ratio
+	}
+	fmt.Printf("%s, %d bytes uncompressed, %d bytes compressed:  %s%d.%d%%\n",
+		pluralFiles(len(list)), usize, csize, sign, ratio/10, ratio%10)
+	return nil
+}
+
+// hostName returns the three-letter name of a host system.
+func hostName(host uint16) string {
+	if int(host) < len(hostNames) {
+		return hostNames[host]
+	}
+	return "???"
+}
+
+// modeString returns the attributes of e as zipinfo shows them: a Unix
+// mode like "-rw-r--r--", or for entries made on MS-DOS, OS/2 or Windows
+// without a Unix mode, their attributes like "-rw-a--".
+func modeString(e ziplib.ListEntry) string {
+	host := e.CreatorVersion >> 8
+	mode := e.ExternalAttrs >> 16
+	attrs := e.ExternalAttrs & 0xff
+	switch host {
+	case hostFAT, hostHPFS, hostNTFS:
+		// Info-ZIP for MS-DOS stores a Unix mode matching the attributes;
+		// any other is shown as the attributes.
+		if host != hostFAT || mode&0o700 != dosMode(attrs) {
+			return dosAttrString(attrs, e.Name)
+		}
+	}
+	return unixModeString(mode)
+}
+
+// dosMode returns the Unix owner permissions matching the MS-DOS
+// attributes attrs: read-only, and directory for execute.
+func dosMode(attrs uint32) uint32 {
+	var mode uint32 = 0o400
+	if attrs&0x01 == 0 {
+		mode |= 0o200
+	}
+	if attrs&0x10 != 0 {
+		mode |= 0o100
+	}
+	return mode
+}
+
+// dosAttrString returns the MS-DOS attributes attrs of the entry name as
+// zipinfo shows them, like "-rw-a--". Names with the extension of an
+// MS-DOS program are shown as executable.
+func dosAttrString(attrs uint32, name string) string {
+	b := []byte("-r-----")
+	for _, a := range []struct {
+		bit uint32
+		col int
+		c   byte
+		set bool
+	}{{0x01, 2, 'w', false}, {0x20, 4, 'a', true}, {0x02, 5, 'h', true}, {0x04, 6, 's', true}} {
+		if (attrs&a.bit != 0) == a.set {
+			b[a.col] = a.c
+		}
+	}
+	switch ext := strings.ToLower(path.Ext(name)); {
+	case attrs&0x08 != 0:
+		b[0] = 'V'
+	case attrs&0x10 != 0:
+		b[0], b[3] = 'd', 'x'
+	case ext == ".com" || ext == ".exe" || ext == ".btm" || ext == ".cmd" || ext == ".bat":
+		b[3] = 'x'
+	}
+	return string(b)
+}
+
+// fileTypes are the characters ls and zipinfo show for the Unix file
+// types.
+var fileTypes = map[uint32]byte{
+	0o040000: 'd',
+	0o100000: '-',
+	0o120000: 'l',
+	0o060000: 'b',
+	0o020000: 'c',
+	0o010000: 'p',
+	0o140000: 's',
+}
+
+// unixModeString returns the Unix mode as zipinfo shows it, like
+// "-rw-r--r--".
+func unixModeString(mode uint32) string {
+	b := []byte("?---------")
+	if c, ok := fileTypes[mode&0o170000]; ok {
+		b[0] = c
+	}
+	const rwx = "rwxrwxrwx"
+	for i := range 9 {
+		if mode&(1<<(8-i)) != 0 {
+			b[1+i] = rwx[i]
+		}
+	}
+	// Setuid, setgid and sticky show in the execute columns, lower case
+	// if the execute bit is set too.
+	for i, special := range []struct {
+		bit uint32
+		c   byte
+	}{{0o4000, 's'}, {0o2000, 's'}, {0o1000, 't'}} {
+		if mode&special.bit == 0 {
+			continue
+		}
+		col := 3 + 3*i
+		if b[col] == 'x' {
+			b[col] = special.c
+		} else {
+			b[col] = special.c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
+
+// entryKind returns zipinfo's two-letter summary of e: t or b for text
+// or binary, upper case if encrypted, followed by -, l, x or X for
+// neither, a data descriptor, extra fields, or both.
+func entryKind(e ziplib.ListEntry) string {
+	kind := []byte("b-")
+	if e.Text {
+		kind[0] = 't'
+	}
+	if e.Flags&0x1 != 0 {
+		kind[0] -= 'a' - 'A'
+	}
+	switch descriptor := e.Flags&0x8 != 0; {
+	case descriptor && e.HasExtra:
+		kind[1] = 'X'
+	case descriptor:
+		kind[1] = 'l'
+	case e.HasExtra:
+		kind[1] = 'x'
+	}
+	return string(kind)
+}
+
+// methodAbbrev returns the four-letter name zipinfo shows for a
+// compression method: deflate is followed by its level class, N, X, F or
+// S, from flags.
+func methodAbbrev(method, flags uint16) string {
+	level := "NXFS"[flags>>1&3]
+	switch method {
+	case 0:
+		return "stor"
+	case 8:
+		return fmt.Sprintf("def%c", level)
+	case 9:
+		return fmt.Sprintf("edf%c", level)
+	case 12:
+		return "bzp2"
+	case 14:
+		return "lzma"
+	case 93:
+		return "zstd"
+	case 95:
+		return "xz  "
+	case 98:
+		return "ppmd"
+	default:
+		return fmt.Sprintf("u%03d", method)
+	}
+}
+
+// zipinfoRatio returns the space saved by compressing usize bytes to
+// csize bytes, in tenths of a percent and negative if the entry grew,
+// rounded like zipinfo does.
+func zipinfoRatio(usize, csize uint64) int {
+	if usize == 0 {
+		return 0
+	}
+	saved, sign := usize-csize, 1
+	if csize > usize {
+		saved, sign = csize-usize, -1
+	}
+	var permille uint64
+	if usize > 2000000 {
+		denom := usize / 1000
+		permille = (saved + denom/2) / denom
+	} else {
+		permille = (1000*saved + usize/2) / usize
+	}
+	return sign * int(permille) //nolint:gosec // At most 1000 times the growth factor.
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readComment reads an archive comment for -z from in. On a terminal it
+// prompts like zip and reads lines up to one holding only a period or
+// the end of input; otherwise all of in is the comment, less a final
+// newline.
+func readComment(in *os.File, prompt io.Writer) (string, error) {
+	fi, err := in.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		b, err := io.ReadAll(in)
+		if err != nil {
+			return "", fmt.Errorf("read comment: %w", err)
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r"), nil
+	}
+	fmt.Fprintln(prompt, "enter new zip file comment (end with .):")
+	var lines []string
+	s := bufio.NewScanner(in)
+	for s.Scan() && s.Text() != "." {
+		lines = append(lines, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		return "", fmt.Errorf("read comment: %w", err)
+	}
+	return strings.Join(lines, "\n"), nil
+}
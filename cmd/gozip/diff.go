@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jaeyeom/gozip/ziplib"
+	"github.com/spf13/cobra"
+)
+
+// newDiffCmd returns the diff subcommand, an alias of --diff.
+func newDiffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "diff old.zip new.zip",
+		Short: "Compare the entries of two zip archives, like --diff",
+		Long: "diff lists entries added, removed, or changed between two archives,\n" +
+			"comparing names, sizes, CRC-32 and modification times. It exits\n" +
+			"with a non-zero status if the archives differ.",
+		Args: usageArgs(cobra.ExactArgs(2)),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return diffArchives(args[0], args[1])
+		},
+		SilenceUsage: true,
+	}
+}
+
+// diffArchives lists the entries added, removed, or changed between the
+// archives at oldPath and newPath, and fails if there are any.
+func diffArchives(oldPath, newPath string) error {
+	report, err := ziplib.Diff(oldPath, newPath)
+	if err != nil {
+		return err
+	}
+	for _, e := range report.Removed {
+		fmt.Fprintf(os.Stdout, "removed: %s\n", e.Name)
+	}
+	for _, e := range report.Added {
+		fmt.Fprintf(os.Stdout, "  added: %s\n", e.Name)
+	}
+	for _, c := range report.Changed {
+		fmt.Fprintf(os.Stdout, "changed: %s (%s)\n", c.Name, strings.Join(c.Fields, ", "))
+	}
+	if !report.Empty() {
+		return errors.New("archives differ")
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/jaeyeom/gozip/ziplib"
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of Info-ZIP zip, which gozip follows so that scripts
+// branching on them work unmodified. Errors that fit none of them exit
+// with 1.
+const (
+	exitEOF      = 2  // unexpected end of zip file
+	exitFormat   = 3  // error in zip file format
+	exitTest     = 8  // archive test failed
+	exitTemp     = 10 // error using a temporary file
+	exitRead     = 11 // read or seek error
+	exitNothing  = 12 // nothing to do
+	exitWrite    = 14 // error writing to a file
+	exitCreate   = 15 // could not create a file to write to
+	exitUsage    = 16 // bad command line parameters
+	exitOpen     = 18 // could not open a specified file to read
+	exitFallback = 1
+)
+
+// usageError marks errors in the command line.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// usagef returns a usageError with the formatted message.
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// usageArgs returns check with its errors marked as usageErrors.
+func usageArgs(check cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := check(cmd, args); err != nil {
+			return usageError{err}
+		}
+		return nil
+	}
+}
+
+// exitCode returns the zip exit status for err.
+func exitCode(err error) int {
+	var (
+		usage   usageError
+		crc     *ziplib.CRCError
+		linkErr *os.LinkError
+		pathErr *fs.PathError
+	)
+	switch {
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, ziplib.ErrNothingToDo):
+		return exitNothing
+	case errors.As(err, &crc):
+		return exitTest
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return exitEOF
+	case errors.Is(err, zip.ErrFormat), errors.Is(err, zip.ErrAlgorithm), errors.Is(err, zip.ErrChecksum):
+		return exitFormat
+	case errors.Is(err, fs.ErrExist):
+		return exitCreate
+	case errors.As(err, &linkErr):
+		// Archives are written to a temporary file and renamed into place.
+		return exitTemp
+	case errors.As(err, &pathErr):
+		switch pathErr.Op {
+		case "open", "stat", "lstat":
+			return exitOpen
+		case "read", "seek":
+			return exitRead
+		case "write", "sync", "close", "truncate":
+			return exitWrite
+		default:
+			return exitCreate
+		}
+	default:
+		return exitFallback
+	}
+}
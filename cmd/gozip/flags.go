@@ -0,0 +1,429 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/jaeyeom/gozip/blobstore"
+	"github.com/jaeyeom/gozip/internal/report"
+	"github.com/jaeyeom/gozip/ziplib"
+	"github.com/spf13/cobra"
+)
+
+// zipFlags holds the command line flags of gozip.
+type zipFlags struct {
+	recursive       bool
+	excludePatterns []string
+	levels          [10]bool // -0 through -9
+	matchSyntax     string
+	nameCharset     string
+	outputFormat    string
+	outputFile      string
+	fix             int
+	fixOut          string
+	verify          bool
+	sample          string
+	seed            uint64
+	diff            bool
+	sfx             bool
+	sfxStub         string
+	sfxTarget       string
+	methodName      string
+	levelFor        []string
+	deterministic   bool
+	dryRun          bool
+	includePatterns []string
+	useIgnoreFiles  bool
+	excludeFrom     []string
+	includeFrom     []string
+	logFormat       string
+	stats           bool
+	sortEntries     bool
+	prefix          string
+	baseDir         string
+	junkPaths       bool
+	special         bool
+	noMacMeta       bool
+	autoStore       bool
+	rateLimit       int64
+	force           bool
+	move            bool
+	grow            bool
+	after           string
+	before          string
+	minSize         int64
+	maxSize         int64
+	dedup           bool
+	incremental     string
+	quiet           bool
+	verbose         bool
+	progress        bool
+	jsonLines       bool
+	commentStdin    bool
+	comment         string
+	encrypt         bool
+	passwordEnv     string
+	threads         int
+	stripExtras     bool
+	latestTime      bool
+	lineEnds        int
+}
+
+// register adds the flags to cmd.
+func (f *zipFlags) register(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVarP(&f.recursive, "recurse-paths", "r", false, "Travel the directory structure recursively")
+	flags.StringArrayVarP(&f.excludePatterns, "exclude", "x", nil, "Exclude files matching pattern")
+	flags.StringArrayVarP(&f.includePatterns, "include", "i", nil, "Include only files matching pattern")
+	flags.StringArrayVar(&f.excludeFrom, "exclude-from", nil, "Read exclude patterns from file, one per line")
+	flags.StringArrayVar(&f.includeFrom, "include-from", nil, "Read include patterns from file, one per line")
+	flags.BoolVar(&f.useIgnoreFiles, "use-ignore-files", false, "Skip paths ignored by .gitignore and .zipignore files")
+	flags.StringVarP(&f.nameCharset, "name-charset", "I", "", "Write entry names in a legacy charset (e.g. cp949, cp437)")
+	flags.StringVar(&f.outputFormat, "output", "text", "Result format: text, or json for a summary document")
+	flags.StringVar(&f.outputFile, "output-file", "", "Write the json result to file (default: stderr)")
+	flags.BoolVar(&f.jsonLines, "json", false, "Print one JSON object per entry and a final summary object instead of text")
+	flags.StringVar(&f.logFormat, "log-format", "text", "Status message format: text, or json for one object per entry")
+	flags.CountVarP(&f.fix, "fix", "F", "Repair a damaged archive; -FF rebuilds it by scanning for entries")
+	flags.StringVar(&f.fixOut, "out", "", "Write the repaired archive to this path")
+	flags.BoolVar(&f.verify, "verify", false, "Test the integrity of zipfile instead of creating it")
+	flags.StringVar(&f.sample, "sample", "", "With --verify, test only a fraction of entries, e.g. 5% or 0.05")
+	flags.Uint64Var(&f.seed, "seed", 0, "Seed for --sample selection (default: random, printed)")
+	flags.BoolVar(&f.diff, "diff", false, "Compare the entries of zipfile with those of a second archive instead of creating it")
+	flags.BoolVar(&f.sfx, "sfx", false, "Create a self-extracting executable instead of a plain archive")
+	flags.StringVar(&f.sfxStub, "sfx-stub", "", "Extraction stub for --sfx, built for the target platform (default: gozipsfx)")
+	flags.StringVar(&f.sfxTarget, "sfx-target", "", "Platform the --sfx executable runs on, as GOOS/GOARCH; uses the stub gozipsfx-GOOS-GOARCH (default: this platform)")
+	flags.StringVarP(&f.methodName, "method", "Z", "deflate", "Compression method: deflate, store, bzip2, lzma or xz")
+	flags.BoolVar(&f.autoStore, "auto-store", false, "Store files that do not compress, such as photos, video and archives, instead of compressing them")
+	flags.StringArrayVar(&f.levelFor, "level-for", nil, "Compression level for matching files, as pattern=level (e.g. '*.jpg=0')")
+	flags.BoolVarP(&f.stripExtras, "strip-extras", "X", false, "Leave out extra file attributes: extended timestamps and UID/GID (-X- keeps them)")
+	// gozip never writes directory entries; zip -D is accepted so that
+	// zip command lines work unchanged.
+	flags.BoolP("no-dir-entries", "D", false, "Do not add directory entries (always the case; accepted for zip compatibility)")
+	flags.CountVarP(&f.lineEnds, "convert-eol", "l", "Convert LF to CR LF in text files; -ll converts CR LF to LF")
+	flags.BoolVarP(&f.latestTime, "latest-time", "o", false, "Set the archive's modification time to that of its newest entry; without files, only set it")
+	flags.BoolVar(&f.noMacMeta, "no-mac-metadata", false, "Leave out __MACOSX directories, .DS_Store and ._* AppleDouble files")
+	flags.BoolVar(&f.special, "special-files", false, "Store FIFOs, sockets and devices as empty entries instead of skipping them")
+	flags.BoolVarP(&f.junkPaths, "junk-paths", "j", false, "Store just the file names, without directory paths")
+	flags.StringVarP(&f.baseDir, "directory", "C", "", "Resolve input paths and name entries relative to this directory")
+	flags.StringVar(&f.prefix, "prefix", "", "Prepend this to every entry name, e.g. 'myproject-1.2.0/'")
+	flags.BoolVar(&f.sortEntries, "sort", false, "Sort entries by path instead of using the directory walk order")
+	flags.BoolVar(&f.deterministic, "deterministic", false, "Build a reproducible archive: sorted entries, fixed times (SOURCE_DATE_EPOCH), no extra fields")
+	flags.BoolVarP(&f.quiet, "quiet", "q", false, "Quiet mode: print only warnings, not each file added")
+	flags.BoolVarP(&f.verbose, "verbose", "v", false, "Verbose mode: print the compressed size and ratio of each file added")
+	flags.BoolVar(&f.progress, "progress", false, "Show a progress bar with throughput and ETA on standard error")
+	flags.BoolVar(&f.stats, "stats", false, "Print entry counts, sizes, compression ratio and largest entries of the archive when done")
+	flags.StringVarP(&f.after, "after", "t", "", "Add only files modified on or after date (YYYY-MM-DD, mmddyyyy or RFC 3339)")
+	flags.StringVar(&f.before, "before", "", "Add only files modified before date; -tt is an alias (YYYY-MM-DD, mmddyyyy or RFC 3339)")
+	flags.Int64Var(&f.minSize, "min-size", 0, "Add only files of at least this many bytes (1 skips empty files)")
+	flags.Int64Var(&f.maxSize, "max-size", 0, "Add only files of at most this many bytes (0: unlimited)")
+	flags.BoolVarP(&f.grow, "grow", "g", false, "Append to an existing archive in place instead of creating a new one")
+	flags.BoolVarP(&f.move, "move", "m", false, "Delete the original files (and emptied directories) once the archive is written")
+	flags.StringVar(&f.incremental, "incremental", "", "Add only files changed since the backup recorded in this manifest file, then update it")
+	flags.BoolVar(&f.dedup, "dedup", false, "Store identical files once; Info-ZIP unzip -t rejects the overlapping entries (\"overlapped components\")")
+	flags.BoolVar(&f.force, "force", false, "Replace zipfile if it already exists")
+	flags.IntVar(&f.threads, "threads", runtime.GOMAXPROCS(0), "Number of files compressed at once; 1 compresses them one at a time")
+	flags.Int64Var(&f.rateLimit, "rate-limit", 0, "Write the archive at most this many bytes per second (0: unlimited)")
+	flags.BoolVarP(&f.encrypt, "encrypt", "e", false, "Encrypt entries with a password prompted for on the terminal (weak zip encryption)")
+	flags.StringVar(&f.passwordEnv, "password-env", "", "Encrypt entries with the password held by this environment variable")
+	flags.BoolVarP(&f.commentStdin, "archive-comment", "z", false, "Read the archive comment from standard input; without files, only replace it")
+	flags.StringVar(&f.comment, "comment", "", "Set the archive comment; without files, only replace it")
+	flags.BoolVar(&f.dryRun, "dry-run", false, "Show what would be added, with sizes, without writing the archive")
+	flags.StringVar(&f.matchSyntax, "match", "glob", "Pattern syntax: glob, doublestar, regexp or gitignore")
+	for i := range f.levels {
+		flags.BoolVarP(&f.levels[i], fmt.Sprint(i), fmt.Sprint(i), false, fmt.Sprintf("Compression level %d", i))
+	}
+}
+
+// checkArgs checks the number of arguments: an archive and files, just
+// the archive to repair, verify or edit, or the two archives to diff.
+func (f *zipFlags) checkArgs(cmd *cobra.Command, args []string) error {
+	if err := f.checkMode(cmd); err != nil {
+		return err
+	}
+	check := cobra.MinimumNArgs(2)
+	switch {
+	case f.fix > 0 || f.verify:
+		check = cobra.ExactArgs(1)
+	case f.diff:
+		check = cobra.ExactArgs(2)
+	case f.editsComment(cmd) || f.latestTime:
+		// Without files, only the comment or time is changed.
+		check = cobra.MinimumNArgs(1)
+	}
+	if err := check(cmd, args); err != nil {
+		return usageError{err}
+	}
+	return nil
+}
+
+// checkMode rejects combining -F, --verify and --diff, which each read
+// archives instead of creating one, and the flags of --verify without it.
+func (f *zipFlags) checkMode(cmd *cobra.Command) error {
+	modes := 0
+	for _, set := range []bool{f.fix > 0, f.verify, f.diff} {
+		if set {
+			modes++
+		}
+	}
+	switch {
+	case modes > 1:
+		return usagef("-F, --verify and --diff cannot be combined")
+	case !f.verify && (cmd.Flags().Changed("sample") || cmd.Flags().Changed("seed")):
+		return usagef("--sample and --seed need --verify")
+	}
+	return nil
+}
+
+// editsComment reports whether the archive comment is set, by -z or
+// --comment.
+func (f *zipFlags) editsComment(cmd *cobra.Command) bool {
+	return f.commentStdin || cmd.Flags().Changed("comment")
+}
+
+// check rejects flags that cannot be combined with each other or with the
+// archive at zipPath.
+func (f *zipFlags) check(zipPath string) error {
+	if err := f.checkTarget(zipPath); err != nil {
+		return err
+	}
+	if err := f.checkSFX(); err != nil {
+		return err
+	}
+	if f.stats && f.dryRun {
+		return usagef("--stats cannot be combined with --dry-run or --sfx")
+	}
+	return nil
+}
+
+// checkTarget rejects flags that need a local archive file when zipPath
+// is "-" for standard output or an object store URL.
+func (f *zipFlags) checkTarget(zipPath string) error {
+	if zipPath != "-" && !blobstore.IsURL(zipPath) {
+		return nil
+	}
+	switch {
+	case f.latestTime:
+		return usagef("-o needs a local archive file")
+	case f.grow:
+		return usagef("--grow needs a local archive file")
+	case f.stats:
+		return usagef("--stats needs a local archive file")
+	case f.sfx && zipPath == "-":
+		return usagef("--sfx cannot write to standard output")
+	case f.sfx:
+		return usagef("--sfx cannot write to an object store")
+	case f.move && zipPath != "-":
+		// The sources would be deleted before the object exists.
+		return usagef("--move cannot be combined with --sfx or an object store")
+	}
+	return nil
+}
+
+// checkSFX rejects flags that --sfx cannot honor, as the archive is built
+// in a temporary file first, and an invalid or unused --sfx-target.
+func (f *zipFlags) checkSFX() error {
+	if !f.sfx {
+		if f.sfxTarget != "" {
+			return usagef("--sfx-target needs --sfx")
+		}
+		return nil
+	}
+	if _, _, err := parseTarget(f.sfxTarget); err != nil {
+		return usageError{err}
+	}
+	switch {
+	case f.sfxStub != "" && f.sfxTarget != "":
+		return usagef("--sfx-stub and --sfx-target cannot be combined")
+	case f.grow:
+		return usagef("--grow needs a local archive file")
+	case f.move:
+		// The sources would be deleted before the final file exists.
+		return usagef("--move cannot be combined with --sfx or an object store")
+	case f.incremental != "":
+		return usagef("--incremental cannot be combined with --sfx")
+	case f.stats:
+		return usagef("--stats cannot be combined with --dry-run or --sfx")
+	}
+	return nil
+}
+
+// reportFormat reports whether the result is written as json, which
+// --json also selects for status messages.
+func (f *zipFlags) reportFormat() (bool, error) {
+	jsonReport, err := report.ParseFormat(f.outputFormat)
+	if err != nil {
+		return false, usageError{err}
+	}
+	if f.jsonLines {
+		// Entries and the summary are objects of one stream.
+		f.logFormat = "json"
+		return true, nil
+	}
+	return jsonReport, nil
+}
+
+// zipOptions returns the options of Zip selected by the flags, with status
+// messages going to out.
+func (f *zipFlags) zipOptions(out io.Writer) (ziplib.ZipOptions, error) {
+	if f.threads < 1 {
+		return ziplib.ZipOptions{}, usagef("--threads must be at least 1")
+	}
+	syntax, err := ziplib.ParseMatchSyntax(f.matchSyntax)
+	if err != nil {
+		return ziplib.ZipOptions{}, usageError{err}
+	}
+	method, err := ziplib.ParseMethod(f.methodName)
+	if err != nil {
+		return ziplib.ZipOptions{}, usageError{err}
+	}
+	overrides, err := parseLevelOverrides(f.levelFor)
+	if err != nil {
+		return ziplib.ZipOptions{}, err
+	}
+	logger, err := report.NewLogger(f.logFormat, out)
+	if err != nil {
+		return ziplib.ZipOptions{}, usageError{err}
+	}
+	after, before, err := f.dateRange()
+	if err != nil {
+		return ziplib.ZipOptions{}, err
+	}
+	verbosity, err := f.verbosity()
+	if err != nil {
+		return ziplib.ZipOptions{}, err
+	}
+	password, err := f.password()
+	if err != nil {
+		return ziplib.ZipOptions{}, err
+	}
+
+	opts := ziplib.ZipOptions{
+		Recursive:          f.recursive,
+		CompressionLevel:   f.level(),
+		Method:             method,
+		AutoStore:          f.autoStore,
+		LevelOverrides:     overrides,
+		ExcludePatterns:    f.excludePatterns,
+		IncludePatterns:    f.includePatterns,
+		ExcludeFrom:        f.excludeFrom,
+		IncludeFrom:        f.includeFrom,
+		UseIgnoreFiles:     f.useIgnoreFiles,
+		MatchSyntax:        syntax,
+		NameEncoding:       f.nameCharset,
+		SortEntries:        f.sortEntries,
+		Prefix:             f.prefix,
+		BaseDir:            f.baseDir,
+		JunkPaths:          f.junkPaths,
+		StoreSpecialFiles:  f.special,
+		ExcludeMacMetadata: f.noMacMeta,
+		StripExtras:        f.stripExtras,
+		LatestTime:         f.latestTime,
+		ConvertLineEndings: f.lineEnding(),
+		Deterministic:      f.deterministic,
+		DryRun:             f.dryRun,
+		RateLimit:          f.rateLimit,
+		Overwrite:          f.force,
+		Move:               f.move,
+		Deduplicate:        f.dedup,
+		Comment:            f.comment,
+		Password:           password,
+		Concurrency:        f.threads,
+		ModifiedAfter:      after,
+		ModifiedBefore:     before,
+		MinSize:            f.minSize,
+		MaxSize:            f.maxSize,
+		Verbosity:          verbosity,
+		Logger:             logger,
+		Output:             out,
+	}
+	if f.deterministic {
+		if opts.DeterministicTime, err = sourceDateEpoch(); err != nil {
+			return ziplib.ZipOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
+// dateRange returns the modification times selected by --after and
+// --before, zero where not given.
+func (f *zipFlags) dateRange() (after, before time.Time, err error) {
+	if after, err = parseDate(f.after); err != nil {
+		return time.Time{}, time.Time{}, usageError{err}
+	}
+	if before, err = parseDate(f.before); err != nil {
+		return time.Time{}, time.Time{}, usageError{err}
+	}
+	return after, before, nil
+}
+
+// verbosity returns the verbosity selected by -q or -v.
+func (f *zipFlags) verbosity() (ziplib.Verbosity, error) {
+	switch {
+	case f.quiet && f.verbose:
+		return 0, usagef("-q and -v cannot be combined")
+	case f.quiet:
+		return ziplib.VerbosityQuiet, nil
+	case f.verbose:
+		return ziplib.VerbosityVerbose, nil
+	}
+	return ziplib.VerbosityNormal, nil
+}
+
+// lineEnding returns the line ending text files are converted to by -l
+// or -ll.
+func (f *zipFlags) lineEnding() ziplib.LineEnding {
+	switch {
+	case f.lineEnds == 1:
+		return ziplib.LineEndingCRLF
+	case f.lineEnds > 1:
+		return ziplib.LineEndingLF
+	}
+	return ziplib.LineEndingKeep
+}
+
+// password returns the password to encrypt entries with: prompted for by
+// -e unless this is a dry run, or held by the variable named by
+// --password-env.
+func (f *zipFlags) password() (string, error) {
+	switch {
+	case f.encrypt && f.passwordEnv != "":
+		return "", usagef("-e and --password-env cannot be combined")
+	case f.encrypt && !f.dryRun:
+		return promptPassword()
+	case f.passwordEnv != "":
+		password := os.Getenv(f.passwordEnv)
+		if password == "" {
+			return "", usagef("--password-env: %s is empty or not set", f.passwordEnv)
+		}
+		return password, nil
+	}
+	return "", nil
+}
+
+// level returns the compression level selected by -0 to -9, the highest
+// given winning, or -1 for the default.
+func (f *zipFlags) level() int {
+	level := -1
+	for i, set := range f.levels {
+		if set {
+			level = i
+		}
+	}
+	return level
+}
+
+// parseLevelOverrides parses the pattern=level arguments of --level-for.
+func parseLevelOverrides(args []string) ([]ziplib.LevelOverride, error) {
+	var overrides []ziplib.LevelOverride
+	for _, s := range args {
+		o, err := ziplib.ParseLevelOverride(s)
+		if err != nil {
+			return nil, usageError{err}
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, nil
+}
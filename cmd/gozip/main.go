@@ -2,56 +2,332 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jaeyeom/gozip/blobstore"
+	"github.com/jaeyeom/gozip/internal/buildinfo"
+	"github.com/jaeyeom/gozip/internal/report"
+	"github.com/jaeyeom/gozip/internal/shellwords"
 	"github.com/jaeyeom/gozip/ziplib"
 	"github.com/spf13/cobra"
 )
 
 func main() {
-	var (
-		recursive       bool
-		excludePatterns []string
-		levels          [10]bool // -0 through -9
-	)
-
+	var f zipFlags
 	rootCmd := &cobra.Command{
 		Use:   "gozip [flags] zipfile file1 [file2 ...]",
 		Short: "Create zip archives",
-		Long:  "gozip creates zip archives, compatible with standard zip.",
-		Args:  cobra.MinimumNArgs(2),
-		RunE: func(_ *cobra.Command, args []string) error {
-			zipPath := args[0]
-			files := args[1:]
-
-			level := -1 // default
-			for i, set := range levels {
-				if set {
-					level = i
-				}
-			}
-
-			opts := ziplib.ZipOptions{
-				Recursive:        recursive,
-				CompressionLevel: level,
-				ExcludePatterns:  excludePatterns,
-				Output:           os.Stdout,
-			}
-
-			return ziplib.Zip(zipPath, files, opts)
-		},
+		Long: "gozip creates zip archives, compatible with standard zip.\n" +
+			"If zipfile is -, the archive is written to standard output; if it is\n" +
+			"an s3:// or gs:// URL, the archive is uploaded to that object.\n" +
+			"With -F or -FF and --out, it repairs a damaged archive instead; with\n" +
+			"--verify, it tests zipfile, and with --diff, it compares zipfile with\n" +
+			"a second archive. The verify and diff commands are aliases of those\n" +
+			"flags; an archive with such a name is given as ./verify.",
+		Args:         f.checkArgs,
+		RunE:         f.run,
 		SilenceUsage: true,
 	}
+	f.register(rootCmd)
 
-	rootCmd.Flags().BoolVarP(&recursive, "recurse-paths", "r", false, "Travel the directory structure recursively")
-	rootCmd.Flags().StringArrayVarP(&excludePatterns, "exclude", "x", nil, "Exclude files matching pattern")
+	rootCmd.AddCommand(newVerifyCmd(&f), newDiffCmd())
+	buildinfo.AddTo(rootCmd, "gozip")
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
-	for i := 0; i <= 9; i++ {
-		rootCmd.Flags().BoolVarP(&levels[i], fmt.Sprintf("%d", i), fmt.Sprintf("%d", i), false, fmt.Sprintf("Compression level %d", i))
+	env, err := envOptions(rootCmd, "GOZIP_OPTS", os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitUsage)
 	}
-
+	rootCmd.SetArgs(append(expandZipFlags(env), expandZipFlags(os.Args[1:])...))
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err}
+	})
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// run creates the archive args[0] of the files args[1:], or repairs,
+// verifies, diffs or edits it as the flags say.
+func (f *zipFlags) run(cmd *cobra.Command, args []string) error {
+	zipPath, files := args[0], args[1:]
+	switch {
+	case f.fix > 0:
+		return f.repair(zipPath)
+	case f.verify:
+		return f.verifyArchive(zipPath)
+	case f.diff:
+		return diffArchives(zipPath, files[0])
+	}
+	return f.write(cmd, zipPath, files)
+}
+
+// write creates the archive at zipPath of files, or without files edits
+// it.
+func (f *zipFlags) write(cmd *cobra.Command, zipPath string, files []string) error {
+	if err := f.check(zipPath); err != nil {
+		return err
+	}
+	if err := f.commentFromStdin(cmd); err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return f.edit(cmd, zipPath)
+	}
+	jsonReport, err := f.reportFormat()
+	if err != nil {
+		return err
+	}
+	out := statusOutput(zipPath)
+	opts, err := f.zipOptions(out)
+	if err != nil {
+		return err
+	}
+
+	create, zipTemp := creators(zipPath, files, f.grow, &opts)
+	if f.incremental != "" {
+		if create, err = withIncremental(f.incremental, &opts, create); err != nil {
+			return err
+		}
+	}
+	if f.progress && !f.dryRun {
+		if create, zipTemp, err = f.withProgress(files, &opts, out, create, zipTemp); err != nil {
+			return err
+		}
+	}
+	if f.stats {
+		create = withStats(zipPath, out, create)
+	}
+
+	if f.sfx {
+		return writeSFX(zipPath, f.sfxStub, f.sfxTarget, zipTemp)
+	}
+	if !jsonReport {
+		return create()
+	}
+	return f.runReported(zipPath, &opts, out, create)
+}
+
+// commentFromStdin reads the archive comment of -z from standard input.
+func (f *zipFlags) commentFromStdin(cmd *cobra.Command) error {
+	if !f.commentStdin {
+		return nil
+	}
+	if cmd.Flags().Changed("comment") {
+		return usagef("-z and --comment cannot be combined")
+	}
+	c, err := readComment(os.Stdin, os.Stderr)
+	if err != nil {
+		return err
+	}
+	f.comment = c
+	return nil
+}
+
+// edit sets the comment or modification time of the archive at zipPath,
+// which is all there is to do without files.
+func (f *zipFlags) edit(cmd *cobra.Command, zipPath string) error {
+	if zipPath == "-" || blobstore.IsURL(zipPath) {
+		return usagef("editing the comment needs a local archive file")
+	}
+	if f.editsComment(cmd) {
+		if err := ziplib.SetComment(zipPath, f.comment); err != nil {
+			return err
+		}
+	}
+	if f.latestTime {
+		return ziplib.SetLatestTime(zipPath)
+	}
+	return nil
+}
+
+// statusOutput returns where status messages go: standard output, unless
+// the archive is streamed there.
+func statusOutput(zipPath string) *os.File {
+	if zipPath == "-" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// creators returns the functions writing the archive of files: create
+// writes it to zipPath, and zipTemp to an existing temporary file, for
+// uploads and --sfx. Both use opts as it is when they are called.
+func creators(zipPath string, files []string, grow bool, opts *ziplib.ZipOptions) (create func() error, zipTemp func(path string) error) {
+	zipTemp = func(path string) error {
+		o := *opts
+		o.Overwrite = true
+		return ziplib.Zip(path, files, o)
+	}
+	switch {
+	case zipPath == "-":
+		create = func() error { return ziplib.ZipTo(os.Stdout, files, *opts) }
+	case blobstore.IsURL(zipPath):
+		create = func() error { return uploadArchive(zipPath, zipTemp) }
+	case grow:
+		create = func() error { return ziplib.Grow(zipPath, files, *opts) }
+	default:
+		create = func() error { return ziplib.Zip(zipPath, files, *opts) }
+	}
+	return create, zipTemp
+}
+
+// withIncremental makes create add only the files changed since the
+// backup recorded in the manifest at path, and then update the manifest.
+// Without a manifest yet, this is the full backup.
+func withIncremental(path string, opts *ziplib.ZipOptions, create func() error) (func() error, error) {
+	base, err := ziplib.ReadManifest(path)
+	switch {
+	case err == nil:
+		opts.BaseManifest = base
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+	opts.Manifest = new(ziplib.Manifest)
+	return func() error {
+		if err := create(); err != nil || opts.DryRun {
+			return err
+		}
+		return ziplib.WriteManifest(path, opts.Manifest)
+	}, nil
+}
+
+// withProgress makes create and zipTemp show a progress bar on standard
+// error, with status messages printed above it.
+func (f *zipFlags) withProgress(files []string, opts *ziplib.ZipOptions, out io.Writer, create func() error, zipTemp func(string) error) (func() error, func(string) error, error) {
+	// A dry run with the same options gives the total to show.
+	var planned ziplib.Result
+	plan := *opts
+	plan.DryRun, plan.Result, plan.Manifest, plan.Logger, plan.Output = true, &planned, nil, nil, io.Discard
+	if err := ziplib.ZipTo(io.Discard, files, plan); err != nil {
+		return nil, nil, err
+	}
+	bar := report.NewProgress(os.Stderr, planned.UncompressedBytes)
+	opts.OnProgress = bar.Update
+	opts.Output = bar.Above(out)
+	if opts.Logger != nil {
+		opts.Logger, _ = report.NewLogger(f.logFormat, opts.Output)
+	}
+	shown := func() error {
+		defer bar.Finish()
+		return create()
+	}
+	shownTemp := func(path string) error {
+		defer bar.Finish()
+		return zipTemp(path)
+	}
+	return shown, shownTemp, nil
+}
+
+// withStats makes create print the statistics of the archive at zipPath
+// to out once it is written.
+func withStats(zipPath string, out io.Writer, create func() error) func() error {
+	return func() error {
+		if err := create(); err != nil {
+			return err
+		}
+		s, err := ziplib.Stats(zipPath)
+		if err != nil {
+			return err
+		}
+		report.WriteStats(out, s)
+		return nil
+	}
+}
+
+// repair writes the repaired archive at zipPath to --out.
+func (f *zipFlags) repair(zipPath string) error {
+	if f.fixOut == "" {
+		return usagef("repairing an archive requires --out")
+	}
+	return ziplib.Fix(zipPath, f.fixOut, ziplib.FixOptions{
+		FullScan: f.fix > 1,
+		Output:   os.Stdout,
+	})
+}
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH environment
+// variable, or the zero time if it is unset.
+func sourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", v, err)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// runReported runs create and writes its result as json: to out with
+// --json, or else to --output-file.
+func (f *zipFlags) runReported(zipPath string, opts *ziplib.ZipOptions, out io.Writer, create func() error) error {
+	var res ziplib.Result
+	opts.Result = &res
+	start := time.Now()
+	err := create()
+	r := report.New("gozip", zipPath, res, time.Since(start), err)
+	if f.jsonLines {
+		return errors.Join(err, report.WriteLine(out, r))
+	}
+	return errors.Join(err, report.Write(f.outputFile, r))
+}
+
+// expandZipFlags rewrites zip forms that single-letter flags cannot
+// express: "-tt date" into --before, and "-X-" into --strip-extras=false.
+func expandZipFlags(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return append(out, args[i:]...)
+		case arg == "-X-":
+			arg = "--strip-extras=false"
+		case arg == "-tt":
+			arg = "--before"
+		case strings.HasPrefix(arg, "-tt"):
+			arg = "--before=" + strings.TrimPrefix(arg, "-tt")
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// parseDate parses a date in local time, in the YYYY-MM-DD or zip's
+// mmddyyyy form, or an RFC 3339 timestamp. An empty string yields the zero
+// time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.DateOnly, "01022006"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD, mmddyyyy or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// envOptions returns the default flags held by the environment variable
+// name for the command line args. Subcommands have flags of their own and
+// get none.
+func envOptions(root *cobra.Command, name string, args []string) ([]string, error) {
+	root.InitDefaultHelpCmd()
+	if cmd, _, err := root.Find(args); err == nil && cmd != root {
+		return nil, nil
 	}
+	return shellwords.FromEnv(name)
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// promptPassword asks for the password of -e on the terminal, without
+// echo, and again to verify it, like zip -e.
+func promptPassword() (string, error) {
+	password, err := readPassword("Enter password: ")
+	if err != nil {
+		return "", err
+	}
+	again, err := readPassword("Verify password: ")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case password != again:
+		return "", errors.New("password verification failed")
+	case password == "":
+		return "", errors.New("empty password")
+	}
+	return password, nil
+}
+
+// readLine reads one line of input typed on the terminal in, less its
+// line ending.
+func readLine(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
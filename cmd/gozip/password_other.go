@@ -0,0 +1,11 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+
+package main
+
+import "errors"
+
+// readPassword fails on platforms where gozip cannot turn off the echo of
+// the terminal.
+func readPassword(string) (string, error) {
+	return "", errors.New("reading a password from the terminal is not supported here; use --password-env")
+}
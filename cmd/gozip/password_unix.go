@@ -0,0 +1,37 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// readPassword shows prompt on the controlling terminal and reads a line
+// from it with echo turned off.
+func readPassword(prompt string) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	defer tty.Close()
+	fd := int(tty.Fd()) //nolint:gosec // File descriptors fit in an int.
+	old, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	noEcho := *old
+	noEcho.Lflag &^= unix.ECHO
+	noEcho.Lflag |= unix.ICANON | unix.ISIG
+	noEcho.Iflag |= unix.ICRNL
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &noEcho); err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	defer unix.IoctlSetTermios(fd, ioctlSetTermios, old) //nolint:errcheck // Best effort; the terminal is closed next.
+
+	fmt.Fprint(tty, prompt)
+	defer fmt.Fprintln(tty) // The newline typed was not echoed.
+	return readLine(tty)
+}
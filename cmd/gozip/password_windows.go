@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// readPassword shows prompt on the console and reads a line from it with
+// echo turned off.
+func readPassword(prompt string) (string, error) {
+	in, err := os.OpenFile("CONIN$", os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	defer out.Close()
+	h := windows.Handle(in.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	noEcho := mode&^windows.ENABLE_ECHO_INPUT | windows.ENABLE_PROCESSED_INPUT | windows.ENABLE_LINE_INPUT
+	if err := windows.SetConsoleMode(h, noEcho); err != nil {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	defer windows.SetConsoleMode(h, mode) //nolint:errcheck // Best effort; the console is closed next.
+
+	fmt.Fprint(out, prompt)
+	defer fmt.Fprintln(out) // The newline typed was not echoed.
+	return readLine(in)
+}
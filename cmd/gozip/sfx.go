@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// writeSFX builds the archive with create in a temporary file and turns it
+// into a self-extractor at sfxPath using the stub executable. An empty stub
+// means the gozipsfx for the target platform, "GOOS/GOARCH" or empty for
+// the host, installed next to gozip or found in PATH.
+func writeSFX(sfxPath, stub, target string, create func(zipPath string) error) error {
+	if stub == "" {
+		goos, goarch, err := parseTarget(target)
+		if err != nil {
+			return usageError{err}
+		}
+		if stub, err = findStub(goos, goarch); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(sfxPath), ".gozip-sfx-*.zip")
+	if err != nil {
+		return fmt.Errorf("create temporary archive: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := create(tmp.Name()); err != nil {
+		return err
+	}
+	return ziplib.WriteSFX(sfxPath, tmp.Name(), stub)
+}
+
+// parseTarget parses a --sfx-target platform such as "windows/amd64". An
+// empty target is the host platform.
+func parseTarget(target string) (goos, goarch string, err error) {
+	if target == "" {
+		return runtime.GOOS, runtime.GOARCH, nil
+	}
+	goos, goarch, ok := strings.Cut(target, "/")
+	if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		return "", "", fmt.Errorf("invalid --sfx-target %q: use GOOS/GOARCH, e.g. windows/amd64", target)
+	}
+	return goos, goarch, nil
+}
+
+// stubNames returns the file names of the gozipsfx stub for goos and
+// goarch, in the order they are looked for: gozipsfx-GOOS-GOARCH, and for
+// the host platform also plain gozipsfx, as go install names it.
+func stubNames(goos, goarch string) []string {
+	ext := ""
+	if goos == "windows" {
+		ext = ".exe"
+	}
+	names := []string{"gozipsfx-" + goos + "-" + goarch + ext}
+	if goos == runtime.GOOS && goarch == runtime.GOARCH {
+		names = append(names, "gozipsfx"+ext)
+	}
+	return names
+}
+
+// findStub locates the gozipsfx stub for goos and goarch next to the
+// running binary or in PATH. A stub built for another platform is never
+// picked, so that the self-extractor runs where it was meant to.
+func findStub(goos, goarch string) (string, error) {
+	names := stubNames(goos, goarch)
+	if self, err := os.Executable(); err == nil {
+		for _, name := range names {
+			p := filepath.Join(filepath.Dir(self), name)
+			if _, err := os.Stat(p); err == nil {
+				return p, nil
+			}
+		}
+	}
+	for _, name := range names {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	if goos == runtime.GOOS && goarch == runtime.GOARCH {
+		return "", errors.New("gozipsfx stub not found: install it with " +
+			"go install github.com/jaeyeom/gozip/cmd/gozipsfx@latest or pass --sfx-stub")
+	}
+	return "", fmt.Errorf("gozipsfx stub for %s/%s not found: build it with "+
+		"GOOS=%s GOARCH=%s go build -o %s github.com/jaeyeom/gozip/cmd/gozipsfx "+
+		"next to gozip or in PATH, or pass --sfx-stub", goos, goarch, goos, goarch, names[0])
+}
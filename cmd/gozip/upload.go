@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jaeyeom/gozip/blobstore"
+)
+
+// uploadArchive builds the archive with create in a temporary file and
+// uploads it to the object store URL dest, such as s3://bucket/key.zip.
+func uploadArchive(dest string, create func(zipPath string) error) error {
+	store, key, err := blobstore.Parse(dest)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", ".gozip-upload-*.zip")
+	if err != nil {
+		return fmt.Errorf("create temporary archive: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err := create(tmp.Name()); err != nil {
+		return err
+	}
+	f, err := os.Open(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("open temporary archive: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat temporary archive: %w", err)
+	}
+	return store.Put(key, f, fi.Size()) //nolint:wrapcheck // Store errors are already prefixed.
+}
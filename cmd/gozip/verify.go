@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jaeyeom/gozip/ziplib"
+	"github.com/spf13/cobra"
+)
+
+// newVerifyCmd returns the verify subcommand, an alias of --verify whose
+// --sample and --seed set those of f.
+func newVerifyCmd(f *zipFlags) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify [flags] zipfile",
+		Short: "Test the integrity of a zip archive, like --verify",
+		Long: "verify decompresses entries and checks their CRC-32 without extracting them.\n" +
+			"With --sample, only a random subset is tested, always including the\n" +
+			"largest entry and the entry stored last.",
+		Args: usageArgs(cobra.ExactArgs(1)),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return f.verifyArchive(args[0])
+		},
+		SilenceUsage: true,
+	}
+	cmd.Flags().StringVar(&f.sample, "sample", "", "Test only a fraction of entries, e.g. 5% or 0.05")
+	cmd.Flags().Uint64Var(&f.seed, "seed", 0, "Seed for sample selection (default: random, printed)")
+	return cmd
+}
+
+// verifyArchive tests the integrity of the archive at zipPath, or of the
+// sample of its entries that --sample selects.
+func (f *zipFlags) verifyArchive(zipPath string) error {
+	fraction, err := parseSample(f.sample)
+	if err != nil {
+		return usageError{err}
+	}
+	return ziplib.Verify(zipPath, ziplib.VerifyOptions{
+		SampleFraction: fraction,
+		Seed:           f.seed,
+		Output:         os.Stdout,
+	})
+}
+
+// parseSample parses a sample size given as a percentage ("5%") or a
+// fraction ("0.05"). An empty string means every entry is tested.
+func parseSample(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	num, scale := s, 1.0
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		num, scale = p, 100
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v <= 0 || v/scale > 1 {
+		return 0, fmt.Errorf("invalid sample %q: use a percentage like 5%% or a fraction like 0.05", s)
+	}
+	return v / scale, nil
+}
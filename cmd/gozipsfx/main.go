@@ -0,0 +1,49 @@
+// Command gozipsfx is the extraction stub of self-extracting archives made
+// with gozip --sfx. When run, it extracts the zip archive appended to its
+// own executable.
+//
+// It uses the standard flag package rather than cobra to keep the stub,
+// which is copied into every self-extractor, small.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+func main() {
+	outputDir := flag.String("d", ".", "Extract files into directory")
+	overwrite := flag.Bool("o", false, "Overwrite existing files")
+	list := flag.Bool("l", false, "List archive contents")
+	flag.Parse()
+
+	if err := run(*outputDir, *overwrite, *list); err != nil {
+		fmt.Fprintln(os.Stderr, "gozipsfx:", err)
+		os.Exit(1)
+	}
+}
+
+func run(outputDir string, overwrite, list bool) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+	if list {
+		entries, err := ziplib.List(self)
+		if err != nil {
+			return fmt.Errorf("listing archive: %w", err)
+		}
+		for _, e := range entries {
+			fmt.Printf("%9d  %s\n", e.UncompressedSize, e.Name)
+		}
+		return nil
+	}
+	return ziplib.Unzip(self, ziplib.UnzipOptions{
+		OutputDir: outputDir,
+		Overwrite: overwrite,
+		Output:    os.Stdout,
+	})
+}
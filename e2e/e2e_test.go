@@ -2,6 +2,8 @@
 package e2e
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -252,3 +254,248 @@ func searchString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestGozipSFX(t *testing.T) {
+	gozip, _ := buildBinaries(t)
+	stub := filepath.Join(t.TempDir(), "gozipsfx")
+	build := exec.Command("go", "build", "-o", stub, "./cmd/gozipsfx") //nolint:gosec // Test-only; args are not user-controlled.
+	build.Dir = ".."
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build gozipsfx: %v\n%s", err, out)
+	}
+
+	src := setupTestData(t)
+	sfx := filepath.Join(t.TempDir(), "archive.sfx")
+	cmd := exec.Command(gozip, "-r", "--sfx", "--sfx-stub", stub, sfx, ".")
+	cmd.Dir = src
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("gozip --sfx: %v\n%s", err, out)
+	}
+
+	extractDir := t.TempDir()
+	run := exec.Command(sfx, "-d", extractDir)
+	if out, err := run.CombinedOutput(); err != nil {
+		t.Fatalf("run self-extractor: %v\n%s", err, out)
+	}
+	verifyExtracted(t, extractDir)
+
+	if _, err := exec.LookPath("unzip"); err == nil {
+		if out, err := exec.Command("unzip", "-t", sfx).CombinedOutput(); err != nil {
+			t.Errorf("unzip -t self-extractor: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestBzip2Interop(t *testing.T) {
+	requireCmd(t, "zip")
+	requireCmd(t, "unzip")
+	gozip, gounzip := buildBinaries(t)
+	src := setupTestData(t)
+
+	// System zip -> gounzip.
+	sysZip := filepath.Join(t.TempDir(), "system.zip")
+	cmd := exec.Command("zip", "-r", "-Z", "bzip2", sysZip, ".")
+	cmd.Dir = src
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("zip -Z bzip2 not supported: %v\n%s", err, out)
+	}
+	extractDir := t.TempDir()
+	if out, err := exec.Command(gounzip, "-d", extractDir, sysZip).CombinedOutput(); err != nil {
+		t.Fatalf("gounzip bzip2 archive: %v\n%s", err, out)
+	}
+	verifyExtracted(t, extractDir)
+
+	// gozip -> system unzip.
+	goZip := filepath.Join(t.TempDir(), "gozip.zip")
+	cmd = exec.Command(gozip, "-r", "--method", "bzip2", goZip, ".")
+	cmd.Dir = src
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("gozip --method bzip2: %v\n%s", err, out)
+	}
+	extractDir = t.TempDir()
+	if out, err := exec.Command("unzip", "-d", extractDir, goZip).CombinedOutput(); err != nil {
+		t.Fatalf("unzip gozip bzip2 archive: %v\n%s", err, out)
+	}
+	verifyExtracted(t, extractDir)
+}
+
+// TestGozipToStdout streams an archive through a pipe and extracts it with
+// system unzip.
+func TestGozipToStdout(t *testing.T) {
+	requireCmd(t, "unzip")
+	gozipBin, _ := buildBinaries(t)
+
+	srcDir := setupTestData(t)
+	zipPath := filepath.Join(t.TempDir(), "stdout.zip")
+	extractDir := t.TempDir()
+
+	//   gozip -r - . | cat > archive.zip
+	cmd := exec.Command(gozipBin, "-r", "-", ".")
+	cmd.Dir = srcDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("gozip -: %v\n%s", err, stderr.String())
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("adding:")) {
+		t.Errorf("status messages should go to stderr, got %q", stderr.String())
+	}
+	if err := os.WriteFile(zipPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd = exec.Command("unzip", "-o", zipPath, "-d", extractDir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("system unzip: %v\n%s", err, out)
+	}
+	verifyExtracted(t, extractDir)
+}
+
+func TestGozipExitCodes(t *testing.T) {
+	gozipBin, _ := buildBinaries(t)
+	srcDir := setupTestData(t)
+	existing := filepath.Join(t.TempDir(), "existing.zip")
+	if err := os.WriteFile(existing, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The statuses documented for Info-ZIP zip.
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"nothing to do", []string{"-x", "*.txt", filepath.Join(t.TempDir(), "a.zip"), "hello.txt"}, 12},
+		{"existing archive", []string{existing, "hello.txt"}, 15},
+		{"bad flag", []string{"--no-such-flag", "a.zip", "hello.txt"}, 16},
+		{"missing argument", []string{"a.zip"}, 16},
+		{"missing input", []string{filepath.Join(t.TempDir(), "b.zip"), "missing.txt"}, 18},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(gozipBin, tt.args...) //nolint:gosec // Test-only; args are not user-controlled.
+		cmd.Dir = srcDir
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != tt.want {
+			t.Errorf("%s: gozip exited with %v, want status %d", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestGozipSubcommandAliases checks that the verify, diff and version
+// subcommands behave as the flags they alias, and ignore GOZIP_OPTS.
+func TestGozipSubcommandAliases(t *testing.T) {
+	gozipBin, gounzipBin := buildBinaries(t)
+	srcDir := setupTestData(t)
+	tmp := t.TempDir()
+	oldZip, newZip := filepath.Join(tmp, "old.zip"), filepath.Join(tmp, "new.zip")
+	for _, args := range [][]string{{oldZip, "hello.txt"}, {newZip, "hello.txt", "empty.txt"}} {
+		cmd := exec.Command(gozipBin, args...) //nolint:gosec // Test-only; args are not user-controlled.
+		cmd.Dir = srcDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("gozip %v: %v\n%s", args, err, out)
+		}
+	}
+
+	tests := []struct {
+		bin  string
+		flag []string
+		cmd  []string
+	}{
+		{gozipBin, []string{"--verify", oldZip}, []string{"verify", oldZip}},
+		{gozipBin, []string{"--verify", "--sample", "50%", "--seed", "7", newZip}, []string{"verify", "--sample", "50%", "--seed", "7", newZip}},
+		{gozipBin, []string{"--diff", oldZip, newZip}, []string{"diff", oldZip, newZip}},
+		{gozipBin, []string{"--version"}, []string{"version"}},
+		{gounzipBin, []string{"--version"}, []string{"version"}},
+	}
+	for _, tt := range tests {
+		run := func(args []string) (string, int) {
+			cmd := exec.Command(tt.bin, args...) //nolint:gosec // Test-only; args are not user-controlled.
+			cmd.Env = append(os.Environ(), "GOZIP_OPTS=-r -9", "GOUNZIP_OPTS=-o")
+			out, err := cmd.Output()
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return string(out), exitErr.ExitCode()
+			}
+			if err != nil {
+				t.Fatalf("%v: %v", args, err)
+			}
+			return string(out), 0
+		}
+		wantOut, wantCode := run(tt.flag)
+		gotOut, gotCode := run(tt.cmd)
+		if gotOut != wantOut || gotCode != wantCode {
+			t.Errorf("%v = %q (status %d), want %q (status %d) as for %v", tt.cmd, gotOut, gotCode, wantOut, wantCode, tt.flag)
+		}
+	}
+}
+
+// TestGounzipSkipsBackupManifest checks that gounzip leaves the manifest
+// of an incremental backup out of extraction and listings unless it is
+// named.
+func TestGounzipSkipsBackupManifest(t *testing.T) {
+	const manifestName = ".gozip-manifest.json"
+	gozipBin, gounzipBin := buildBinaries(t)
+	srcDir := setupTestData(t)
+	tmp := t.TempDir()
+	zipPath := filepath.Join(tmp, "backup.zip")
+	cmd := exec.Command(gozipBin, "-r", "--incremental", filepath.Join(tmp, "backup.json"), zipPath, ".")
+	cmd.Dir = srcDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("gozip: %v\n%s", err, out)
+	}
+
+	extractDir := t.TempDir()
+	if out, err := exec.Command(gounzipBin, "-d", extractDir, zipPath).CombinedOutput(); err != nil {
+		t.Fatalf("gounzip: %v\n%s", err, out)
+	}
+	verifyExtracted(t, extractDir)
+	if _, err := os.Stat(filepath.Join(extractDir, manifestName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("manifest extracted: %v", err)
+	}
+	out, err := exec.Command(gounzipBin, "-l", zipPath).CombinedOutput()
+	if err != nil || containsString(string(out), manifestName) {
+		t.Errorf("gounzip -l = %v, listing the manifest:\n%s", err, out)
+	}
+
+	if out, err := exec.Command(gounzipBin, "-d", extractDir, zipPath, manifestName).CombinedOutput(); err != nil {
+		t.Fatalf("gounzip %s: %v\n%s", manifestName, err, out)
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, manifestName)); err != nil {
+		t.Errorf("named manifest not extracted: %v", err)
+	}
+}
+
+// TestGounzipListingsMatchSystemUnzip compares the listings of gounzip -l
+// and -v with those of unzip byte for byte, for an archive with empty,
+// shrunk and grown entries.
+func TestGounzipListingsMatchSystemUnzip(t *testing.T) {
+	requireCmd(t, "unzip")
+	gozipBin, gounzipBin := buildBinaries(t)
+	srcDir := setupTestData(t)
+	big := bytes.Repeat([]byte("abcdefgh\n"), 2000)
+	if err := os.WriteFile(filepath.Join(srcDir, "big.txt"), big, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(t.TempDir(), "golden.zip")
+	cmd := exec.Command(gozipBin, "-r", zipPath, ".")
+	cmd.Dir = srcDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("gozip: %v\n%s", err, out)
+	}
+
+	for _, flag := range []string{"-l", "-v"} {
+		want, err := exec.Command("unzip", flag, zipPath).Output()
+		if err != nil {
+			t.Fatalf("unzip %s: %v", flag, err)
+		}
+		got, err := exec.Command(gounzipBin, flag, zipPath).Output()
+		if err != nil {
+			t.Fatalf("gounzip %s: %v", flag, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("gounzip %s:\n%s\nwant as unzip:\n%s", flag, got, want)
+		}
+	}
+}
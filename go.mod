@@ -2,7 +2,13 @@ module github.com/jaeyeom/gozip
 
 go 1.25.0
 
-require github.com/spf13/cobra v1.10.2
+require (
+	github.com/dsnet/compress v0.0.1
+	github.com/spf13/cobra v1.10.2
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/sys v0.47.0
+	golang.org/x/text v0.37.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
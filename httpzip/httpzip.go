@@ -0,0 +1,168 @@
+// Package httpzip reads zip archives served over HTTP using range requests.
+//
+// A Reader implements io.ReaderAt on top of a URL, so it can be passed to
+// ziplib.ListReader or ziplib.UnzipReader. Listing an archive then fetches
+// only its central directory, and extracting a few entries fetches only
+// their bytes, instead of downloading the whole archive.
+package httpzip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// blockSize is the size of the block cached for small reads. Archive
+// readers read the central directory and entry data through small buffers,
+// which would otherwise cost one request each.
+const blockSize = 64 << 10
+
+// ErrRangeNotSupported is returned when the server ignores range requests.
+var ErrRangeNotSupported = errors.New("httpzip: server does not support range requests")
+
+// Reader reads a remote file through HTTP range requests. It is safe for
+// concurrent use.
+type Reader struct {
+	url    string
+	client *http.Client
+	size   int64
+
+	mu    sync.Mutex // guards start and block
+	start int64
+	block []byte
+}
+
+// IsURL reports whether name is an http or https URL.
+func IsURL(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
+// Open returns a Reader for the file at url, using client for requests, or
+// http.DefaultClient if client is nil. Open makes one small request to
+// learn the size of the file and check that the server supports ranges.
+func Open(url string, client *http.Client) (*Reader, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &Reader{url: url, client: client}
+	resp, err := r.get(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	size, err := totalSize(resp.Header.Get("Content-Range"))
+	if err != nil {
+		return nil, err
+	}
+	r.size = size
+	return r, nil
+}
+
+// Size returns the size of the remote file in bytes.
+func (r *Reader) Size() int64 {
+	return r.size
+}
+
+// ReadAt implements io.ReaderAt. Reads smaller than a block are served
+// from a cached block; larger reads are fetched directly.
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("httpzip: negative offset")
+	}
+	n := 0
+	for n < len(p) && off+int64(n) < r.size {
+		pos := off + int64(n)
+		want := min(int64(len(p)-n), r.size-pos)
+		var (
+			m   int
+			err error
+		)
+		if want >= blockSize {
+			m, err = r.fetch(p[n:n+int(want)], pos)
+		} else {
+			m, err = r.readCached(p[n:n+int(want)], pos)
+		}
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readCached copies into p from the cached block holding off, fetching the
+// block first if needed. It stops at the end of the block.
+func (r *Reader) readCached(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	start := off - off%blockSize
+	if r.block == nil || r.start != start {
+		buf := make([]byte, min(blockSize, r.size-start))
+		if _, err := r.fetch(buf, start); err != nil {
+			r.block = nil
+			return 0, err
+		}
+		r.start, r.block = start, buf
+	}
+	return copy(p, r.block[off-start:]), nil
+}
+
+// fetch fills p with the bytes of the file starting at off.
+func (r *Reader) fetch(p []byte, off int64) (int, error) {
+	resp, err := r.get(off, off+int64(len(p))-1)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	n, err := io.ReadFull(resp.Body, p)
+	if err != nil {
+		return n, fmt.Errorf("httpzip: read %s: %w", r.url, err)
+	}
+	return n, nil
+}
+
+// get requests the bytes from first to last inclusive and checks that the
+// server answered with that range.
+func (r *Reader) get(first, last int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("httpzip: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", first, last))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("httpzip: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp, nil
+	case http.StatusOK:
+		resp.Body.Close()
+		return nil, ErrRangeNotSupported
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("httpzip: GET %s: %s", r.url, resp.Status)
+	}
+}
+
+// totalSize parses the complete length from a "bytes first-last/total"
+// Content-Range header.
+func totalSize(contentRange string) (int64, error) {
+	_, total, ok := strings.Cut(contentRange, "/")
+	if !ok || !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, fmt.Errorf("httpzip: invalid Content-Range %q", contentRange)
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("httpzip: unknown archive size in Content-Range %q", contentRange)
+	}
+	return size, nil
+}
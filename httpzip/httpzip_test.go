@@ -0,0 +1,136 @@
+package httpzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// newArchive returns an archive with a large incompressible entry and a
+// small one.
+func newArchive(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	big := make([]byte, 1<<20)
+	rng := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // Test data only.
+	for i := range big {
+		big[i] = byte(rng.Uint32())
+	}
+	for name, data := range map[string][]byte{"big.bin": big, "small.txt": []byte("hello\n")} {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serve serves data with range support and counts the bytes sent.
+func serve(t *testing.T, data []byte, sent *atomic.Int64) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &countingResponseWriter{ResponseWriter: w, n: sent}
+		http.ServeContent(cw, r, "archive.zip", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/archive.zip"
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+	n *atomic.Int64
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return w.ResponseWriter.Write(p) //nolint:wrapcheck // Test helper passes errors through.
+}
+
+func TestListAndExtractPartially(t *testing.T) {
+	data := newArchive(t)
+	var sent atomic.Int64
+	url := serve(t, data, &sent)
+
+	r, err := Open(url, nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if r.Size() != int64(len(data)) {
+		t.Fatalf("Size = %d, want %d", r.Size(), len(data))
+	}
+
+	entries, err := ziplib.ListReader(r, r.Size())
+	if err != nil {
+		t.Fatalf("ListReader: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	dir := t.TempDir()
+	err = ziplib.UnzipReader(r, r.Size(), ziplib.UnzipOptions{OutputDir: dir, FilePatterns: []string{"small.txt"}})
+	if err != nil {
+		t.Fatalf("UnzipReader: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "small.txt"))
+	if err != nil || string(got) != "hello\n" {
+		t.Errorf("small.txt = %q, %v", got, err)
+	}
+	if n := sent.Load(); n > int64(len(data))/4 {
+		t.Errorf("downloaded %d of %d bytes for a listing and one small entry", n, len(data))
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	data := newArchive(t)
+	var sent atomic.Int64
+	r, err := Open(serve(t, data, &sent), nil)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	for _, tt := range []struct{ off, n int64 }{
+		{0, 10},
+		{blockSize - 5, 10},        // spans two cached blocks
+		{100, 3 * blockSize},       // fetched directly
+		{int64(len(data)) - 4, 4},  // last bytes
+		{int64(len(data)) - 4, 10}, // past the end
+	} {
+		p := make([]byte, tt.n)
+		n, err := r.ReadAt(p, tt.off)
+		want := data[tt.off:min(tt.off+tt.n, int64(len(data)))]
+		if !bytes.Equal(p[:n], want) {
+			t.Errorf("ReadAt(%d, %d) returned wrong data", tt.off, tt.n)
+		}
+		if short := n < len(p); short != errors.Is(err, io.EOF) {
+			t.Errorf("ReadAt(%d, %d) = %d, %v", tt.off, tt.n, n, err)
+		}
+	}
+}
+
+func TestOpenWithoutRangeSupport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("PK"))
+	}))
+	defer srv.Close()
+	if _, err := Open(srv.URL, nil); !errors.Is(err, ErrRangeNotSupported) {
+		t.Errorf("Open = %v, want ErrRangeNotSupported", err)
+	}
+}
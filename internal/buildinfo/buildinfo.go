@@ -0,0 +1,118 @@
+// Package buildinfo describes the running gozip or gounzip binary, so
+// bug reports can identify it.
+package buildinfo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata injected at link time, as the Makefile does:
+//
+//	go build -ldflags "-X github.com/jaeyeom/gozip/internal/buildinfo.version=v1.2.0"
+//
+// Values left empty are taken from the module and VCS information that
+// the Go toolchain embeds.
+var (
+	version string
+	commit  string
+	date    string
+)
+
+// Info is the build metadata of the running binary.
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+	Platform  string
+}
+
+// Get returns the build metadata of the running binary. Fields that are
+// not known are "unknown", and the version of an untagged build is "dev".
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fillFromBuildInfo(&info, bi)
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// fillFromBuildInfo sets the fields of info still empty from bi: the
+// module version set by go install, and the VCS revision and time set by
+// go build in a checkout.
+func fillFromBuildInfo(info *Info, bi *debug.BuildInfo) {
+	if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	var revision, modified string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			modified = s.Value
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		}
+	}
+	if info.Commit == "" && revision != "" {
+		info.Commit = revision
+		if modified == "true" {
+			info.Commit += "-dirty"
+		}
+	}
+}
+
+// String formats info as printed by the --version flag and the version
+// subcommand of command.
+func (info Info) String(command string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", command, info.Version)
+	fmt.Fprintf(&b, "  commit: %s\n", info.Commit)
+	fmt.Fprintf(&b, "  built:  %s\n", info.Date)
+	fmt.Fprintf(&b, "  go:     %s %s\n", info.GoVersion, info.Platform)
+	return b.String()
+}
+
+// AddTo adds a --version flag to the root command root of the CLI called
+// command, and a version subcommand as an alias of it. An archive named
+// version is then given as ./version.
+func AddTo(root *cobra.Command, command string) {
+	info := Get()
+	root.Version = info.Version
+	// Defined here so that cobra does not claim -v for it.
+	root.Flags().Bool("version", false, "Print version information and exit")
+	root.SetVersionTemplate(info.String(command))
+	root.AddCommand(&cobra.Command{
+		Use:   "version",
+		Short: "Print version information, like --version",
+		Long:  "version prints the version, commit, build date and Go version of " + command + ".",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			io.WriteString(cmd.OutOrStdout(), info.String(command)) //nolint:errcheck // Nothing to do if stdout fails.
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestFillFromBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "vcs.time", Value: "2024-05-06T07:08:09Z"},
+		},
+	}
+	var info Info
+	fillFromBuildInfo(&info, bi)
+	if info.Version != "v1.2.3" || info.Commit != "abc123-dirty" || info.Date != "2024-05-06T07:08:09Z" {
+		t.Errorf("info = %+v", info)
+	}
+
+	// Values injected with -ldflags win.
+	info = Info{Version: "v2.0.0", Commit: "def456"}
+	fillFromBuildInfo(&info, &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}})
+	if info.Version != "v2.0.0" || info.Commit != "def456" {
+		t.Errorf("injected values replaced: %+v", info)
+	}
+}
+
+func TestString(t *testing.T) {
+	info := Info{Version: "v1.0.0", Commit: "abc", Date: "2024-01-01", GoVersion: "go1.25.0", Platform: "linux/amd64"}
+	got := info.String("gozip")
+	for _, want := range []string{"gozip v1.0.0\n", "commit: abc\n", "built:  2024-01-01\n", "go:     go1.25.0 linux/amd64\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("String() = %q, missing %q", got, want)
+		}
+	}
+}
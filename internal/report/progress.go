@@ -0,0 +1,167 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// Intervals between progress updates: a terminal bar is redrawn often,
+// while plain lines for logs are rare.
+const (
+	barInterval  = 100 * time.Millisecond
+	lineInterval = 5 * time.Second
+	barWidth     = 30
+)
+
+// Progress renders the progress of a gozip or gounzip run for --progress:
+// a bar redrawn in place with throughput and ETA if out is a terminal,
+// and otherwise a status line every few seconds.
+type Progress struct {
+	mu    sync.Mutex
+	out   io.Writer
+	tty   bool
+	total uint64
+	start time.Time
+	last  time.Time
+	shown bool // a bar is drawn on the current line
+	ended bool
+	done  uint64
+}
+
+// NewProgress returns a Progress writing to out for a run processing
+// total bytes, or an unknown amount if total is zero. A total reported
+// by the updates replaces it.
+func NewProgress(out *os.File, total uint64) *Progress {
+	fi, err := out.Stat()
+	tty := err == nil && fi.Mode()&os.ModeCharDevice != 0
+	return newProgress(out, tty, total, time.Now())
+}
+
+func newProgress(out io.Writer, tty bool, total uint64, start time.Time) *Progress {
+	return &Progress{out: out, tty: tty, total: total, start: start}
+}
+
+// Update records p, as passed to the OnProgress hooks, and redraws the
+// progress if it is due.
+func (b *Progress) Update(p ziplib.Progress) {
+	b.update(p.Bytes, p.Total, time.Now())
+}
+
+func (b *Progress) update(done, total uint64, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = done
+	if total > 0 {
+		b.total = total
+	}
+	interval := lineInterval
+	if b.tty {
+		interval = barInterval
+	}
+	if now.Sub(b.last) < interval {
+		return
+	}
+	b.last = now
+	b.draw(now)
+}
+
+// Finish shows the final progress and ends the bar's line. Later calls
+// do nothing.
+func (b *Progress) Finish() {
+	b.finish(time.Now())
+}
+
+func (b *Progress) finish(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ended {
+		return
+	}
+	b.ended = true
+	b.draw(now)
+	if b.tty {
+		fmt.Fprintln(b.out)
+		b.shown = false
+	}
+}
+
+// draw writes the current progress: over the previous bar on a
+// terminal, or as a new line.
+func (b *Progress) draw(now time.Time) {
+	elapsed := now.Sub(b.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(b.done) / elapsed.Seconds()
+	}
+	var line strings.Builder
+	if b.tty {
+		line.WriteString("\r\033[K")
+	}
+	if b.total > 0 {
+		frac := min(float64(b.done)/float64(b.total), 1)
+		if b.tty {
+			filled := int(frac * barWidth)
+			fmt.Fprintf(&line, "[%s%s] ", strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled))
+		}
+		fmt.Fprintf(&line, "%3.0f%%  %s / %s  %s/s", 100*frac, formatBytes(b.done), formatBytes(b.total), formatBytes(uint64(rate)))
+		if rate > 0 && b.done < b.total {
+			eta := time.Duration(float64(b.total-b.done) / rate * float64(time.Second))
+			fmt.Fprintf(&line, "  ETA %s", eta.Round(time.Second))
+		}
+	} else {
+		fmt.Fprintf(&line, "%s  %s/s", formatBytes(b.done), formatBytes(uint64(rate)))
+	}
+	if !b.tty {
+		line.WriteByte('\n')
+	}
+	io.WriteString(b.out, line.String()) //nolint:errcheck // Progress output is best effort.
+	b.shown = b.tty
+}
+
+// Above returns a writer for status messages shown while the progress is
+// drawn: on a terminal, the bar is cleared before each message and drawn
+// again below it.
+func (b *Progress) Above(w io.Writer) io.Writer {
+	if !b.tty {
+		return w
+	}
+	return &aboveWriter{b: b, w: w}
+}
+
+type aboveWriter struct {
+	b *Progress
+	w io.Writer
+}
+
+func (a *aboveWriter) Write(p []byte) (int, error) {
+	a.b.mu.Lock()
+	defer a.b.mu.Unlock()
+	if a.b.shown {
+		io.WriteString(a.b.out, "\r\033[K") //nolint:errcheck // Progress output is best effort.
+	}
+	n, err := a.w.Write(p)
+	if a.b.shown {
+		a.b.draw(time.Now())
+	}
+	return n, err //nolint:wrapcheck // Pass-through writer.
+}
+
+// formatBytes formats n with a binary unit, e.g. "12.3 MiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
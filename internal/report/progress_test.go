@@ -0,0 +1,59 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressLines(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newProgress(&out, false, 4<<20, start)
+
+	p.update(1<<20, 0, start.Add(10*time.Second))
+	p.update(2<<20, 0, start.Add(11*time.Second)) // Too soon for another line.
+	p.update(4<<20, 0, start.Add(12*time.Second))
+	p.finish(start.Add(20 * time.Second))
+	p.finish(start.Add(21 * time.Second))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []string{
+		" 25%  1.0 MiB / 4.0 MiB  102.4 KiB/s  ETA 30s",
+		"100%  4.0 MiB / 4.0 MiB  204.8 KiB/s",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got lines %q, want %q", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestProgressBarAbove(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Now()
+	p := newProgress(&out, true, 100, start)
+	p.update(50, 0, start.Add(time.Second))
+	if !strings.Contains(out.String(), "["+strings.Repeat("=", barWidth/2)+strings.Repeat(" ", barWidth/2)+"]  50%") {
+		t.Errorf("bar = %q", out.String())
+	}
+	out.Reset()
+	if _, err := p.Above(&out).Write([]byte("  inflating: a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.HasPrefix(got, "\r\033[K  inflating: a\n\r\033[K[") {
+		t.Errorf("message not drawn above the bar: %q", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[uint64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Package report renders the outcome of a gozip or gounzip run, as a single
+// JSON document for orchestration systems or as text summaries.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// Exit reasons reported for a run.
+const (
+	ReasonOK          = "ok"
+	ReasonError       = "error"
+	ReasonUnsupported = "unsupported"
+	ReasonCRC         = "crc"
+	ReasonNoSpace     = "no-space"
+	ReasonLimit       = "limit"
+)
+
+// Report is the final result of one CLI run.
+type Report struct {
+	Command           string   `json:"command"`
+	Archive           string   `json:"archive"`
+	Entries           int      `json:"entries"`
+	Skipped           int      `json:"skipped"`
+	UncompressedBytes uint64   `json:"uncompressedBytes"`
+	CompressedBytes   uint64   `json:"compressedBytes"`
+	DurationSeconds   float64  `json:"durationSeconds"`
+	ExitReason        string   `json:"exitReason"`
+	Errors            []string `json:"errors"`
+}
+
+// New builds the report of a run of command on archive that produced res
+// and err after taking d.
+func New(command, archive string, res ziplib.Result, d time.Duration, err error) Report {
+	return Report{
+		Command:           command,
+		Archive:           archive,
+		Entries:           res.Entries,
+		Skipped:           res.Skipped,
+		UncompressedBytes: res.UncompressedBytes,
+		CompressedBytes:   res.CompressedBytes,
+		DurationSeconds:   d.Seconds(),
+		ExitReason:        ExitReason(err),
+		Errors:            messages(err),
+	}
+}
+
+// ExitReason classifies err into one of the Reason constants.
+func ExitReason(err error) string {
+	var (
+		unsupported *ziplib.UnsupportedError
+		crc         *ziplib.CRCError
+		space       *ziplib.SpaceError
+		limit       *ziplib.LimitError
+	)
+	switch {
+	case err == nil:
+		return ReasonOK
+	case errors.As(err, &unsupported):
+		return ReasonUnsupported
+	case errors.As(err, &crc):
+		return ReasonCRC
+	case errors.As(err, &space):
+		return ReasonNoSpace
+	case errors.As(err, &limit):
+		return ReasonLimit
+	default:
+		return ReasonError
+	}
+}
+
+// messages flattens err, splitting errors joined with errors.Join.
+func messages(err error) []string {
+	if err == nil {
+		return []string{}
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // Only the top level is split.
+		var msgs []string
+		for _, e := range joined.Unwrap() {
+			msgs = append(msgs, messages(e)...)
+		}
+		return msgs
+	}
+	return []string{err.Error()}
+}
+
+// Write encodes r as indented JSON to the file at path, or to standard
+// error if path is empty.
+func Write(path string, r Report) error {
+	if path == "" {
+		return encode(os.Stderr, r)
+	}
+	f, err := os.Create(path) //nolint:gosec // Report path is chosen by the user.
+	if err != nil {
+		return fmt.Errorf("create report: %w", err)
+	}
+	if err := encode(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close report: %w", err)
+	}
+	return nil
+}
+
+// WriteLine encodes r as a single line of JSON to w, to end a stream of
+// JSON objects such as that of gozip --json.
+func WriteLine(w io.Writer, r Report) error {
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+func encode(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// ParseFormat validates an --output flag value.
+// It reports whether JSON output was requested.
+func ParseFormat(s string) (bool, error) {
+	switch s {
+	case "text":
+		return false, nil
+	case "json":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid output %q: must be text or json", s)
+	}
+}
+
+// NewLogger returns the logger selected by a --log-format flag value:
+// nil for text, so status messages are printed as usual, or a logger
+// writing one JSON object per event to w for json.
+func NewLogger(format string, w io.Writer) (*slog.Logger, error) {
+	switch format {
+	case "text":
+		return nil, nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, nil)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be text or json", format)
+	}
+}
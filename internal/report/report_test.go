@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+func TestExitReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ReasonOK},
+		{errors.New("boom"), ReasonError},
+		{&ziplib.UnsupportedError{}, ReasonUnsupported},
+		{fmt.Errorf("wrapped: %w", &ziplib.CRCError{Entry: "a"}), ReasonCRC},
+		{&ziplib.SpaceError{}, ReasonNoSpace},
+		{errors.Join(errors.New("x"), &ziplib.LimitError{Limit: "MaxEntries"}), ReasonLimit},
+	}
+	for _, tt := range tests {
+		if got := ExitReason(tt.err); got != tt.want {
+			t.Errorf("ExitReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+	err := errors.Join(errors.New("first"), errors.New("second"))
+	r := New("gounzip", "a.zip", ziplib.Result{Entries: 3, Skipped: 1, UncompressedBytes: 30}, 2*time.Second, err)
+	if err := Write(path, r); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, b)
+	}
+	if got["entries"] != 3.0 || got["skipped"] != 1.0 || got["durationSeconds"] != 2.0 || got["exitReason"] != ReasonError {
+		t.Errorf("report = %s", b)
+	}
+	var back Report
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(back.Errors, []string{"first", "second"}) {
+		t.Errorf("Errors = %q, want [first second]", back.Errors)
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteLine(&buf, New("gozip", "a.zip", ziplib.Result{Entries: 2}, time.Second, nil)); err != nil {
+		t.Fatalf("WriteLine: %v", err)
+	}
+	line, rest, _ := strings.Cut(buf.String(), "\n")
+	if rest != "" {
+		t.Errorf("report spans several lines: %q", buf.String())
+	}
+	var got Report
+	if err := json.Unmarshal([]byte(line), &got); err != nil || got.Entries != 2 || got.ExitReason != ReasonOK {
+		t.Errorf("report = %q (%v)", line, err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if j, err := ParseFormat("json"); err != nil || !j {
+		t.Errorf("ParseFormat(json) = %v, %v", j, err)
+	}
+	if j, err := ParseFormat("text"); err != nil || j {
+		t.Errorf("ParseFormat(text) = %v, %v", j, err)
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("expected error for yaml")
+	}
+}
@@ -0,0 +1,29 @@
+package report
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jaeyeom/gozip/ziplib"
+)
+
+// WriteStats prints s as the summary shown by gozip --stats and
+// gounzip -l --totals.
+func WriteStats(w io.Writer, s ziplib.ArchiveStats) {
+	fmt.Fprintf(w, "entries:      %d (%d files, %d directories)\n", s.Entries, s.Entries-s.Dirs, s.Dirs)
+	fmt.Fprintf(w, "uncompressed: %d bytes\n", s.UncompressedBytes)
+	fmt.Fprintf(w, "compressed:   %d bytes (%.1f%% saved)\n", s.CompressedBytes, 100*s.Ratio())
+	if len(s.Methods) > 0 {
+		fmt.Fprintf(w, "methods:\n")
+	}
+	for _, m := range s.Methods {
+		fmt.Fprintf(w, "  %-10s %6d entries %12d -> %12d bytes (%.1f%% saved)\n",
+			m.Name, m.Entries, m.UncompressedBytes, m.CompressedBytes, 100*m.Ratio())
+	}
+	if len(s.Largest) > 0 {
+		fmt.Fprintf(w, "largest:\n")
+	}
+	for _, e := range s.Largest {
+		fmt.Fprintf(w, "  %12d  %s\n", e.UncompressedSize, e.Name)
+	}
+}
@@ -0,0 +1,83 @@
+// Package shellwords splits command lines into arguments like a POSIX
+// shell, for --exec commands and option defaults read from the
+// environment.
+package shellwords
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Split splits s into arguments. Single quotes, double quotes, and
+// backslash escapes are honored as in a POSIX shell; no other shell syntax
+// is interpreted. A blank s yields no arguments.
+func Split(s string) ([]string, error) {
+	var sp splitter
+	for _, r := range s {
+		sp.add(r)
+	}
+	if sp.quote != 0 || sp.escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	sp.endArg()
+	return sp.args, nil
+}
+
+// splitter holds the state of Split between characters.
+type splitter struct {
+	args    []string
+	cur     strings.Builder
+	inArg   bool
+	quote   rune // the open quote, if any
+	escaped bool // after an unquoted or double-quoted backslash
+}
+
+// add adds the next character r of the command line.
+func (sp *splitter) add(r rune) {
+	switch {
+	case sp.escaped:
+		// Inside double quotes a backslash only escapes a few characters.
+		if sp.quote == '"' && !strings.ContainsRune("$`\"\\\n", r) {
+			sp.cur.WriteByte('\\')
+		}
+		sp.cur.WriteRune(r)
+		sp.escaped = false
+	case r == '\\' && sp.quote != '\'':
+		sp.escaped, sp.inArg = true, true
+	case sp.quote != 0:
+		if r == sp.quote {
+			sp.quote = 0
+		} else {
+			sp.cur.WriteRune(r)
+		}
+	case r == '\'' || r == '"':
+		sp.quote, sp.inArg = r, true
+	case r == ' ' || r == '\t' || r == '\n':
+		sp.endArg()
+	default:
+		sp.cur.WriteRune(r)
+		sp.inArg = true
+	}
+}
+
+// endArg ends the current argument, if one has started.
+func (sp *splitter) endArg() {
+	if sp.inArg {
+		sp.args = append(sp.args, sp.cur.String())
+		sp.cur.Reset()
+		sp.inArg = false
+	}
+}
+
+// FromEnv returns the default flags held by the environment variable
+// name, like the ZIPOPT and UNZIP variables of Info-ZIP, or none if it is
+// unset or blank. Commands parse them before the command line, so that
+// its flags take precedence.
+func FromEnv(name string) ([]string, error) {
+	args, err := Split(os.Getenv(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid default options in %s: %w", name, err)
+	}
+	return args, nil
+}
@@ -0,0 +1,33 @@
+package shellwords
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"blank", "  ", nil, false},
+		{"flags", "-r -9", []string{"-r", "-9"}, false},
+		{"quoted", `-x '*.o' -d "out dir"`, []string{"-x", "*.o", "-d", "out dir"}, false},
+		{"unterminated", `-x "*.o`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOZIP_TEST_OPTS", tt.value)
+			got, err := FromEnv("GOZIP_TEST_OPTS")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromEnv error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FromEnv = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package ziplib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// installFile moves the complete file at tmp to dest. Unless overwrite is
+// set it fails with an error matching fs.ErrExist if dest exists; the
+// check and the move are one step where the filesystem supports hard
+// links, so a concurrent writer cannot slip in between.
+func installFile(tmp, dest string, overwrite bool) error {
+	if overwrite {
+		if err := os.Rename(tmp, dest); err != nil {
+			return fmt.Errorf("replace archive: %w", err)
+		}
+		return nil
+	}
+	err := os.Link(tmp, dest)
+	if err == nil {
+		_ = os.Remove(tmp)
+		return nil
+	}
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	// Filesystems without hard links fall back to a separate check.
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("creating archive: %w", &fs.PathError{Op: "create", Path: dest, Err: fs.ErrExist})
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	return nil
+}
+
+// lockCurrent locks f, opened from path, and reports whether path still
+// names f once the lock is held. Zip replaces an archive by renaming a new
+// file over it, so a writer that waited for the lock may hold it on a file
+// no longer in place, whose changes would be lost; it must open path again.
+func lockCurrent(f *os.File, path string) (bool, error) {
+	if err := lockFile(f); err != nil {
+		return false, err
+	}
+	held, err := f.Stat()
+	if err != nil {
+		return false, fmt.Errorf("stat archive: %w", err)
+	}
+	cur, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat archive: %w", err)
+	}
+	return os.SameFile(held, cur), nil
+}
+
+// openLocked opens the existing archive at zipPath to change it in place
+// and locks it, opening it again if it was replaced while waiting.
+func openLocked(zipPath string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(zipPath, os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("open archive: %w", err)
+		}
+		current, err := lockCurrent(f, zipPath)
+		if err == nil && current {
+			return f, nil
+		}
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isArchive reports whether info is the file the archive is being
+// written to, which a walk of the directory holding it would otherwise
+// add to itself.
+func (a *archiver) isArchive(info os.FileInfo) bool {
+	return a.self != nil && os.SameFile(a.self, info)
+}
@@ -0,0 +1,63 @@
+package ziplib
+
+import (
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// An entry is stored rather than compressed when deflating its leading
+// sample at the fastest level saves less than 1/autoStoreMinSaving of it.
+// Data that barely shrinks at level 1, such as JPEG, MP4 or other
+// archives, does not shrink usefully at higher levels either.
+const autoStoreMinSaving = 50
+
+// sampleCompresses reads up to len(buf) bytes from r into buf and reports
+// whether they compress well enough to be worth compressing. It returns
+// the number of bytes read, which the caller must write before the rest
+// of r. Empty input is reported as compressible, leaving the method as
+// configured.
+func sampleCompresses(r io.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, false, err //nolint:wrapcheck // Callers wrap with the file name.
+	}
+	if n == 0 {
+		return 0, true, nil
+	}
+	var c byteCounter
+	fw, err := newFlateWriter(&c, flate.BestSpeed)
+	if err != nil {
+		return n, false, err
+	}
+	_, _ = fw.Write(buf[:n]) // Writes to a byteCounter cannot fail.
+	_ = fw.Close()
+	return n, int(c) < n-n/autoStoreMinSaving, nil
+}
+
+// byteCounter is a writer that only counts the bytes written to it.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// autoStoreSample opens the file at path and samples it as writeFile does,
+// for a dry run to report the method the entry would get.
+func autoStoreSample(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	bp := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(bp)
+	_, ok, err := sampleCompresses(f, *bp)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", path, err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,58 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestZipAutoStore(t *testing.T) {
+	src := t.TempDir()
+	random := make([]byte, 100<<10)
+	rng := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // Test data need not be secure.
+	for i := range random {
+		random[i] = byte(rng.Uint32())
+	}
+	text := strings.Repeat("compressible text ", 5000)
+	writeFile(t, filepath.Join(src, "photo.jpg"), string(random))
+	writeFile(t, filepath.Join(src, "notes.txt"), text)
+	writeFile(t, filepath.Join(src, "empty.txt"), "")
+
+	zipPath := filepath.Join(t.TempDir(), "auto.zip")
+	if err := Zip(zipPath, []string{src}, ZipOptions{Recursive: true, CompressionLevel: -1, AutoStore: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	methods := make(map[string]uint16)
+	for _, f := range r.File {
+		methods[filepath.Base(f.Name)] = f.Method
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if _, err := copyBuffer(&b, rc); err != nil {
+			t.Errorf("read %s: %v", f.Name, err)
+		}
+		rc.Close()
+		want := map[string]string{"photo.jpg": string(random), "notes.txt": text}[filepath.Base(f.Name)]
+		if b.String() != want {
+			t.Errorf("%s content differs after the sample", f.Name)
+		}
+	}
+	if methods["photo.jpg"] != zip.Store {
+		t.Errorf("photo.jpg method = %d, want store", methods["photo.jpg"])
+	}
+	if methods["notes.txt"] != zip.Deflate || methods["empty.txt"] != zip.Deflate {
+		t.Errorf("methods = %v, want deflate for notes.txt and empty.txt", methods)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build darwin || freebsd || netbsd
+
+package ziplib
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// birthTime returns the creation time in st, the status of the file at
+// path.
+func birthTime(_ string, st *unix.Stat_t) (time.Time, bool) {
+	if st.Btim.Sec <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(st.Btim.Sec), int64(st.Btim.Nsec)), true //nolint:unconvert // Field types vary by platform.
+}
@@ -0,0 +1,14 @@
+//go:build unix && !linux && !darwin && !freebsd && !netbsd
+
+package ziplib
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// birthTime reports no creation time on systems that do not record one.
+func birthTime(string, *unix.Stat_t) (time.Time, bool) {
+	return time.Time{}, false
+}
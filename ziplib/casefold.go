@@ -0,0 +1,141 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// CaseCollisionPolicy selects what Unzip does with entries whose names
+// differ only by case, such as README and readme, when the output
+// directory is on a case-insensitive filesystem where they would be
+// extracted to the same file.
+type CaseCollisionPolicy int
+
+const (
+	// CaseCollisionError fails before anything is extracted. It is the
+	// default.
+	CaseCollisionError CaseCollisionPolicy = iota
+	// CaseCollisionRename extracts each later entry under the first free
+	// name of the form readme~1, readme~2 and so on.
+	CaseCollisionRename
+	// CaseCollisionLastWins extracts only the last of the colliding
+	// entries; the earlier ones are counted as skipped.
+	CaseCollisionLastWins
+)
+
+// ParseCaseCollisionPolicy converts a policy name ("error", "rename" or
+// "last-wins") to a CaseCollisionPolicy.
+func ParseCaseCollisionPolicy(s string) (CaseCollisionPolicy, error) {
+	switch s {
+	case "error", "":
+		return CaseCollisionError, nil
+	case "rename":
+		return CaseCollisionRename, nil
+	case "last-wins":
+		return CaseCollisionLastWins, nil
+	default:
+		return 0, fmt.Errorf("invalid case collision policy %q: must be error, rename or last-wins", s)
+	}
+}
+
+// caseInsensitiveDir reports whether dir, or its closest existing
+// ancestor, is on a case-insensitive filesystem. It looks up the deepest
+// path element with letters under a different case, so nothing is
+// written. Without such an element it assumes the platform default.
+func caseInsensitiveDir(dir string) bool {
+	for p := existingAncestor(dir); ; p = filepath.Dir(p) {
+		base := filepath.Base(p)
+		if swapped := swapCase(base); swapped != base {
+			orig, err1 := os.Stat(p)
+			other, err2 := os.Stat(filepath.Join(filepath.Dir(p), swapped))
+			return err1 == nil && err2 == nil && os.SameFile(orig, other)
+		}
+		if filepath.Dir(p) == p {
+			return runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+		}
+	}
+}
+
+// swapCase returns s with upper and lower case letters exchanged.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// resolveCaseCollisions applies CaseCollisions to the files of selected
+// whose destinations differ only by case, and returns the entries left to
+// extract. It does nothing unless insensitive is true. Entries with
+// exactly the same destination are left to OnConflict.
+func (x *extractor) resolveCaseCollisions(selected []*zip.File, insensitive bool) ([]*zip.File, error) {
+	if !insensitive {
+		return selected, nil
+	}
+	owner := make(map[string]*zip.File) // folded destination to the entry extracted there
+	dests := make(map[*zip.File]string)
+	dropped := make(map[*zip.File]bool)
+	var errs []error
+	for _, f := range selected {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		destPath, err := x.destination(f)
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(destPath)
+		prev, ok := owner[key]
+		if ok && dests[prev] == destPath {
+			continue
+		}
+		if ok {
+			switch x.opts.CaseCollisions {
+			case CaseCollisionRename:
+				x.names[f] = freeName(x.names[f], func(name string) bool {
+					x.names[f] = name
+					p, err := x.destination(f)
+					return err != nil || owner[strings.ToLower(p)] != nil
+				})
+				destPath, _ = x.destination(f)
+				key = strings.ToLower(destPath)
+			case CaseCollisionLastWins:
+				dropped[prev] = true
+			default:
+				errs = append(errs, fmt.Errorf("case collision: %s and %s extract to the same file", prev.Name, f.Name))
+				continue
+			}
+		}
+		owner[key] = f
+		dests[f] = destPath
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return x.dropCollisions(selected, dropped, dests), nil
+}
+
+// dropCollisions returns the entries of selected that are not dropped,
+// recording the dropped ones, which extract to dests, as skipped.
+func (x *extractor) dropCollisions(selected []*zip.File, dropped map[*zip.File]bool, dests map[*zip.File]string) []*zip.File {
+	if len(dropped) == 0 {
+		return selected
+	}
+	kept := make([]*zip.File, 0, len(selected)-len(dropped))
+	for _, f := range selected {
+		if dropped[f] {
+			x.skipped(f, dests[f], "case collision")
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
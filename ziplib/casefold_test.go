@@ -0,0 +1,100 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// caseCollisionExtractor opens an archive holding README, docs/a.txt,
+// readme and Docs/A.txt and returns an extractor for it and its entries.
+func caseCollisionExtractor(t *testing.T, policy CaseCollisionPolicy) (*extractor, []*zip.File) {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "case.zip")
+	var headers []*zip.FileHeader
+	for _, name := range []string{"README", "docs/a.txt", "readme", "Docs/A.txt"} {
+		headers = append(headers, &zip.FileHeader{Name: name, Method: zip.Deflate})
+	}
+	writeTestZip(t, zipPath, "x", headers...)
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	dir := t.TempDir()
+	x := &extractor{
+		opts:         UnzipOptions{CaseCollisions: policy},
+		outputDir:    dir,
+		absOutputDir: dir,
+		result:       &Result{},
+		names:        make(map[*zip.File]string),
+	}
+	for _, f := range r.File {
+		x.rename(f)
+	}
+	return x, r.File
+}
+
+func TestResolveCaseCollisions(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		x, files := caseCollisionExtractor(t, CaseCollisionError)
+		_, err := x.resolveCaseCollisions(files, true)
+		if err == nil || !strings.Contains(err.Error(), "README and readme") || !strings.Contains(err.Error(), "docs/a.txt and Docs/A.txt") {
+			t.Errorf("err = %v, want both collisions reported", err)
+		}
+	})
+
+	t.Run("case-sensitive", func(t *testing.T) {
+		x, files := caseCollisionExtractor(t, CaseCollisionError)
+		got, err := x.resolveCaseCollisions(files, false)
+		if err != nil || len(got) != len(files) {
+			t.Errorf("got %d entries, %v, want all entries", len(got), err)
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		x, files := caseCollisionExtractor(t, CaseCollisionRename)
+		got, err := x.resolveCaseCollisions(files, true)
+		if err != nil || len(got) != len(files) {
+			t.Fatalf("got %d entries, %v, want all entries", len(got), err)
+		}
+		var names []string
+		for _, f := range got {
+			names = append(names, x.names[f])
+		}
+		if want := []string{"README", "docs/a.txt", "readme~1", "Docs/A~1.txt"}; !slices.Equal(names, want) {
+			t.Errorf("names = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("last-wins", func(t *testing.T) {
+		x, files := caseCollisionExtractor(t, CaseCollisionLastWins)
+		got, err := x.resolveCaseCollisions(files, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range got {
+			names = append(names, f.Name)
+		}
+		if want := []string{"readme", "Docs/A.txt"}; !slices.Equal(names, want) {
+			t.Errorf("kept = %v, want %v", names, want)
+		}
+		if x.result.Skipped != 2 {
+			t.Errorf("skipped = %d, want 2", x.result.Skipped)
+		}
+	})
+}
+
+func TestCaseInsensitiveDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Probe")
+	writeFile(t, dir, "")
+	lower := filepath.Join(filepath.Dir(dir), "probe")
+	_, err := filepath.EvalSymlinks(lower)
+	if got, want := caseInsensitiveDir(dir), err == nil; got != want {
+		t.Errorf("caseInsensitiveDir = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,327 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/text/encoding"
+)
+
+// Central directory record signatures and sizes.
+const (
+	centralHeaderSig   = 0x02014b50
+	dirEndSig          = 0x06054b50
+	dir64LocatorSig    = 0x07064b50
+	dir64EndSig        = 0x06064b50
+	centralHeaderLen   = 46
+	dirEndLen          = 22
+	dir64LocatorLen    = 20
+	dir64EndLen        = 56
+	maxDirEndComment   = 1<<16 - 1
+	extraNTFS          = 0x000a
+	extraUnixTimes     = 0x000d
+	extraInfoZipUnix   = 0x5855
+	centralScanBufSize = 64 << 10
+)
+
+// dirEnd describes the central directory of an archive, as found from its
+// end records.
+type dirEnd struct {
+	off     int64  // offset of the central directory in the file
+	base    int64  // bytes prepended to the archive, which recorded offsets exclude
+	count   uint64 // number of entries
+	length  int64  // size of the central directory
+	comment string // archive comment
+}
+
+// centralDirectory locates the central directory of the archive of the
+// given size read from r. Archives with data prepended, such as
+// self-extracting ones, are found like archive/zip finds them.
+func centralDirectory(r io.ReaderAt, size int64) (dirEnd, error) {
+	tail := min(size, dirEndLen+maxDirEndComment)
+	buf := make([]byte, tail)
+	if _, err := r.ReadAt(buf, size-tail); err != nil && !errors.Is(err, io.EOF) {
+		return dirEnd{}, fmt.Errorf("read end of central directory: %w", err)
+	}
+	p := findDirEnd(buf)
+	if p < 0 {
+		return dirEnd{}, zip.ErrFormat
+	}
+	le := binary.LittleEndian
+	end := size - tail + int64(p)
+	b := buf[p:]
+	d := dirEnd{
+		count:   uint64(le.Uint16(b[10:])),
+		length:  int64(le.Uint32(b[12:])),
+		off:     int64(le.Uint32(b[16:])),
+		comment: string(b[dirEndLen : dirEndLen+int(le.Uint16(b[20:]))]),
+	}
+	if d.count == 0xffff || d.length == 0xffffffff || d.off == 0xffffffff {
+		var err error
+		if end, err = d.readZip64(r, end); err != nil {
+			return dirEnd{}, err
+		}
+	}
+	if d.length < 0 || d.off < 0 || d.length > end {
+		return dirEnd{}, zip.ErrFormat
+	}
+	recorded := d.off
+	// Data prepended to the archive shifts every offset; the directory
+	// then ends right where the end record starts.
+	if !hasSignature(r, d.off, centralHeaderSig) {
+		d.off = end - d.length
+	}
+	if d.count > 0 && !hasSignature(r, d.off, centralHeaderSig) {
+		return dirEnd{}, zip.ErrFormat
+	}
+	d.base = d.off - recorded
+	return d, nil
+}
+
+// findDirEnd returns the offset in buf of the last end of central
+// directory record whose comment fits in buf, or -1.
+func findDirEnd(buf []byte) int {
+	le := binary.LittleEndian
+	for i := len(buf) - dirEndLen; i >= 0; i-- {
+		if le.Uint32(buf[i:]) == dirEndSig && i+dirEndLen+int(le.Uint16(buf[i+20:])) <= len(buf) {
+			return i
+		}
+	}
+	return -1
+}
+
+// readZip64 replaces the saturated fields of d with those of the Zip64
+// end of central directory record, if the end record at end has one, and
+// returns where that record starts, which then ends the directory.
+func (d *dirEnd) readZip64(r io.ReaderAt, end int64) (int64, error) {
+	end64, ok := findDir64End(r, end)
+	if !ok {
+		return end, nil
+	}
+	var b [dir64EndLen]byte
+	if _, err := r.ReadAt(b[:], end64); err != nil {
+		return 0, fmt.Errorf("read zip64 end of central directory: %w", err)
+	}
+	le := binary.LittleEndian
+	if le.Uint32(b[:]) != dir64EndSig {
+		return 0, zip.ErrFormat
+	}
+	d.count = le.Uint64(b[32:])
+	d.length = int64(le.Uint64(b[40:])) //nolint:gosec // Checked against the file size by centralDirectory.
+	d.off = int64(le.Uint64(b[48:]))    //nolint:gosec // Checked against the file size by centralDirectory.
+	return end64, nil
+}
+
+// findDir64End returns the offset of the Zip64 end of central directory
+// record, read from the locator preceding the end record at end.
+func findDir64End(r io.ReaderAt, end int64) (int64, bool) {
+	if end < dir64LocatorLen {
+		return 0, false
+	}
+	var loc [dir64LocatorLen]byte
+	if _, err := r.ReadAt(loc[:], end-dir64LocatorLen); err != nil {
+		return 0, false
+	}
+	if binary.LittleEndian.Uint32(loc[:]) != dir64LocatorSig {
+		return 0, false
+	}
+	off := binary.LittleEndian.Uint64(loc[8:])
+	if off > uint64(end) { //nolint:gosec // Offsets are never negative.
+		return 0, false
+	}
+	return int64(off), true //nolint:gosec // Bounded by end.
+}
+
+// hasSignature reports whether the 4 bytes at off in r are sig.
+func hasSignature(r io.ReaderAt, off int64, sig uint32) bool {
+	var b [4]byte
+	if _, err := r.ReadAt(b[:], off); err != nil {
+		return false
+	}
+	return binary.LittleEndian.Uint32(b[:]) == sig
+}
+
+// centralInfo holds the fields of a central directory record that
+// zip.FileHeader lacks.
+type centralInfo struct {
+	internalAttrs uint16
+	offset        int64 // of the local header, as recorded
+}
+
+// scanCentralDirectory calls fn with the header and centralInfo of each
+// entry in the central directory of the archive of the given size read
+// from r, in archive order, until fn returns false. Only one header is held at a time,
+// so memory use does not grow with the number of entries. Names are
+// decoded as in openArchive.
+func scanCentralDirectory(r io.ReaderAt, size int64, enc encoding.Encoding, fn func(h *zip.FileHeader, info centralInfo) bool) error {
+	d, err := centralDirectory(r, size)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	br := bufio.NewReaderSize(io.NewSectionReader(r, d.off, size-d.off), centralScanBufSize)
+	for i := uint64(0); i < d.count; i++ {
+		h, info, err := readCentralHeader(br)
+		if err != nil {
+			return fmt.Errorf("open archive: entry %d: %w", i, err)
+		}
+		decodeName(h, enc)
+		if !fn(h, info) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// readCentralHeader reads one central directory file header from r,
+// resolving Zip64 sizes and offsets and the modification time as
+// archive/zip does. It also returns the fields zip.FileHeader lacks.
+func readCentralHeader(r io.Reader) (*zip.FileHeader, centralInfo, error) {
+	var b [centralHeaderLen]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, centralInfo{}, fmt.Errorf("read central directory: %w", err)
+	}
+	le := binary.LittleEndian
+	if le.Uint32(b[:]) != centralHeaderSig {
+		return nil, centralInfo{}, zip.ErrFormat
+	}
+	nameLen, extraLen, commentLen := int(le.Uint16(b[28:])), int(le.Uint16(b[30:])), int(le.Uint16(b[32:]))
+	rest := make([]byte, nameLen+extraLen+commentLen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, centralInfo{}, fmt.Errorf("read central directory: %w", err)
+	}
+	h := &zip.FileHeader{
+		CreatorVersion:     le.Uint16(b[4:]),
+		ReaderVersion:      le.Uint16(b[6:]),
+		Flags:              le.Uint16(b[8:]),
+		Method:             le.Uint16(b[10:]),
+		ModifiedTime:       le.Uint16(b[12:]),
+		ModifiedDate:       le.Uint16(b[14:]),
+		CRC32:              le.Uint32(b[16:]),
+		CompressedSize64:   uint64(le.Uint32(b[20:])),
+		UncompressedSize64: uint64(le.Uint32(b[24:])),
+		ExternalAttrs:      le.Uint32(b[38:]),
+		Name:               string(rest[:nameLen]),
+		Extra:              rest[nameLen : nameLen+extraLen],
+		Comment:            string(rest[nameLen+extraLen:]),
+	}
+	info := centralInfo{internalAttrs: le.Uint16(b[36:]), offset: int64(le.Uint32(b[42:]))}
+
+	var modified time.Time
+	for _, f := range parseExtra(h.Extra) {
+		if f.tag == extraZip64 {
+			readZip64Extra(h, &info, f.data)
+		} else if t, ok := extraModified(f); ok {
+			modified = t
+		}
+	}
+	h.Modified = msDosTime(h.ModifiedDate, h.ModifiedTime)
+	if !modified.IsZero() {
+		dos := h.Modified
+		h.Modified = modified.UTC()
+		// The MS-DOS time is local to the writer; its offset from the
+		// Unix time gives the zone to present the time in.
+		if h.ModifiedTime != 0 || h.ModifiedDate != 0 {
+			h.Modified = modified.In(offsetZone(dos.Sub(modified)))
+		}
+	}
+	return h, info, nil
+}
+
+// readZip64Extra sets the sizes and offset saturated in the central
+// header h from the Zip64 extra field d, where only those are present,
+// in order.
+func readZip64Extra(h *zip.FileHeader, info *centralInfo, d []byte) {
+	le := binary.LittleEndian
+	if h.UncompressedSize64 == 0xffffffff && len(d) >= 8 {
+		h.UncompressedSize64, d = le.Uint64(d), d[8:]
+	}
+	if h.CompressedSize64 == 0xffffffff && len(d) >= 8 {
+		h.CompressedSize64, d = le.Uint64(d), d[8:]
+	}
+	if info.offset == 0xffffffff && len(d) >= 8 {
+		info.offset = int64(le.Uint64(d)) //nolint:gosec // Only compared with other offsets.
+	}
+}
+
+// extraModified returns the modification time in the extra field f, if
+// it is one that records it.
+func extraModified(f extraField) (time.Time, bool) {
+	le := binary.LittleEndian
+	switch d := f.data; f.tag {
+	case extraNTFS:
+		return ntfsModified(d)
+	case extraUnixTimes, extraInfoZipUnix:
+		if len(d) >= 8 {
+			return time.Unix(int64(le.Uint32(d[4:])), 0), true
+		}
+	case extraExtTime:
+		if len(d) >= 5 && d[0]&1 != 0 {
+			return time.Unix(int64(le.Uint32(d[1:])), 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ntfsModified returns the modification time in an NTFS extra field.
+func ntfsModified(d []byte) (time.Time, bool) {
+	if len(d) < 4 {
+		return time.Time{}, false
+	}
+	d = d[4:] // Reserved.
+	for len(d) >= 4 {
+		tag, size := binary.LittleEndian.Uint16(d), int(binary.LittleEndian.Uint16(d[2:]))
+		d = d[4:]
+		if size > len(d) {
+			break
+		}
+		if tag == 1 && size == 24 {
+			// 100ns intervals since 1601-01-01.
+			ticks := int64(binary.LittleEndian.Uint64(d)) //nolint:gosec // Times before 30828 fit.
+			epoch := time.Date(1601, time.January, 1, 0, 0, 0, 0, time.UTC)
+			return time.Unix(epoch.Unix()+ticks/1e7, ticks%1e7*100), true
+		}
+		d = d[size:]
+	}
+	return time.Time{}, false
+}
+
+// msDosTime converts an MS-DOS date and time to a time in UTC.
+func msDosTime(date, t uint16) time.Time {
+	return time.Date(
+		int(date>>9+1980), time.Month(date>>5&0xf), int(date&0x1f),
+		int(t>>11), int(t>>5&0x3f), int(t&0x1f*2), 0, time.UTC)
+}
+
+// offsetZone returns a zone for offset rounded to a quarter hour, or UTC
+// if it is outside the range of real zones.
+func offsetZone(offset time.Duration) *time.Location {
+	offset = offset.Round(15 * time.Minute)
+	if offset < -12*time.Hour || offset > 14*time.Hour {
+		offset = 0
+	}
+	return time.FixedZone("", int(offset/time.Second))
+}
+
+// centralInfos returns the centralInfo of each of files, the entries of
+// the archive of the given size read from r as archive/zip opened it.
+// Records and files are matched by position.
+func centralInfos(r io.ReaderAt, size int64, files []*zip.File) (map[*zip.File]centralInfo, error) {
+	infos := make(map[*zip.File]centralInfo, len(files))
+	i := 0
+	err := scanCentralDirectory(r, size, nil, func(_ *zip.FileHeader, info centralInfo) bool {
+		if i < len(files) {
+			infos[files[i]] = info
+		}
+		i++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
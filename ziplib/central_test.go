@@ -0,0 +1,119 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// zipListing returns the entries of the archive in b as archive/zip reads
+// them, for comparison with the central directory scanner.
+func zipListing(t *testing.T, b []byte) []ListEntry {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make([]ListEntry, 0, len(r.File))
+	for _, f := range r.File {
+		entries = append(entries, listEntry(&f.FileHeader))
+	}
+	return entries
+}
+
+func equalListings(a, b []ListEntry) bool {
+	return slices.EqualFunc(a, b, func(x, y ListEntry) bool {
+		return x.Name == y.Name && x.UncompressedSize == y.UncompressedSize &&
+			x.CompressedSize == y.CompressedSize && x.CRC32 == y.CRC32 &&
+			x.Modified.Equal(y.Modified) && x.IsDir == y.IsDir
+	})
+}
+
+func TestEntriesReaderMatchesArchiveZip(t *testing.T) {
+	mtime := time.Date(2024, 5, 6, 7, 8, 10, 0, time.FixedZone("", 9*60*60))
+	var buf bytes.Buffer
+	buf.WriteString("#!/bin/sh\nexit 0\n") // Prepended data, as in a self-extracting archive.
+	// Offsets are left relative to the start of the archive proper.
+	w := zip.NewWriter(&buf)
+	headers := []*zip.FileHeader{
+		{Name: "dir/", Modified: mtime},
+		{Name: "dir/a.txt", Method: zip.Deflate, Modified: mtime, Comment: "a comment"},
+		{Name: "b.txt", Method: zip.Store},
+		{Name: "한글.txt", Method: zip.Deflate, Modified: mtime},
+	}
+	for _, h := range headers {
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !h.Mode().IsDir() {
+			fmt.Fprintf(fw, "content of %s", h.Name)
+		}
+	}
+	w.SetComment("archive comment")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ListReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ListReader: %v", err)
+	}
+	if want := zipListing(t, buf.Bytes()); !equalListings(got, want) {
+		t.Errorf("ListReader = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestEntriesReaderZip64Directory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes an archive with more than 65535 entries")
+	}
+	// More entries than the classic end record can count forces the
+	// Zip64 end of central directory record.
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i := range 1<<16 + 10 {
+		if _, err := w.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("f%d", i), Method: zip.Store}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	var last ListEntry
+	for e, err := range EntriesReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), ListOptions{}) {
+		if err != nil {
+			t.Fatalf("EntriesReaderWithOptions: %v", err)
+		}
+		n++
+		last = e
+	}
+	if n != 1<<16+10 || last.Name != fmt.Sprintf("f%d", 1<<16+9) {
+		t.Errorf("read %d entries ending with %q", n, last.Name)
+	}
+}
+
+func TestEntriesReaderRejectsDamagedDirectory(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	if _, err := w.Create("a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	// Corrupt the signature of the only central directory header.
+	b[bytes.Index(b, []byte("PK\x01\x02"))+2] = 0
+	if _, err := ListReader(bytes.NewReader(b), int64(len(b))); err == nil {
+		t.Error("expected an error for a damaged central directory")
+	}
+	if _, err := ListReader(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("expected an error for a file that is not a zip archive")
+	}
+}
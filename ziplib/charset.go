@@ -0,0 +1,57 @@
+package ziplib
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// charsets maps the code page names accepted in options to encodings.
+var charsets = map[string]encoding.Encoding{
+	"cp437":     charmap.CodePage437,
+	"cp850":     charmap.CodePage850,
+	"cp866":     charmap.CodePage866,
+	"cp949":     korean.EUCKR,
+	"euc-kr":    korean.EUCKR,
+	"cp932":     japanese.ShiftJIS,
+	"shift-jis": japanese.ShiftJIS,
+	"sjis":      japanese.ShiftJIS,
+	"euc-jp":    japanese.EUCJP,
+	"cp936":     simplifiedchinese.GBK,
+	"gbk":       simplifiedchinese.GBK,
+	"gb18030":   simplifiedchinese.GB18030,
+	"cp950":     traditionalchinese.Big5,
+	"big5":      traditionalchinese.Big5,
+	"cp1252":    charmap.Windows1252,
+	"latin1":    charmap.ISO8859_1,
+}
+
+// lookupCharset returns the encoding for a code page name such as "cp949"
+// or "shift-jis". Names are case-insensitive and "_" is accepted for "-".
+// An empty name returns nil, meaning no conversion.
+func lookupCharset(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil //nolint:nilnil // A nil encoding means no conversion.
+	}
+	enc, ok := charsets[strings.ReplaceAll(strings.ToLower(name), "_", "-")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported charset %q", name)
+	}
+	return enc, nil
+}
+
+// decodeCharset converts name from enc to UTF-8. The name is returned
+// unchanged if it cannot be decoded.
+func decodeCharset(name string, enc encoding.Encoding) string {
+	decoded, err := enc.NewDecoder().String(name)
+	if err != nil {
+		return name
+	}
+	return decoded
+}
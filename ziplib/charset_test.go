@@ -0,0 +1,84 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupCharset(t *testing.T) {
+	for _, name := range []string{"cp949", "CP437", "shift-jis", "Shift_JIS", "gbk"} {
+		if enc, err := lookupCharset(name); err != nil || enc == nil {
+			t.Errorf("lookupCharset(%q) = %v, %v", name, enc, err)
+		}
+	}
+	if enc, err := lookupCharset(""); err != nil || enc != nil {
+		t.Errorf("lookupCharset(\"\") = %v, %v; want nil, nil", enc, err)
+	}
+	if _, err := lookupCharset("klingon"); err == nil {
+		t.Error("expected error for unknown charset")
+	}
+}
+
+func TestDecodeCharset(t *testing.T) {
+	tests := []struct {
+		charset string
+		raw     string
+		want    string
+	}{
+		{"cp949", "\xc7\xd1\xb1\xdb.txt", "한글.txt"},
+		{"shift-jis", "\x93\xfa\x96\x7b.txt", "日本.txt"},
+		{"gbk", "\xd6\xd0\xce\xc4.txt", "中文.txt"},
+		{"cp437", "caf\x82.txt", "café.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.charset, func(t *testing.T) {
+			enc, err := lookupCharset(tt.charset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decodeCharset(tt.raw, enc); got != tt.want {
+				t.Errorf("decodeCharset(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnzipEncoding(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "cp949.zip")
+	extractDir := t.TempDir()
+
+	legacy := &zip.FileHeader{Name: "\xc7\xd1\xb1\xdb.txt", Method: zip.Deflate, NonUTF8: true}
+	// Names flagged as UTF-8 must not be transcoded.
+	modern := &zip.FileHeader{Name: "유니코드.txt", Method: zip.Deflate}
+	writeTestZip(t, zipPath, "data", legacy, modern)
+
+	err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, Encoding: "cp949"})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for _, name := range []string{"한글.txt", "유니코드.txt"} {
+		if _, err := os.Stat(filepath.Join(extractDir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+
+	entries, err := ListWithOptions(zipPath, ListOptions{Encoding: "cp949"})
+	if err != nil {
+		t.Fatalf("ListWithOptions: %v", err)
+	}
+	if entries[0].Name != "한글.txt" {
+		t.Errorf("listed name = %q, want %q", entries[0].Name, "한글.txt")
+	}
+}
+
+func TestUnzipUnknownEncoding(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "any.zip")
+	writeTestZip(t, zipPath, "x", &zip.FileHeader{Name: "a.txt"})
+
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: t.TempDir(), Encoding: "klingon"}); err == nil {
+		t.Fatal("expected error for unknown encoding")
+	}
+}
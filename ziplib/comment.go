@@ -0,0 +1,65 @@
+package ziplib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errCommentTooLong is returned for archive comments that do not fit the
+// 16-bit length of the end of central directory record.
+var errCommentTooLong = errors.New("archive comment longer than 65535 bytes")
+
+// Comment returns the comment of the archive at zipPath, stored in its end
+// of central directory record.
+func Comment(zipPath string) (string, error) {
+	f, err := os.Open(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("stat archive: %w", err)
+	}
+	d, err := centralDirectory(f, fi.Size())
+	if err != nil {
+		return "", fmt.Errorf("open archive: %w", err)
+	}
+	return d.comment, nil
+}
+
+// SetComment replaces the comment of the existing archive at zipPath,
+// like zip -z without files to add. Only the end records following the
+// central directory are rewritten, in place; entries are untouched. An
+// empty comment removes it.
+func SetComment(zipPath, comment string) error {
+	if len(comment) > maxDirEndComment {
+		return errCommentTooLong
+	}
+	f, err := openLocked(zipPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat archive: %w", err)
+	}
+	d, err := centralDirectory(f, fi.Size())
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	end := d.off + d.length
+	b := appendDirEnd(nil, d.count, d.length, d.off-d.base, comment)
+	if _, err := f.WriteAt(b, end); err != nil {
+		return fmt.Errorf("write comment: %w", err)
+	}
+	if err := f.Truncate(end + int64(len(b))); err != nil {
+		return fmt.Errorf("write comment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write comment: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package ziplib
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestZipComment(t *testing.T) {
+	dir := setupTestDir(t)
+	t.Chdir(dir)
+	zipPath := filepath.Join(t.TempDir(), "comment.zip")
+	if err := Zip(zipPath, []string{"hello.txt"}, ZipOptions{Comment: "line 1\nline 2"}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if got, err := Comment(zipPath); err != nil || got != "line 1\nline 2" {
+		t.Errorf("Comment = %q, %v; want the comment given to Zip", got, err)
+	}
+
+	if err := Grow(zipPath, []string{"foo.go"}, ZipOptions{Comment: "grown"}); err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+	contents, comment := zipContents(t, zipPath)
+	if len(contents) != 2 || comment != "grown" {
+		t.Errorf("after Grow: %d entries, comment %q; want 2 entries, comment %q", len(contents), comment, "grown")
+	}
+
+	long := strings.Repeat("x", maxDirEndComment+1)
+	if err := Zip(filepath.Join(t.TempDir(), "long.zip"), []string{"hello.txt"}, ZipOptions{Comment: long}); !errors.Is(err, errCommentTooLong) {
+		t.Errorf("Zip with a long comment: err = %v, want errCommentTooLong", err)
+	}
+}
+
+func TestSetComment(t *testing.T) {
+	dir := setupTestDir(t)
+	t.Chdir(dir)
+	zipPath := filepath.Join(t.TempDir(), "comment.zip")
+	if err := Zip(zipPath, []string{"hello.txt", "foo.go"}, ZipOptions{Comment: "a long original comment"}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	for _, want := range []string{"short", "a comment longer than the original one", ""} {
+		if err := SetComment(zipPath, want); err != nil {
+			t.Fatalf("SetComment(%q): %v", want, err)
+		}
+		contents, comment := zipContents(t, zipPath)
+		if comment != want {
+			t.Errorf("comment = %q, want %q", comment, want)
+		}
+		if contents["hello.txt"] != "hello world\n" || len(contents) != 2 {
+			t.Errorf("entries changed by SetComment: %v", contents)
+		}
+	}
+
+	if err := SetComment(zipPath, strings.Repeat("x", maxDirEndComment+1)); !errors.Is(err, errCommentTooLong) {
+		t.Errorf("SetComment with a long comment: err = %v, want errCommentTooLong", err)
+	}
+}
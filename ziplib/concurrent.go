@@ -0,0 +1,192 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// concurrentMaxSize is the size up to which files are compressed
+// concurrently with ZipOptions.Concurrency, in memory. Larger files are
+// compressed while they are written, one at a time.
+const concurrentMaxSize = 8 << 20
+
+// compressJob is a file compressed in the background, written to the
+// archive once the files before it are.
+type compressJob struct {
+	path, name string
+	info       os.FileInfo
+	level      int
+	method     Method
+	start      time.Time
+	done       chan struct{}
+
+	// Set by compress before done is closed.
+	data       []byte // contents
+	compressed []byte // contents compressed with method
+	sum        []byte // SHA-256 of data, if wanted
+	text       bool   // data is converted text
+	err        error
+}
+
+// concurrent reports whether the file described by info is compressed in
+// the background.
+func (a *archiver) concurrent(info os.FileInfo) bool {
+	return a.workers != nil && info.Mode().IsRegular() && info.Size() <= concurrentMaxSize
+}
+
+// submit starts compressing the file at path in the background, once
+// fewer than Concurrency files are, and writes the files before it that
+// are done.
+func (a *archiver) submit(path, name string, info os.FileInfo) error {
+	// Compressed files wait in memory for their turn; a slow one at the
+	// head of the queue holds back the others.
+	if len(a.jobs) >= 2*cap(a.workers) {
+		if err := a.writeJob(); err != nil {
+			return err
+		}
+	}
+	level, method := a.entryMethod(path)
+	j := &compressJob{path: path, name: name, info: info, level: level, method: method, done: make(chan struct{})}
+	a.hooks.started(EntryEvent{Name: name, Path: path, Size: uint64(info.Size())}) //nolint:gosec // File sizes are never negative.
+	j.start = time.Now()
+	a.workers <- struct{}{}
+	a.jobs = append(a.jobs, j)
+	go func() {
+		defer func() { <-a.workers }()
+		defer close(j.done)
+		j.err = a.compress(j)
+	}()
+	return a.flushJobs(false)
+}
+
+// compress reads and compresses the file of j.
+func (a *archiver) compress(j *compressJob) error {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", j.path, err)
+	}
+	defer f.Close()
+	data, text, err := a.textReader(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", j.path, err)
+	}
+	sample := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(sample)
+	data, method, err := a.chooseMethod(data, j.method, *sample)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", j.path, err)
+	}
+	j.text = text
+	data, h := a.hashing(a.progress.reader(data, j.name))
+	b := bytes.NewBuffer(make([]byte, 0, j.info.Size()+bytes.MinRead))
+	if _, err := b.ReadFrom(data); err != nil {
+		return fmt.Errorf("read %s: %w", j.path, err)
+	}
+	j.data, j.method = b.Bytes(), method
+	if h != nil {
+		j.sum = h.Sum(nil)
+	}
+	var header zip.FileHeader
+	method.setHeader(&header)
+	var out bytes.Buffer
+	w, err := compressors(j.level)[header.Method](&out)
+	if err != nil {
+		return fmt.Errorf("compress %s: %w", j.path, err)
+	}
+	if _, err := w.Write(j.data); err != nil {
+		return fmt.Errorf("compress %s: %w", j.path, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("compress %s: %w", j.path, err)
+	}
+	j.compressed = out.Bytes()
+	return nil
+}
+
+// flushJobs writes the files compressed in the background from the head
+// of the queue: all of them if wait is set, or else those already done.
+func (a *archiver) flushJobs(wait bool) error {
+	for len(a.jobs) > 0 {
+		if !wait {
+			select {
+			case <-a.jobs[0].done:
+			default:
+				return nil
+			}
+		}
+		if err := a.writeJob(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJob waits for the file at the head of the queue and writes it
+// like writeFile does, with its compressed data replayed.
+func (a *archiver) writeJob() (err error) {
+	j := a.jobs[0]
+	<-j.done
+	a.jobs = a.jobs[1:]
+	ev := EntryEvent{Name: j.name, Path: j.path, Size: uint64(j.info.Size())} //nolint:gosec // File sizes are never negative.
+	defer func() { a.hooks.finished(ev, j.start, err) }()
+	if j.err != nil {
+		return j.err
+	}
+	header, err := a.fileHeader(j.path, j.name, j.info)
+	if err != nil {
+		return err
+	}
+	j.method.setHeader(header)
+	if a.opts.Password != "" {
+		header.Flags |= flagEncrypted
+	}
+	var comp *entryCompressor
+	registerCompressors(a.w, map[uint16]zip.Compressor{header.Method: replay(j.compressed)}, a.tracking(&comp), a.sealer(header))
+	fw, err := a.w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("create header %s: %w", j.path, err)
+	}
+	a.markText(header.Name, j.text)
+	// archive/zip takes the checksum and size from the contents, and the
+	// replaying compressor writes their compressed form.
+	if _, err := fw.Write(j.data); err != nil {
+		return fmt.Errorf("write %s: %w", j.path, err)
+	}
+	return a.fileAdded(j.path, j.name, j.info, header, j.method, int64(len(j.data)), j.sum, comp, j.start)
+}
+
+// waitJobs waits for the files still compressed in the background, once
+// adding files failed.
+func (a *archiver) waitJobs() {
+	for _, j := range a.jobs {
+		<-j.done
+	}
+	a.jobs = nil
+}
+
+// replay returns a compressor that discards what is written to it and
+// writes compressed instead when closed.
+func replay(compressed []byte) zip.Compressor {
+	return func(out io.Writer) (io.WriteCloser, error) {
+		return &replayWriter{out: out, compressed: compressed}, nil
+	}
+}
+
+type replayWriter struct {
+	out        io.Writer
+	compressed []byte
+}
+
+func (r *replayWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (r *replayWriter) Close() error {
+	_, err := r.out.Write(r.compressed)
+	r.compressed = nil
+	return err //nolint:wrapcheck // Pass-through writer.
+}
@@ -0,0 +1,72 @@
+package ziplib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestZipConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 40 {
+		writeFile(t, filepath.Join(dir, fmt.Sprintf("f%02d.txt", i)), strings.Repeat(fmt.Sprintf("line %d\n", i), i*100))
+	}
+	// Too large to compress in the background, so it is written in turn.
+	writeFile(t, filepath.Join(dir, "large.txt"), strings.Repeat("large\n", concurrentMaxSize/5))
+	t.Chdir(dir)
+
+	for _, opts := range []ZipOptions{
+		{CompressionLevel: -1},
+		{CompressionLevel: -1, AutoStore: true},
+		{CompressionLevel: -1, LevelOverrides: []LevelOverride{{Pattern: "f1*", Level: 0}}},
+	} {
+		var archives [][]byte
+		var orders [][]string
+		for _, n := range []int{1, 8} {
+			o := opts
+			o.Recursive, o.Concurrency = true, n
+			var order []string
+			o.OnEntryDone = func(ev EntryEvent) { order = append(order, ev.Name) }
+			zipPath := filepath.Join(t.TempDir(), "out.zip")
+			if err := Zip(zipPath, []string{"."}, o); err != nil {
+				t.Fatalf("Zip with Concurrency %d: %v", n, err)
+			}
+			b, err := os.ReadFile(zipPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			archives, orders = append(archives, b), append(orders, order)
+		}
+		if string(archives[0]) != string(archives[1]) {
+			t.Errorf("%+v: archives differ with and without Concurrency", opts)
+		}
+		if !slices.Equal(orders[0], orders[1]) || len(orders[0]) != 41 {
+			t.Errorf("%+v: entries done in order %v, want %v", opts, orders[1], orders[0])
+		}
+	}
+}
+
+func TestZipConcurrencyError(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "b.txt"), "b")
+	t.Chdir(dir)
+	if err := os.Chmod("b.txt", 0); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := os.Open("b.txt"); err == nil {
+		f.Close()
+		t.Skip("unreadable files can be read, e.g. as root")
+	}
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	err := Zip(zipPath, []string{"a.txt", "b.txt"}, ZipOptions{Concurrency: 4})
+	if !os.IsPermission(err) && !strings.Contains(fmt.Sprint(err), "permission denied") {
+		t.Errorf("Zip = %v, want a permission error", err)
+	}
+	if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+		t.Errorf("archive left behind after failure: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConflictPolicy selects what Unzip does when the destination of an entry
+// already exists.
+type ConflictPolicy int
+
+const (
+	// ConflictError fails the entry, leaving the existing file alone. It
+	// is the default.
+	ConflictError ConflictPolicy = iota
+	// ConflictOverwrite replaces the existing file, like unzip -o.
+	ConflictOverwrite
+	// ConflictSkip keeps the existing file and skips the entry, like
+	// unzip -n.
+	ConflictSkip
+	// ConflictRename keeps both, extracting the entry under the first
+	// free name of the form file~1.txt, file~2.txt and so on.
+	ConflictRename
+)
+
+// ParseConflictPolicy converts a policy name ("error", "overwrite", "skip"
+// or "rename") to a ConflictPolicy.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch s {
+	case "error", "":
+		return ConflictError, nil
+	case "overwrite":
+		return ConflictOverwrite, nil
+	case "skip":
+		return ConflictSkip, nil
+	case "rename":
+		return ConflictRename, nil
+	default:
+		return 0, fmt.Errorf("invalid conflict policy %q: must be error, overwrite, skip or rename", s)
+	}
+}
+
+// ConflictDecision is the answer of UnzipOptions.ConflictPrompt about one
+// existing file.
+type ConflictDecision int
+
+const (
+	// DecisionSkip keeps the existing file and skips the entry.
+	DecisionSkip ConflictDecision = iota
+	// DecisionOverwrite replaces the existing file.
+	DecisionOverwrite
+	// DecisionOverwriteAll replaces this and every later existing file
+	// without asking again.
+	DecisionOverwriteAll
+	// DecisionSkipAll skips this and every later entry whose file exists
+	// without asking again.
+	DecisionSkipAll
+	// DecisionRename extracts the entry under a free name, as
+	// ConflictRename does.
+	DecisionRename
+)
+
+// entrySkipped is returned by extractEntry for entries deliberately left
+// out. It holds the reason.
+type entrySkipped string
+
+func (e entrySkipped) Error() string {
+	return string(e)
+}
+
+// errConflictSkipped is returned by extractEntry for entries left out
+// because their destination exists.
+const errConflictSkipped = entrySkipped("file exists")
+
+// conflictPolicy returns the effective policy, honoring Freshen, Update,
+// Overwrite and answers to ConflictPrompt that apply to all later files.
+func (x *extractor) conflictPolicy() ConflictPolicy {
+	switch {
+	case x.opts.Freshen || x.opts.Update:
+		// checkFreshen has already let through only newer entries.
+		return ConflictOverwrite
+	case x.opts.OnConflict != ConflictError:
+		return x.opts.OnConflict
+	case x.opts.Overwrite:
+		return ConflictOverwrite
+	default:
+		return x.decided
+	}
+}
+
+// ask consults ConflictPrompt about the existing file at p and returns
+// the policy to apply to it. The caller holds x.mu.
+func (x *extractor) ask(p string) ConflictPolicy {
+	switch x.opts.ConflictPrompt(p) {
+	case DecisionOverwrite:
+		return ConflictOverwrite
+	case DecisionOverwriteAll:
+		x.decided = ConflictOverwrite
+		return ConflictOverwrite
+	case DecisionSkipAll:
+		x.decided = ConflictSkip
+		return ConflictSkip
+	case DecisionRename:
+		return ConflictRename
+	default:
+		return ConflictSkip
+	}
+}
+
+// resolveConflict returns the path a file or link destined for destPath
+// is written to under the conflict policy, or errConflictSkipped.
+func (x *extractor) resolveConflict(f *zip.File, destPath string) (string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if !x.exists(f, destPath) {
+		x.claim(f, destPath)
+		return destPath, nil
+	}
+	policy := x.conflictPolicy()
+	if policy == ConflictError && x.opts.ConflictPrompt != nil {
+		policy = x.ask(destPath)
+	}
+	switch policy {
+	case ConflictOverwrite:
+		if _, claimed := x.claimed[destPath]; x.opts.Backup && !claimed {
+			if err := backup(destPath); err != nil {
+				return "", err
+			}
+		}
+		x.claim(f, destPath)
+		return destPath, nil
+	case ConflictSkip:
+		return "", errConflictSkipped
+	case ConflictRename:
+		p := freeName(destPath, func(c string) bool { return x.exists(f, c) })
+		x.claim(f, p)
+		return p, nil
+	default:
+		return "", fmt.Errorf("file exists: %s (use overwrite option)", destPath)
+	}
+}
+
+// exists reports whether p is taken for f: claimed by another entry of
+// this call, or present on disk without having been claimed by f itself,
+// as happens when f is retried. The caller holds x.mu.
+func (x *extractor) exists(f *zip.File, p string) bool {
+	if owner, ok := x.claimed[p]; ok {
+		return owner != f
+	}
+	_, err := os.Lstat(p)
+	return err == nil
+}
+
+// claim records that f is extracted to p, so that concurrent entries
+// renamed on conflict do not pick the same name. The caller holds x.mu.
+func (x *extractor) claim(f *zip.File, p string) {
+	if x.claimed == nil {
+		x.claimed = make(map[string]*zip.File)
+	}
+	x.claimed[p] = f
+}
+
+// backup renames the existing file at p to p~, or to p~N~ with the
+// lowest free N if p~ is taken.
+func backup(p string) error {
+	name := backupName(p)
+	if err := os.Rename(p, name); err != nil {
+		return fmt.Errorf("backup %s: %w", p, err)
+	}
+	return nil
+}
+
+// backupName returns the name backup would move p to.
+func backupName(p string) string {
+	name := p + "~"
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(name); err != nil {
+			return name
+		}
+		name = fmt.Sprintf("%s~%d~", p, n)
+	}
+}
+
+// freeName returns the first name of the form base~N.ext next to p that
+// is not taken.
+func freeName(p string, taken func(string) bool) string {
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	for n := 1; ; n++ {
+		if c := fmt.Sprintf("%s~%d%s", base, n, ext); !taken(c) {
+			return c
+		}
+	}
+}
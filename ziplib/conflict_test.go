@@ -0,0 +1,137 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestUnzipConflictPolicies(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "archived",
+		&zip.FileHeader{Name: "a.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "new.txt", Method: zip.Store},
+	)
+
+	tests := []struct {
+		policy  ConflictPolicy
+		want    map[string]string
+		skipped int
+	}{
+		{ConflictOverwrite, map[string]string{"a.txt": "archived", "new.txt": "archived"}, 0},
+		{ConflictSkip, map[string]string{"a.txt": "existing", "new.txt": "archived"}, 1},
+		{ConflictRename, map[string]string{"a.txt": "existing", "a~1.txt": "archived", "a~2.txt": "", "new.txt": "archived"}, 0},
+	}
+	for _, tt := range tests {
+		dest := t.TempDir()
+		writeFile(t, filepath.Join(dest, "a.txt"), "existing")
+		var res Result
+		if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, OnConflict: tt.policy, Result: &res}); err != nil {
+			t.Fatalf("policy %d: Unzip: %v", tt.policy, err)
+		}
+		for name, want := range tt.want {
+			if want == "" {
+				if _, err := os.Stat(filepath.Join(dest, name)); !os.IsNotExist(err) {
+					t.Errorf("policy %d: %s exists: %v", tt.policy, name, err)
+				}
+				continue
+			}
+			if got := readFile(t, filepath.Join(dest, name)); got != want {
+				t.Errorf("policy %d: %s = %q, want %q", tt.policy, name, got, want)
+			}
+		}
+		if res.Skipped != tt.skipped {
+			t.Errorf("policy %d: skipped = %d, want %d", tt.policy, res.Skipped, tt.skipped)
+		}
+	}
+}
+
+func TestUnzipRenameDuplicateEntries(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "dup.zip")
+	writeTestZip(t, zipPath, "same",
+		&zip.FileHeader{Name: "notes", Method: zip.Store},
+		&zip.FileHeader{Name: "notes", Method: zip.Store},
+	)
+	dest := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, OnConflict: ConflictRename}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for _, name := range []string{"notes", "notes~1"} {
+		if got := readFile(t, filepath.Join(dest, name)); got != "same" {
+			t.Errorf("%s = %q, want %q", name, got, "same")
+		}
+	}
+}
+
+func TestParseConflictPolicy(t *testing.T) {
+	for s, want := range map[string]ConflictPolicy{"": ConflictError, "overwrite": ConflictOverwrite, "skip": ConflictSkip, "rename": ConflictRename} {
+		if got, err := ParseConflictPolicy(s); err != nil || got != want {
+			t.Errorf("ParseConflictPolicy(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseConflictPolicy("ask"); err == nil {
+		t.Error("ParseConflictPolicy(\"ask\") succeeded")
+	}
+}
+
+func TestUnzipConflictPrompt(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "archived",
+		&zip.FileHeader{Name: "a", Method: zip.Store},
+		&zip.FileHeader{Name: "b", Method: zip.Store},
+		&zip.FileHeader{Name: "c", Method: zip.Store},
+		&zip.FileHeader{Name: "d", Method: zip.Store},
+	)
+	dest := t.TempDir()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		writeFile(t, filepath.Join(dest, name), "existing")
+	}
+
+	answers := []ConflictDecision{DecisionRename, DecisionOverwrite, DecisionSkipAll}
+	var asked []string
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir: dest,
+		ConflictPrompt: func(path string) ConflictDecision {
+			asked = append(asked, filepath.Base(path))
+			d := answers[0]
+			answers = answers[1:]
+			return d
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(asked, want) {
+		t.Errorf("asked about %q, want %q", asked, want)
+	}
+	for name, want := range map[string]string{"a": "existing", "a~1": "archived", "b": "archived", "c": "existing", "d": "existing"} {
+		if got := readFile(t, filepath.Join(dest, name)); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestUnzipBackup(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "archived",
+		&zip.FileHeader{Name: "a.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "a.txt", Method: zip.Store},
+	)
+	dest := t.TempDir()
+	writeFile(t, filepath.Join(dest, "a.txt"), "current")
+	writeFile(t, filepath.Join(dest, "a.txt~"), "older")
+
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, Overwrite: true, Backup: true}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for name, want := range map[string]string{"a.txt": "archived", "a.txt~": "older", "a.txt~1~": "current"} {
+		if got := readFile(t, filepath.Join(dest, name)); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "a.txt~2~")); !os.IsNotExist(err) {
+		t.Errorf("file written by the first entry was backed up: %v", err)
+	}
+}
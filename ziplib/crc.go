@@ -0,0 +1,39 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// CRCError reports an entry whose extracted data does not match the CRC-32
+// stored in the archive.
+type CRCError struct {
+	// Entry is the entry name as stored in the archive.
+	Entry string
+	// Want is the CRC-32 recorded in the archive.
+	Want uint32
+	// Got is the CRC-32 of the data actually read.
+	Got uint32
+}
+
+func (e *CRCError) Error() string {
+	return fmt.Sprintf("%s: bad CRC %08x (should be %08x)", e.Entry, e.Got, e.Want)
+}
+
+// copyVerified copies the contents of f from r to w while computing their
+// CRC-32, and returns a *CRCError if it does not match the archive. The
+// checksum error archive/zip reports at EOF is replaced by the typed one.
+func copyVerified(w io.Writer, r io.Reader, f *zip.File) error {
+	h := crc32.NewIEEE()
+	_, err := copyBuffer(io.MultiWriter(w, h), r)
+	if err != nil && !errors.Is(err, zip.ErrChecksum) {
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	if got := h.Sum32(); got != f.CRC32 || err != nil {
+		return &CRCError{Entry: f.Name, Want: f.CRC32, Got: got}
+	}
+	return nil
+}
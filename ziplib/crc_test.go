@@ -0,0 +1,98 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeBadCRCZip creates an archive with one stored entry whose recorded
+// CRC-32 does not match its content.
+func writeBadCRCZip(t *testing.T) (path string, want, got uint32) {
+	t.Helper()
+	content := []byte("corrupted payload")
+	got = crc32.ChecksumIEEE(content)
+	want = got ^ 0xdeadbeef
+
+	path = filepath.Join(t.TempDir(), "badcrc.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	fw, err := w.CreateRaw(&zip.FileHeader{
+		Name:               "bad.txt",
+		Method:             zip.Store,
+		CRC32:              want,
+		CompressedSize64:   uint64(len(content)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, want, got
+}
+
+func TestUnzipCRCMismatch(t *testing.T) {
+	zipPath, want, got := writeBadCRCZip(t)
+
+	tests := []struct {
+		name     string
+		keep     bool
+		wantFile bool
+	}{
+		{"corrupt file deleted", false, false},
+		{"corrupt file kept", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractDir := t.TempDir()
+			err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, KeepCorrupt: tt.keep})
+
+			var crcErr *CRCError
+			if !errors.As(err, &crcErr) {
+				t.Fatalf("expected *CRCError, got: %v", err)
+			}
+			if crcErr.Entry != "bad.txt" || crcErr.Want != want || crcErr.Got != got {
+				t.Errorf("CRCError = %+v, want entry bad.txt want %08x got %08x", crcErr, want, got)
+			}
+
+			_, statErr := os.Stat(filepath.Join(extractDir, "bad.txt"))
+			if exists := statErr == nil; exists != tt.wantFile {
+				t.Errorf("file exists = %v, want %v", exists, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestUnzipCorruptKeepsExistingFile(t *testing.T) {
+	zipPath, _, _ := writeBadCRCZip(t)
+	extractDir := t.TempDir()
+	dest := filepath.Join(extractDir, "bad.txt")
+	writeFile(t, dest, "previous version")
+
+	err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, Overwrite: true})
+	var crcErr *CRCError
+	if !errors.As(err, &crcErr) {
+		t.Fatalf("expected *CRCError, got: %v", err)
+	}
+	// The entry is written beside the destination and only renamed over it
+	// once verified, so the previous file survives a failed extraction.
+	if got := readFile(t, dest); got != "previous version" {
+		t.Errorf("bad.txt = %q, want the previous version", got)
+	}
+	if left, _ := filepath.Glob(filepath.Join(extractDir, tempPattern)); len(left) > 0 {
+		t.Errorf("temporary files left behind: %v", left)
+	}
+}
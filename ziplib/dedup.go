@@ -0,0 +1,184 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// dedupSet tracks the contents of the files written to an archive, so
+// that later files with the same contents reference the data already
+// stored instead of storing it again. A nil *dedupSet does nothing.
+type dedupSet struct {
+	bySize  map[int64][]dedupFile
+	refs    []dedupRef
+	entries int // entries created so far, in central directory order
+}
+
+// dedupFile is a file whose data is stored in the archive.
+type dedupFile struct {
+	path  string
+	sum   [sha256.Size]byte
+	index int // position of its entry in the central directory
+}
+
+// dedupRef is a duplicate file, stored as a central directory record
+// pointing at the data of the entry at index.
+type dedupRef struct {
+	header *zip.FileHeader
+	index  int
+}
+
+func newDedupSet(enabled bool) *dedupSet {
+	if !enabled {
+		return nil
+	}
+	return &dedupSet{bySize: make(map[int64][]dedupFile)}
+}
+
+// find returns the stored file with the same contents as r, of the given
+// size. Files are only hashed here if a stored file has the same size;
+// r is rewound afterwards.
+func (s *dedupSet) find(r io.ReadSeeker, size int64) (dedupFile, bool, error) {
+	if s == nil || size == 0 || len(s.bySize[size]) == 0 {
+		return dedupFile{}, false, nil
+	}
+	h := sha256.New()
+	if _, err := copyBuffer(h, r); err != nil {
+		return dedupFile{}, false, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return dedupFile{}, false, err //nolint:wrapcheck // Callers wrap with the file name.
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	for _, f := range s.bySize[size] {
+		if f.sum == sum {
+			return f, true, nil
+		}
+	}
+	return dedupFile{}, false, nil
+}
+
+// created counts an entry written without contents to deduplicate.
+func (s *dedupSet) created() {
+	if s != nil {
+		s.entries++
+	}
+}
+
+// written records the file at path, of size bytes with checksum sum, as
+// the entry just created.
+func (s *dedupSet) written(path string, size int64, sum []byte) {
+	if s == nil {
+		return
+	}
+	f := dedupFile{path: path, index: s.entries}
+	copy(f.sum[:], sum)
+	s.bySize[size] = append(s.bySize[size], f)
+	s.entries++
+}
+
+// reference records header as a duplicate of the entry at index.
+func (s *dedupSet) reference(header *zip.FileHeader, index int) {
+	s.refs = append(s.refs, dedupRef{header: header, index: index})
+}
+
+// extend appends a record for each duplicate to dir, the central
+// directory written for the other entries, and returns it with the
+// number of records it holds.
+func (s *dedupSet) extend(dir []byte) ([]byte, uint64, error) {
+	var records [][]byte
+	if err := centralRecords(dir, func(_ string, rec []byte) {
+		records = append(records, rec)
+	}); err != nil {
+		return nil, 0, err
+	}
+	count := uint64(len(records))
+	if s == nil {
+		return dir, count, nil
+	}
+	dir = slices.Clip(dir)
+	for _, ref := range s.refs {
+		if ref.index >= len(records) {
+			return nil, 0, fmt.Errorf("deduplicate %s: %w", ref.header.Name, zip.ErrFormat)
+		}
+		dir = append(dir, referenceRecord(records[ref.index], ref.header)...)
+		count++
+	}
+	return dir, count, nil
+}
+
+// referenceRecord returns a central directory record for header that
+// points at the data of the entry described by the record target. The
+// method, sizes, CRC-32 and offset come from target; the name, times,
+// attributes and extra fields are the duplicate's own.
+func referenceRecord(target []byte, header *zip.FileHeader) []byte {
+	le := binary.LittleEndian
+	rec := make([]byte, centralHeaderLen, centralHeaderLen+len(header.Name)+len(header.Extra)+28)
+	copy(rec, target)
+	le.PutUint16(rec[4:], header.CreatorVersion&0xff00|le.Uint16(target[4:])&0xff)
+	le.PutUint16(rec[8:], le.Uint16(target[8:])&^flagUTF8|utf8Flag(header))
+	le.PutUint16(rec[12:], header.ModifiedTime)
+	le.PutUint16(rec[14:], header.ModifiedDate)
+	le.PutUint32(rec[38:], header.ExternalAttrs)
+
+	// The Zip64 field of the target carries its large sizes and offset.
+	nameLen, extraLen := int(le.Uint16(target[28:])), int(le.Uint16(target[30:]))
+	var extra []byte
+	if z, ok := findExtra(target[centralHeaderLen+nameLen:centralHeaderLen+nameLen+extraLen], extraZip64); ok {
+		extra = appendExtra(extra, extraZip64, z)
+	}
+	extra = append(extra, header.Extra...)
+	le.PutUint16(rec[28:], uint16(len(header.Name))) //nolint:gosec // archive/zip rejects longer names.
+	le.PutUint16(rec[30:], uint16(len(extra)))       //nolint:gosec // Extra fields built by this package are small.
+	le.PutUint16(rec[32:], 0)                        // No comment.
+	rec = append(rec, header.Name...)
+	return append(rec, extra...)
+}
+
+// dirTail is an io.ReaderAt over the final bytes of an archive, starting
+// at offset start, as needed to find its central directory. Reads before
+// start see zeros.
+type dirTail struct {
+	b     []byte
+	start int64
+}
+
+func (t dirTail) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) && off+int64(n) < t.start {
+		p[n] = 0
+		n++
+	}
+	if n == len(p) {
+		return n, nil
+	}
+	i := off + int64(n) - t.start
+	if i >= int64(len(t.b)) {
+		return n, io.EOF
+	}
+	m := copy(p[n:], t.b[i:])
+	if n+m < len(p) {
+		return n + m, io.EOF
+	}
+	return n + m, nil
+}
+
+// holdWriter passes writes through to w until held is set, then collects
+// them in held instead.
+type holdWriter struct {
+	w    io.Writer
+	held *bytes.Buffer
+}
+
+func (h *holdWriter) Write(p []byte) (int, error) {
+	if h.held != nil {
+		return h.held.Write(p) //nolint:wrapcheck // Pass-through writer.
+	}
+	return h.w.Write(p) //nolint:wrapcheck // Pass-through writer.
+}
@@ -0,0 +1,121 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestZipDeduplicate(t *testing.T) {
+	src := t.TempDir()
+	data := strings.Repeat("the same contents, over and over. ", 200)
+	writeFile(t, filepath.Join(src, "a.txt"), data)
+	writeFile(t, filepath.Join(src, "b.txt"), data)
+	writeFile(t, filepath.Join(src, "c.txt"), data[1:]+"!") // Same size, different contents.
+	t.Chdir(src)
+
+	files := []string{"a.txt", "b.txt", "c.txt"}
+	dir := t.TempDir()
+	plain, deduped := filepath.Join(dir, "plain.zip"), filepath.Join(dir, "dedup.zip")
+	if err := Zip(plain, files, ZipOptions{CompressionLevel: 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Zip(deduped, files, ZipOptions{CompressionLevel: 0, Deduplicate: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	got, _ := zipContents(t, deduped)
+	if len(got) != 3 || got["a.txt"] != data || got["b.txt"] != data || got["c.txt"] != data[1:]+"!" {
+		t.Errorf("contents of %v entries differ from the files", len(got))
+	}
+	p, err := os.Stat(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := os.Stat(deduped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Size() > p.Size()-int64(len(data)) {
+		t.Errorf("deduplicated archive is %d bytes, want at most %d", d.Size(), p.Size()-int64(len(data)))
+	}
+
+	out := t.TempDir()
+	if err := Unzip(deduped, UnzipOptions{OutputDir: out}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "b.txt")); got != data {
+		t.Errorf("extracted b.txt differs")
+	}
+}
+
+func TestGrowDeduplicate(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	writeFile(t, filepath.Join(src, "b.txt"), "same")
+	writeFile(t, filepath.Join(src, "c.txt"), "same")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "grow.zip")
+	if err := Zip(zipPath, []string{"a.txt"}, ZipOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Grow(zipPath, []string{"b.txt", "c.txt"}, ZipOptions{Deduplicate: true}); err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+	got, _ := zipContents(t, zipPath)
+	if len(got) != 3 || got["a.txt"] != "a" || got["b.txt"] != "same" || got["c.txt"] != "same" {
+		t.Errorf("contents = %v", got)
+	}
+}
+
+// TestDeduplicatedArchiveReadsWithArchiveZip checks that archive/zip reads
+// the overlapping entries that Info-ZIP unzip -t rejects, and that Zip
+// warns about them.
+func TestDeduplicatedArchiveReadsWithArchiveZip(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "same contents")
+	writeFile(t, filepath.Join(src, "b.txt"), "same contents")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "dedup.zip")
+	var out bytes.Buffer
+	if err := Zip(zipPath, []string{"a.txt", "b.txt"}, ZipOptions{Deduplicate: true, Output: &out}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if !strings.Contains(out.String(), "overlapped components") {
+		t.Errorf("output = %q, want a warning about overlapped components", out.String())
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("archive/zip: %v", err)
+	}
+	defer r.Close()
+	if len(r.File) != 2 {
+		t.Fatalf("got %d entries, want 2", len(r.File))
+	}
+	offsets := make(map[int64]bool)
+	for _, f := range r.File {
+		off, err := f.DataOffset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		offsets[off] = true
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		b, err := io.ReadAll(rc) // Fails on a CRC-32 mismatch.
+		rc.Close()
+		if err != nil || string(b) != "same contents" {
+			t.Errorf("%s = %q, %v", f.Name, b, err)
+		}
+	}
+	if len(offsets) != 1 {
+		t.Errorf("entries have %d data offsets, want them to share one", len(offsets))
+	}
+}
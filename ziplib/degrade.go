@@ -0,0 +1,73 @@
+package ziplib
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+)
+
+// DegradationKind classifies an attribute that could not be restored exactly.
+type DegradationKind string
+
+// Kinds of degradation reported during extraction.
+const (
+	// DegradedSymlink means a symbolic link was stored as a file instead.
+	DegradedSymlink DegradationKind = "symlink"
+	// DegradedMode means Unix permission bits were approximated.
+	DegradedMode DegradationKind = "mode"
+	// DegradedOwnership means the recorded owner was not restored.
+	DegradedOwnership DegradationKind = "ownership"
+)
+
+// Degradation describes an entry that was extracted with reduced fidelity,
+// typically when extracting a Unix archive on Windows.
+type Degradation struct {
+	// Name is the entry name as stored in the archive.
+	Name string
+	// Kind is the attribute that was not fully restored.
+	Kind DegradationKind
+	// Detail explains what was done instead.
+	Detail string
+}
+
+// degrade records d and passes it to the OnDegraded callback, if any.
+func (x *extractor) degrade(d Degradation) {
+	x.mu.Lock()
+	x.degraded = append(x.degraded, d)
+	x.mu.Unlock()
+	if x.opts.OnDegraded != nil {
+		x.opts.OnDegraded(d)
+	}
+}
+
+// checkModeFidelity records a degradation when the platform cannot
+// represent the Unix mode of a file. Windows only keeps a read-only flag,
+// so execute and special bits are lost.
+func (x *extractor) checkModeFidelity(name string, mode os.FileMode) {
+	if runtime.GOOS != "windows" || mode.IsDir() {
+		return
+	}
+	if mode&0o111 != 0 || mode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky) != 0 {
+		x.degrade(Degradation{
+			Name:   name,
+			Kind:   DegradedMode,
+			Detail: fmt.Sprintf("mode %04o approximated by the read-only attribute", mode.Perm()),
+		})
+	}
+}
+
+// writeDegradationReport reports the degraded entries to l, as a summary
+// line followed by one line per entry when printed as text.
+func writeDegradationReport(l eventLog, degraded []Degradation) {
+	if len(degraded) == 0 {
+		return
+	}
+	if l.logger == nil && l.enabled(slog.LevelWarn) {
+		fmt.Fprintf(l.out, "warning: %d entries could not be restored exactly:\n", len(degraded))
+	}
+	for _, d := range degraded {
+		l.emit(slog.LevelWarn, "degraded", fmt.Sprintf("  %-9s %s (%s)\n", string(d.Kind)+":", d.Name, d.Detail),
+			entryAttr(d.Name), slog.String("kind", string(d.Kind)), slog.String("detail", d.Detail))
+	}
+}
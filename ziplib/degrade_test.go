@@ -0,0 +1,57 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnzipOwnershipDegradedWithoutRoot(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("ownership is restored when running as root")
+	}
+	zipPath := filepath.Join(t.TempDir(), "owned.zip")
+	h := &zip.FileHeader{Name: "owned.txt"}
+	h.Extra = appendExtra(nil, extraUnixOwner, encodeUnixOwner(1234, 5678))
+	writeTestZip(t, zipPath, "data", h)
+
+	var buf bytes.Buffer
+	var degraded []Degradation
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir:        t.TempDir(),
+		RestoreOwnership: true,
+		OnDegraded:       func(d Degradation) { degraded = append(degraded, d) },
+		Output:           &buf,
+	})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if len(degraded) != 1 || degraded[0].Kind != DegradedOwnership {
+		t.Errorf("degraded = %+v", degraded)
+	}
+	if !strings.Contains(buf.String(), "could not be restored exactly") {
+		t.Errorf("expected summary in output, got: %s", buf.String())
+	}
+}
+
+func TestWriteDegradationReport(t *testing.T) {
+	var buf bytes.Buffer
+	writeDegradationReport(eventLog{out: &buf}, nil)
+	if buf.Len() != 0 {
+		t.Errorf("expected no output for no degradations, got: %q", buf.String())
+	}
+
+	writeDegradationReport(eventLog{out: &buf}, []Degradation{
+		{Name: "bin/tool", Kind: DegradedMode, Detail: "mode 0755 approximated"},
+		{Name: "lib/link", Kind: DegradedSymlink, Detail: "stored as file"},
+	})
+	out := buf.String()
+	for _, want := range []string{"2 entries", "mode:", "bin/tool", "symlink:", "lib/link"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// pendingFile is a file queued to be written once all inputs are known.
+type pendingFile struct {
+	path string
+	name string
+	info os.FileInfo
+}
+
+// addFile writes the file at path, or queues it if entries are sorted so
+// that they can be sorted by name before writing. Files dropped by
+// NameTransform are counted as skipped.
+func (a *archiver) addFile(path string, info os.FileInfo) error {
+	if a.isArchive(info) {
+		a.skipped(path, false, "archive itself")
+		return nil
+	}
+	if !a.inDateRange(info) {
+		a.planExcluded(path, false)
+		a.skipped(path, false, "outside date range")
+		return nil
+	}
+	if !a.inSizeRange(info) {
+		a.planExcluded(path, false)
+		a.skipped(path, false, "outside size range")
+		return nil
+	}
+	if isSpecial(info.Mode()) && !a.opts.StoreSpecialFiles {
+		a.log.emit(slog.LevelWarn, "skipping", fmt.Sprintf("  zip warning: ignoring special file: %s\n", path),
+			slog.String("path", path), slog.String("reason", "special file"))
+		a.skipped(path, false, "special file")
+		return nil
+	}
+	name, ok := a.entryName(path)
+	if !ok {
+		a.planExcluded(path, false)
+		a.skipped(path, false, "dropped by name transform")
+		return nil
+	}
+	if a.unchanged(name, info) {
+		a.planExcluded(path, false)
+		a.skipped(path, false, "unchanged")
+		return nil
+	}
+	if err := a.checkJunked(path, name); err != nil {
+		return err
+	}
+	if !a.opts.SortEntries && !a.opts.Deterministic {
+		return a.writeFile(path, name, info)
+	}
+	a.pending = append(a.pending, pendingFile{path: path, name: name, info: info})
+	return nil
+}
+
+// writePending writes the queued files sorted by entry name.
+func (a *archiver) writePending() error {
+	slices.SortFunc(a.pending, func(x, y pendingFile) int {
+		return strings.Compare(x.name, y.name)
+	})
+	for _, p := range a.pending {
+		if err := a.writeFile(p.path, p.name, p.info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeHeader removes the attributes of h that vary between otherwise
+// identical inputs: the modification time is set to mtime and the mode is
+// reduced to 0644, or 0755 for executables. Callers omit the timestamp and
+// owner extra fields.
+func normalizeHeader(h *zip.FileHeader, mtime time.Time) {
+	if mtime.IsZero() {
+		mtime = dosEpoch
+	}
+	mode := os.FileMode(0o644)
+	if h.Mode()&0o111 != 0 {
+		mode = 0o755
+	}
+	h.SetMode(mode)
+	h.Modified = time.Time{}
+	h.ModifiedDate, h.ModifiedTime = timeToDOS(mtime.UTC()) //nolint:staticcheck // Set directly to keep archive/zip from adding an extra field.
+}
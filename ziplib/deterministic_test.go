@@ -0,0 +1,132 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// buildTree creates tree/ under dir with the given file order, mtime and
+// mode, and returns the bytes of a deterministic archive of it.
+func buildTree(t *testing.T, dir string, names []string, mtime time.Time, mode os.FileMode) []byte {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, "tree", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, path, "content of "+name)
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	if err := Zip(zipPath, []string{"tree"}, ZipOptions{Recursive: true, Deterministic: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestZipDeterministic(t *testing.T) {
+	a := buildTree(t, t.TempDir(), []string{"b.txt", "sub/c.txt", "a.txt"},
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), 0o600)
+	b := buildTree(t, t.TempDir(), []string{"a.txt", "b.txt", "sub/c.txt"},
+		time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC), 0o664)
+	if !bytes.Equal(a, b) {
+		t.Fatal("archives of identical trees differ")
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(a), int64(len(a)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tree/a.txt", "tree/b.txt", "tree/sub/c.txt"}
+	if len(r.File) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(r.File), len(want))
+	}
+	for i, f := range r.File {
+		if f.Name != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, f.Name, want[i])
+		}
+		if f.Mode().Perm() != 0o644 {
+			t.Errorf("%s: mode %v, want 0644", f.Name, f.Mode().Perm())
+		}
+		if len(f.Extra) != 0 {
+			t.Errorf("%s: unexpected extra fields %x", f.Name, f.Extra)
+		}
+		if !f.Modified.Equal(dosEpoch) {
+			t.Errorf("%s: modified %v, want %v", f.Name, f.Modified, dosEpoch)
+		}
+	}
+}
+
+func TestZipDeterministicTime(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "run.sh"), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(src, "run.sh"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	err := Zip(zipPath, []string{filepath.Join(src, "run.sh")}, ZipOptions{
+		Deterministic:     true,
+		DeterministicTime: mtime,
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	f := r.File[0]
+	if f.Mode().Perm() != 0o755 {
+		t.Errorf("mode %v, want 0755", f.Mode().Perm())
+	}
+	if got := f.Modified; !got.Equal(mtime) {
+		t.Errorf("modified %v, want %v", got, mtime)
+	}
+}
+
+func TestZipSortEntries(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir("a", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"z.txt", "a/b.txt", "a.b"} {
+		writeFile(t, name, name)
+	}
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	if err := Zip(zipPath, []string{"z.txt", "a", "a.b"}, ZipOptions{Recursive: true, SortEntries: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"a.b", "a/b.txt", "z.txt"}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Name != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, e.Name, want[i])
+		}
+		if e.Modified.Year() < 2000 {
+			t.Errorf("%s: modification time %v was normalized", e.Name, e.Modified)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ziplib
+
+import (
+	"slices"
+	"strings"
+)
+
+// DiffReport lists the differences between two archives. Entries are
+// matched by name and each list is sorted by name.
+type DiffReport struct {
+	// Added lists entries present only in the second archive.
+	Added []ListEntry
+	// Removed lists entries present only in the first archive.
+	Removed []ListEntry
+	// Changed lists entries present in both archives whose contents or
+	// metadata differ.
+	Changed []EntryDiff
+}
+
+// Empty reports whether the archives have the same entries.
+func (r DiffReport) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// EntryDiff describes an entry that differs between two archives.
+type EntryDiff struct {
+	// Name is the entry name.
+	Name string
+	// Fields names what differs: "size", "crc" and/or "mtime".
+	Fields []string
+	// Old and New are the entry in the first and second archive.
+	Old, New ListEntry
+}
+
+// Diff compares the archives at a and b by entry name, size, CRC-32 and
+// modification time. Compressed sizes and archive order are ignored.
+func Diff(a, b string) (DiffReport, error) {
+	old, err := readEntries(a)
+	if err != nil {
+		return DiffReport{}, err
+	}
+	cur, err := readEntries(b)
+	if err != nil {
+		return DiffReport{}, err
+	}
+
+	var report DiffReport
+	for name, o := range old {
+		n, ok := cur[name]
+		if !ok {
+			report.Removed = append(report.Removed, o)
+			continue
+		}
+		if fields := diffFields(o, n); len(fields) > 0 {
+			report.Changed = append(report.Changed, EntryDiff{Name: name, Fields: fields, Old: o, New: n})
+		}
+	}
+	for name, n := range cur {
+		if _, ok := old[name]; !ok {
+			report.Added = append(report.Added, n)
+		}
+	}
+
+	byName := func(x, y ListEntry) int { return strings.Compare(x.Name, y.Name) }
+	slices.SortFunc(report.Added, byName)
+	slices.SortFunc(report.Removed, byName)
+	slices.SortFunc(report.Changed, func(x, y EntryDiff) int { return strings.Compare(x.Name, y.Name) })
+	return report, nil
+}
+
+// readEntries returns the entries of the archive at path keyed by name.
+func readEntries(path string) (map[string]ListEntry, error) {
+	r, err := openArchive(path, "")
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	entries := make(map[string]ListEntry, len(r.File))
+	for _, f := range r.File {
+		entries[f.Name] = listEntry(&f.FileHeader)
+	}
+	return entries, nil
+}
+
+// diffFields names the attributes that differ between o and n.
+func diffFields(o, n ListEntry) []string {
+	var fields []string
+	if o.UncompressedSize != n.UncompressedSize {
+		fields = append(fields, "size")
+	}
+	if o.CRC32 != n.CRC32 {
+		fields = append(fields, "crc")
+	}
+	if !o.Modified.Equal(n.Modified) {
+		fields = append(fields, "mtime")
+	}
+	return fields
+}
@@ -0,0 +1,65 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	oldPath := filepath.Join(dir, "old.zip")
+	newPath := filepath.Join(dir, "new.zip")
+	writeTestZip(t, oldPath, "same",
+		&zip.FileHeader{Name: "same.txt", Modified: mtime},
+		&zip.FileHeader{Name: "touched.txt", Modified: mtime},
+		&zip.FileHeader{Name: "gone.txt", Modified: mtime},
+	)
+	writeTestZip(t, newPath, "same",
+		&zip.FileHeader{Name: "new.txt", Modified: mtime},
+		&zip.FileHeader{Name: "touched.txt", Modified: mtime.Add(time.Hour)},
+		&zip.FileHeader{Name: "same.txt", Modified: mtime},
+	)
+
+	report, err := Diff(oldPath, newPath)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(report.Added) != 1 || report.Added[0].Name != "new.txt" {
+		t.Errorf("Added = %+v, want [new.txt]", report.Added)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "gone.txt" {
+		t.Errorf("Removed = %+v, want [gone.txt]", report.Removed)
+	}
+	if len(report.Changed) != 1 || report.Changed[0].Name != "touched.txt" ||
+		!slices.Equal(report.Changed[0].Fields, []string{"mtime"}) {
+		t.Errorf("Changed = %+v, want touched.txt mtime", report.Changed)
+	}
+
+	self, err := Diff(oldPath, oldPath)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if !self.Empty() {
+		t.Errorf("Diff of archive with itself = %+v, want empty", self)
+	}
+}
+
+func TestDiffContent(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.zip")
+	b := filepath.Join(dir, "b.zip")
+	writeTestZip(t, a, "one", &zip.FileHeader{Name: "f.txt"})
+	writeTestZip(t, b, "three", &zip.FileHeader{Name: "f.txt"})
+
+	report, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(report.Changed) != 1 || !slices.Equal(report.Changed[0].Fields, []string{"size", "crc"}) {
+		t.Errorf("Changed = %+v, want f.txt size and crc", report.Changed)
+	}
+}
@@ -0,0 +1,63 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// pendingDir is an extracted directory whose mode and times are restored
+// once all entries are extracted: creating its children would change its
+// times again, and a read-only mode would prevent creating them. f is nil
+// for directories created implicitly as parents of other entries.
+type pendingDir struct {
+	path string
+	f    *zip.File
+}
+
+// makeDirs creates path and any missing parents. They are created
+// writable by the owner so that entries can be extracted into them; if
+// that differs from the directory mode, the mode is applied by restoreDirs.
+func (x *extractor) makeDirs(path string) error {
+	mode := x.modes.parentMode()
+	if mode&0o700 == 0o700 {
+		return makeDirs(path, mode, nil)
+	}
+	return makeDirs(path, mode|0o700, func(p string) { x.deferDir(p, nil) })
+}
+
+// deferDir queues the directory at path, extracted from the directory
+// entry f or created implicitly if f is nil.
+func (x *extractor) deferDir(path string, f *zip.File) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.pendingDirs = append(x.pendingDirs, pendingDir{path: path, f: f})
+}
+
+// restoreDirs applies the ownership, mode and times of the queued
+// directories, deepest first, so that no directory is modified or made
+// read-only before its children are done.
+func (x *extractor) restoreDirs() error {
+	slices.SortStableFunc(x.pendingDirs, func(a, b pendingDir) int {
+		return cmp.Compare(pathDepth(filepath.ToSlash(b.path)), pathDepth(filepath.ToSlash(a.path)))
+	})
+	for _, d := range x.pendingDirs {
+		if d.f == nil {
+			if err := restoreMode(d.path, x.modes.parentMode()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := x.restoreAttrs(d.path, d.f); err != nil {
+			return err
+		}
+		atime, mtime := x.entryTimes(d.f)
+		if err := os.Chtimes(d.path, atime, mtime); err != nil {
+			return fmt.Errorf("chtimes %s: %w", d.path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestUnzipRestoresDirectoryTimes(t *testing.T) {
+	outer := time.Date(2021, 3, 4, 5, 6, 8, 0, time.UTC)
+	inner := time.Date(2022, 7, 8, 9, 10, 12, 0, time.UTC)
+	zipPath := filepath.Join(t.TempDir(), "tree.zip")
+	writeTestZip(t, zipPath, "data",
+		&zip.FileHeader{Name: "a/", Method: zip.Store, Modified: outer},
+		&zip.FileHeader{Name: "a/b/", Method: zip.Store, Modified: inner},
+		&zip.FileHeader{Name: "a/b/file.txt", Method: zip.Store, Modified: inner},
+		&zip.FileHeader{Name: "a/late.txt", Method: zip.Store, Modified: inner},
+	)
+	dest := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for name, want := range map[string]time.Time{"a": outer, "a/b": inner} {
+		fi, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.ModTime().Equal(want) {
+			t.Errorf("%s modified %v, want %v", name, fi.ModTime().UTC(), want)
+		}
+	}
+}
+
+func TestUnzipRestoresDirectoryModesLast(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on windows")
+	}
+	ro := &zip.FileHeader{Name: "ro/", Method: zip.Store}
+	ro.SetMode(os.ModeDir | 0o555)
+	zipPath := filepath.Join(t.TempDir(), "modes.zip")
+	writeTestZip(t, zipPath, "data",
+		ro,
+		&zip.FileHeader{Name: "ro/file.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "implicit/sub/file.txt", Method: zip.Store},
+	)
+	dest := t.TempDir()
+	t.Cleanup(func() {
+		for _, p := range []string{"ro", "implicit", "implicit/sub"} {
+			_ = os.Chmod(filepath.Join(dest, p), 0o755)
+		}
+	})
+
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, DirMode: 0o500}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for name, want := range map[string]os.FileMode{"ro": 0o555, "implicit": 0o500, "implicit/sub": 0o500} {
+		fi, err := os.Stat(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != want {
+			t.Errorf("%s mode = %v, want %v", name, fi.Mode().Perm(), want)
+		}
+	}
+	if got := readFile(t, filepath.Join(dest, "ro", "file.txt")); got != "data" {
+		t.Errorf("ro/file.txt = %q, want %q", got, "data")
+	}
+}
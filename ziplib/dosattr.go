@@ -0,0 +1,30 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+)
+
+// MS-DOS attributes kept in the low byte of the external attributes.
+const (
+	dosReadOnly = 0x01
+	dosHidden   = 0x02
+	dosSystem   = 0x04
+
+	dosAttrMask = dosReadOnly | dosHidden | dosSystem
+)
+
+// restoreDOSAttrs sets the hidden and system attributes recorded for f on
+// path. The read-only attribute follows from the restored mode, so it is
+// only set here along with the others. Platforms without DOS attributes
+// ignore them.
+func restoreDOSAttrs(path string, f *zip.File) error {
+	attrs := f.ExternalAttrs & dosAttrMask
+	if attrs&(dosHidden|dosSystem) == 0 {
+		return nil
+	}
+	if err := setDOSAttrs(path, attrs); err != nil {
+		return fmt.Errorf("set attributes %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package ziplib
+
+// fileDOSAttrs reports no DOS attributes on platforms that lack them.
+func fileDOSAttrs(string) uint32 {
+	return 0
+}
+
+// setDOSAttrs ignores DOS attributes on platforms that lack them.
+func setDOSAttrs(string, uint32) error {
+	return nil
+}
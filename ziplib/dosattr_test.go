@@ -0,0 +1,30 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUnzipRestoresDOSAttrs(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "dos.zip")
+	hidden := &zip.FileHeader{Name: "hidden.txt", Method: zip.Deflate, ExternalAttrs: dosHidden | dosSystem}
+	plain := &zip.FileHeader{Name: "plain.txt", Method: zip.Deflate}
+	writeTestZip(t, zipPath, "x", hidden, plain)
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	want := uint32(0)
+	if runtime.GOOS == "windows" {
+		want = dosHidden | dosSystem
+	}
+	if got := fileDOSAttrs(filepath.Join(dest, "hidden.txt")); got != want {
+		t.Errorf("hidden.txt attributes = %#x, want %#x", got, want)
+	}
+	if got := fileDOSAttrs(filepath.Join(dest, "plain.txt")); got != 0 {
+		t.Errorf("plain.txt attributes = %#x, want 0", got)
+	}
+}
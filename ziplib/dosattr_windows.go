@@ -0,0 +1,32 @@
+package ziplib
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// fileDOSAttrs returns the read-only, hidden and system attributes of the
+// file at path.
+func fileDOSAttrs(path string) uint32 {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0
+	}
+	attrs, err := windows.GetFileAttributes(p)
+	if err != nil {
+		return 0
+	}
+	return attrs & dosAttrMask
+}
+
+// setDOSAttrs adds attrs to the attributes of the file at path.
+func setDOSAttrs(path string, attrs uint32) error {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+	cur, err := windows.GetFileAttributes(p)
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by the caller.
+	}
+	return windows.SetFileAttributes(p, cur|attrs) //nolint:wrapcheck // Wrapped by the caller.
+}
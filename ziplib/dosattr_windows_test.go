@@ -0,0 +1,35 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestZipStoresDOSAttrs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hidden.txt")
+	writeFile(t, path, "x")
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := windows.SetFileAttributes(p, windows.FILE_ATTRIBUTE_HIDDEN|windows.FILE_ATTRIBUTE_SYSTEM); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(dir, "out.zip")
+	if err := Zip(zipPath, []string{path}, ZipOptions{JunkPaths: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := r.File[0].ExternalAttrs & dosAttrMask; got != dosHidden|dosSystem {
+		t.Errorf("attributes = %#x, want %#x", got, dosHidden|dosSystem)
+	}
+}
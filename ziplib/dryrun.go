@@ -0,0 +1,128 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// dryRun walks files as Zip would and prints what would be added,
+// followed by a total.
+func (a *archiver) dryRun(files []string) error {
+	if err := a.addAll(files); err != nil {
+		return err
+	}
+	a.log.emit(slog.LevelInfo, "would add total", fmt.Sprintf("would add %d files, %d bytes uncompressed\n", a.result.Entries, a.result.UncompressedBytes),
+		slog.Int("entries", a.result.Entries), sizeAttr(a.result.UncompressedBytes))
+	return nil
+}
+
+// planFile reports the entry name that writeFile would add for path in a
+// dry run, and counts it in the result as if it had been written.
+func (a *archiver) planFile(path, name string, info os.FileInfo) error {
+	size := uint64(info.Size()) //nolint:gosec // File sizes are never negative.
+	method := a.opts.Method
+	if levelFor(a.levels, a.relPath(path), a.opts.CompressionLevel) == 0 {
+		method = MethodStore
+	}
+	if a.opts.AutoStore && method != MethodStore && !isSpecial(info.Mode()) {
+		ok, err := autoStoreSample(path)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			method = MethodStore
+		}
+	}
+	a.result.Entries++
+	a.result.UncompressedBytes += size
+	a.log.emit(slog.LevelInfo, "would add", fmt.Sprintf("  would add: %s (%d bytes, %s)\n", name, size, method),
+		entryAttr(name), sizeAttr(size), slog.String("method", method.String()))
+	return nil
+}
+
+// planExcluded reports a path left out by the exclude patterns in a dry run.
+func (a *archiver) planExcluded(path string, isDir bool) {
+	if !a.opts.DryRun {
+		return
+	}
+	name := fileEntryName(path, isDir)
+	a.log.emit(slog.LevelInfo, "excluding", fmt.Sprintf("  excluding: %s\n", name), slog.String("path", name))
+}
+
+// plan prints what extracting files would do without touching the
+// filesystem. Entries whose destination already exists, or is claimed by an
+// earlier entry, are handled as the conflict policy says; under
+// ConflictError they are reported as collisions and counted as skipped.
+func (x *extractor) plan(files []*zip.File) {
+	seen := make(map[string]bool)
+	for _, f := range files {
+		destPath, err := x.destination(f)
+		if err != nil {
+			x.log.emit(slog.LevelWarn, "skipping", fmt.Sprintf("   skipping: %s (%v)\n", f.Name, err),
+				entryAttr(f.Name), slog.String("reason", err.Error()))
+			x.skipped(f, "", err.Error())
+			continue
+		}
+		if err := x.checkFreshen(f, destPath); err != nil {
+			x.log.emit(slog.LevelInfo, "would skip", fmt.Sprintf("   would skip: %s (%v)\n", destPath, err),
+				entryAttr(f.Name), slog.String("path", destPath), slog.String("reason", err.Error()))
+			x.skipped(f, destPath, err.Error())
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			x.log.emit(slog.LevelInfo, "would create", fmt.Sprintf("   would create: %s/\n", destPath),
+				entryAttr(f.Name), slog.String("path", destPath))
+			x.record(f)
+			continue
+		}
+		x.planFile(f, destPath, seen)
+	}
+}
+
+// planFile reports what extracting the file entry f to destPath would
+// do, given the paths seen so far, which it adds destPath to unless the
+// entry would be skipped.
+func (x *extractor) planFile(f *zip.File, destPath string, seen map[string]bool) {
+	taken := func(p string) bool {
+		_, err := os.Lstat(p)
+		return err == nil || seen[p]
+	}
+	exists := taken(destPath)
+	policy := x.conflictPolicy()
+	switch {
+	case exists && policy == ConflictSkip:
+		x.log.emit(slog.LevelInfo, "would skip", fmt.Sprintf("   would skip: %s (file exists)\n", destPath),
+			entryAttr(f.Name), slog.String("path", destPath), slog.String("reason", "file exists"))
+		x.skipped(f, destPath, "file exists")
+		return
+	case exists && policy == ConflictRename:
+		renamed := freeName(destPath, taken)
+		x.log.emit(slog.LevelInfo, "would rename", fmt.Sprintf("   would rename: %s -> %s (%d bytes)\n", destPath, renamed, f.UncompressedSize64),
+			entryAttr(f.Name), slog.String("path", renamed), sizeAttr(f.UncompressedSize64))
+		destPath = renamed
+	case seen[destPath] && policy == ConflictError:
+		x.log.emit(slog.LevelWarn, "would collide", fmt.Sprintf("   would collide: %s (duplicate of an earlier entry)\n", destPath),
+			entryAttr(f.Name), slog.String("path", destPath), slog.String("reason", "duplicate of an earlier entry"))
+		x.skipped(f, destPath, "duplicate of an earlier entry")
+		return
+	case exists && policy == ConflictError:
+		x.log.emit(slog.LevelWarn, "would collide", fmt.Sprintf("   would collide: %s (file exists)\n", destPath),
+			entryAttr(f.Name), slog.String("path", destPath), slog.String("reason", "file exists"))
+		x.skipped(f, destPath, "file exists")
+		return
+	case exists && x.opts.Backup && !seen[destPath]:
+		b := backupName(destPath)
+		x.log.emit(slog.LevelInfo, "would replace", fmt.Sprintf("   would replace: %s (%d bytes, backup %s)\n", destPath, f.UncompressedSize64, b),
+			entryAttr(f.Name), slog.String("path", destPath), sizeAttr(f.UncompressedSize64), slog.String("backup", b))
+	case exists:
+		x.log.emit(slog.LevelInfo, "would replace", fmt.Sprintf("   would replace: %s (%d bytes)\n", destPath, f.UncompressedSize64),
+			entryAttr(f.Name), slog.String("path", destPath), sizeAttr(f.UncompressedSize64))
+	default:
+		x.log.emit(slog.LevelInfo, "would extract", fmt.Sprintf("   would extract: %s (%d bytes)\n", destPath, f.UncompressedSize64),
+			entryAttr(f.Name), slog.String("path", destPath), sizeAttr(f.UncompressedSize64))
+	}
+	seen[destPath] = true
+	x.record(f)
+}
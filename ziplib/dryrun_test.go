@@ -0,0 +1,92 @@
+package ziplib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestZipDryRun(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "keep.txt"), "hello")
+	writeFile(t, filepath.Join(src, "drop.log"), "ignored")
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+
+	var out bytes.Buffer
+	var res Result
+	err := Zip(zipPath, []string{src}, ZipOptions{
+		Recursive:        true,
+		CompressionLevel: -1,
+		ExcludePatterns:  []string{"*.log"},
+		DryRun:           true,
+		Result:           &res,
+		Output:           &out,
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if _, err := os.Stat(zipPath); !os.IsNotExist(err) {
+		t.Errorf("dry run created the archive: %v", err)
+	}
+	if res.Entries != 1 || res.Skipped != 1 || res.UncompressedBytes != 5 {
+		t.Errorf("result = %+v, want 1 entry of 5 bytes and 1 skipped", res)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"would add: " + filepath.ToSlash(filepath.Join(src, "keep.txt")) + " (5 bytes, deflate)",
+		"excluding: " + filepath.ToSlash(filepath.Join(src, "drop.log")),
+		"would add 1 files, 5 bytes uncompressed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestUnzipDryRun(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "new.txt"), "new")
+	writeFile(t, filepath.Join(src, "old.txt"), "old!")
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	if err := Zip(zipPath, []string{filepath.Join(src, "new.txt"), filepath.Join(src, "old.txt")}, ZipOptions{}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	dest := t.TempDir()
+	writeFile(t, filepath.Join(dest, "old.txt"), "existing")
+	var out bytes.Buffer
+	var res Result
+	err := Unzip(zipPath, UnzipOptions{OutputDir: dest, JunkPaths: true, DryRun: true, Result: &res, Output: &out})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("dry run extracted new.txt: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dest, "old.txt")); string(b) != "existing" {
+		t.Errorf("dry run modified old.txt: %q", b)
+	}
+	if res.Entries != 1 || res.Skipped != 1 {
+		t.Errorf("result = %+v, want 1 entry and 1 skipped", res)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"would extract: " + filepath.Join(dest, "new.txt") + " (3 bytes)",
+		"would collide: " + filepath.Join(dest, "old.txt") + " (file exists)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+
+	out.Reset()
+	err = Unzip(zipPath, UnzipOptions{OutputDir: dest, JunkPaths: true, Overwrite: true, DryRun: true, Output: &out})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if want := "would replace: " + filepath.Join(dest, "old.txt"); !strings.Contains(out.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, out.String())
+	}
+}
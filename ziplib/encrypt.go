@@ -0,0 +1,108 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"hash/crc32"
+	"io"
+)
+
+// encryptHeaderLen is the size of the header that precedes the data of
+// an entry encrypted with the traditional PKWARE scheme.
+const encryptHeaderLen = 12
+
+// zipCrypto holds the three keys of the traditional PKWARE encryption,
+// the scheme of zip -e that every unzip can decrypt.
+type zipCrypto [3]uint32
+
+// newZipCrypto returns the keys initialized from password.
+func newZipCrypto(password string) *zipCrypto {
+	k := &zipCrypto{0x12345678, 0x23456789, 0x34567890}
+	for i := range len(password) {
+		k.update(password[i])
+	}
+	return k
+}
+
+// update mixes the plaintext byte b into the keys.
+func (k *zipCrypto) update(b byte) {
+	k[0] = crc32.IEEETable[byte(k[0])^b] ^ k[0]>>8
+	k[1] = (k[1]+k[0]&0xff)*134775813 + 1
+	k[2] = crc32.IEEETable[byte(k[2])^byte(k[1]>>24)] ^ k[2]>>8
+}
+
+// stream returns the next byte of the key stream.
+func (k *zipCrypto) stream() byte {
+	t := k[2]&0xffff | 2
+	return byte(t * (t ^ 1) >> 8)
+}
+
+// encrypt returns the plaintext byte b encrypted.
+func (k *zipCrypto) encrypt(b byte) byte {
+	c := b ^ k.stream()
+	k.update(b)
+	return c
+}
+
+// encryptWriter encrypts what is written through it, preceded by the
+// encryption header. archive/zip opens compressors before it writes the
+// local header, so the encryption header is held until the first write,
+// or Close for entries without data.
+type encryptWriter struct {
+	w    io.Writer
+	keys *zipCrypto
+	head []byte // encryption header not written yet
+	buf  []byte
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if err := e.flush(); err != nil {
+		return 0, err
+	}
+	// p belongs to the caller and is encrypted into a copy.
+	e.buf = append(e.buf[:0], p...)
+	for i, b := range e.buf {
+		e.buf[i] = e.keys.encrypt(b)
+	}
+	return e.w.Write(e.buf) //nolint:wrapcheck // Pass-through writer.
+}
+
+// Close writes the encryption header if nothing was written. It does not
+// close the underlying writer.
+func (e *encryptWriter) Close() error {
+	return e.flush()
+}
+
+func (e *encryptWriter) flush() error {
+	if e.head == nil {
+		return nil
+	}
+	head := e.head
+	e.head = nil
+	for i, b := range head {
+		head[i] = e.keys.encrypt(b)
+	}
+	_, err := e.w.Write(head)
+	return err //nolint:wrapcheck // Pass-through writer.
+}
+
+// sealer returns the function through which the compressors registered
+// for header write, which encrypts the entry with ZipOptions.Password if
+// header is flagged encrypted, or nil without a password.
+func (a *archiver) sealer(header *zip.FileHeader) func(io.Writer) io.WriteCloser {
+	if a.opts.Password == "" {
+		return nil
+	}
+	return func(w io.Writer) io.WriteCloser {
+		if header.Flags&flagEncrypted == 0 {
+			return nopWriteCloser{w}
+		}
+		head := make([]byte, encryptHeaderLen)
+		rand.Read(head[:encryptHeaderLen-1]) //nolint:errcheck // Never fails.
+		// The CRC is only known once the data is written, so the byte
+		// that lets unzip check the password is the high byte of the
+		// DOS time, which archive/zip has set by now.
+		head[encryptHeaderLen-1] = byte(header.ModifiedTime >> 8)
+		return &encryptWriter{w: w, keys: newZipCrypto(a.opts.Password), head: head}
+	}
+}
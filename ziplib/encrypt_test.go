@@ -0,0 +1,80 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// decryptEntry returns the uncompressed contents of the encrypted entry
+// f, checking the password check byte of its encryption header.
+func decryptEntry(t *testing.T, f *zip.File, password string) string {
+	t.Helper()
+	raw, err := f.OpenRaw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) < encryptHeaderLen {
+		t.Fatalf("%s: %d bytes of data, shorter than the encryption header", f.Name, len(b))
+	}
+	k := newZipCrypto(password)
+	for i, c := range b {
+		b[i] = c ^ k.stream()
+		k.update(b[i])
+	}
+	if got, want := b[encryptHeaderLen-1], byte(f.ModifiedTime>>8); got != want {
+		t.Fatalf("%s: check byte = %#x, want %#x", f.Name, got, want)
+	}
+	var r io.Reader = bytes.NewReader(b[encryptHeaderLen:])
+	if f.Method == zip.Deflate {
+		r = flate.NewReader(r)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("%s: %v", f.Name, err)
+	}
+	return string(data)
+}
+
+func TestZipPassword(t *testing.T) {
+	for _, method := range []Method{MethodDeflate, MethodStore} {
+		t.Run(method.String(), func(t *testing.T) {
+			dir := setupTestDir(t)
+			writeFile(t, filepath.Join(dir, "empty.txt"), "")
+			t.Chdir(dir)
+			zipPath := filepath.Join(t.TempDir(), "secret.zip")
+			opts := ZipOptions{Recursive: true, Method: method, Password: "s3cret", Manifest: new(Manifest)}
+			if err := Zip(zipPath, []string{"."}, opts); err != nil {
+				t.Fatalf("Zip: %v", err)
+			}
+
+			r, err := zip.OpenReader(zipPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			for _, f := range r.File {
+				if f.Name == ManifestName || f.Mode().IsDir() {
+					if f.Flags&flagEncrypted != 0 {
+						t.Errorf("%s is encrypted", f.Name)
+					}
+					continue
+				}
+				if f.Flags&flagEncrypted == 0 {
+					t.Errorf("%s is not encrypted", f.Name)
+					continue
+				}
+				if got, want := decryptEntry(t, f, "s3cret"), readFile(t, filepath.FromSlash(f.Name)); got != want {
+					t.Errorf("%s decrypts to %q, want %q", f.Name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ziplib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// eventLog reports what one Zip or Unzip call does, entry by entry. Events
+// go to the caller's slog.Logger as structured records if one is set, and
+// are otherwise printed as the traditional text lines on the output.
+type eventLog struct {
+	logger *slog.Logger
+	out    io.Writer
+	min    slog.Level // events below this level are dropped
+}
+
+// Verbosity selects how much Zip or Unzip reports about each entry.
+type Verbosity int
+
+// Verbosity levels.
+const (
+	// VerbosityNormal reports each file added, like zip.
+	VerbosityNormal Verbosity = iota
+	// VerbosityQuiet only reports warnings, like zip -q.
+	VerbosityQuiet
+	// VerbosityVerbose also reports the uncompressed and compressed size
+	// and the space saved for each entry, like zip -v.
+	VerbosityVerbose
+	// VerbositySilent reports nothing, not even warnings, like unzip -qq;
+	// errors are still returned.
+	VerbositySilent
+)
+
+// minLevel returns the level of the least important events reported at
+// verbosity v.
+func (v Verbosity) minLevel() slog.Level {
+	switch v {
+	case VerbosityQuiet:
+		return slog.LevelWarn
+	case VerbositySilent:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// enabled reports whether events of the given level are reported.
+func (l eventLog) enabled(level slog.Level) bool {
+	return level >= l.min
+}
+
+// emit reports the event action, e.g. "inflating", with the given
+// attributes, or prints text if there is no logger.
+func (l eventLog) emit(level slog.Level, action, text string, attrs ...slog.Attr) {
+	if !l.enabled(level) {
+		return
+	}
+	if l.logger != nil {
+		l.logger.LogAttrs(context.Background(), level, action, attrs...)
+		return
+	}
+	fmt.Fprint(l.out, text)
+}
+
+// entryAttr, sizeAttr and durationAttr build the attributes shared by
+// most events.
+func entryAttr(name string) slog.Attr {
+	return slog.String("entry", name)
+}
+
+func sizeAttr(n uint64) slog.Attr {
+	return slog.Uint64("size", n)
+}
+
+func durationAttr(start time.Time) slog.Attr {
+	return slog.Duration("duration", time.Since(start))
+}
@@ -0,0 +1,141 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// decodeEvents parses the JSON lines written by a slog.JSONHandler.
+func decodeEvents(t *testing.T, data []byte) []map[string]any {
+	t.Helper()
+	var events []map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var e map[string]any
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestLoggerEvents(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "hello")
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	t.Chdir(src)
+
+	var logs, out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	err := Zip(zipPath, []string{"a.txt"}, ZipOptions{
+		CompressionLevel: -1,
+		Logger:           logger,
+		Output:           &out,
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	dest := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, Logger: logger, Output: &out}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("text output written alongside the logger:\n%s", out.String())
+	}
+
+	events := decodeEvents(t, logs.Bytes())
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2: %s", len(events), logs.String())
+	}
+	for i, want := range []string{"adding", "inflating"} {
+		e := events[i]
+		if e["msg"] != want {
+			t.Errorf("event %d msg = %v, want %q", i, e["msg"], want)
+		}
+		if e["entry"] != "a.txt" {
+			t.Errorf("event %d entry = %v, want a.txt", i, e["entry"])
+		}
+		if e["size"] != float64(5) {
+			t.Errorf("event %d size = %v, want 5", i, e["size"])
+		}
+		if _, ok := e["duration"]; !ok {
+			t.Errorf("event %d has no duration: %v", i, e)
+		}
+	}
+	if events[0]["method"] != "deflate" {
+		t.Errorf("adding method = %v, want deflate", events[0]["method"])
+	}
+	if c, ok := events[0]["compressed"].(float64); !ok || c == 0 {
+		t.Errorf("adding compressed = %v, want the compressed size", events[0]["compressed"])
+	}
+}
+
+func TestZipVerbosity(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), strings.Repeat("a", 1000))
+	t.Chdir(src)
+
+	tests := []struct {
+		verbosity Verbosity
+		method    Method
+		want      string
+	}{
+		{VerbosityNormal, MethodDeflate, "  adding: a.txt\n"},
+		{VerbosityQuiet, MethodDeflate, ""},
+		{VerbosityVerbose, MethodStore, "  adding: a.txt\t(in=1000) (out=1000) (stored 0%)\n"},
+		{VerbosityVerbose, MethodDeflate, "  adding: a.txt\t(in=1000) (out="},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		opts := ZipOptions{CompressionLevel: -1, Method: tt.method, Verbosity: tt.verbosity, Output: &out}
+		if err := Zip(filepath.Join(t.TempDir(), "out.zip"), []string{"a.txt"}, opts); err != nil {
+			t.Fatalf("Zip: %v", err)
+		}
+		if got := out.String(); !strings.HasPrefix(got, tt.want) || tt.want == "" && got != "" {
+			t.Errorf("verbosity %d, %v: output %q, want %q", tt.verbosity, tt.method, got, tt.want)
+		}
+		if tt.verbosity == VerbosityVerbose && tt.method == MethodDeflate && !strings.HasSuffix(out.String(), "(deflated 99%)\n") {
+			t.Errorf("verbose deflate output %q, want 99%% saved", out.String())
+		}
+	}
+}
+
+func TestUnzipVerbosity(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "x", &zip.FileHeader{Name: "a.txt"}, &zip.FileHeader{Name: "b.txt"})
+	dest := t.TempDir()
+	writeFile(t, filepath.Join(dest, "a.txt"), "existing")
+
+	tests := []struct {
+		verbosity Verbosity
+		want      []string
+		dropped   []string
+	}{
+		{VerbosityNormal, []string{"would collide", "would extract"}, nil},
+		{VerbosityQuiet, []string{"would collide"}, []string{"would extract"}},
+		{VerbositySilent, nil, []string{"would collide", "would extract"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		opts := UnzipOptions{OutputDir: dest, DryRun: true, Verbosity: tt.verbosity, Output: &out}
+		if err := Unzip(zipPath, opts); err != nil {
+			t.Fatalf("Unzip: %v", err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("verbosity %d: output %q lacks %q", tt.verbosity, out.String(), want)
+			}
+		}
+		for _, dropped := range tt.dropped {
+			if strings.Contains(out.String(), dropped) {
+				t.Errorf("verbosity %d: output %q has %q", tt.verbosity, out.String(), dropped)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ziplib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/jaeyeom/gozip/internal/shellwords"
+)
+
+// execPlaceholder is replaced with the extracted file path in exec hooks.
+const execPlaceholder = "{}"
+
+// splitCommand splits a command line into arguments as
+// shellwords.Split does, rejecting blank commands.
+func splitCommand(s string) ([]string, error) {
+	args, err := shellwords.Split(s)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The message already names the command.
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// expandCommand substitutes path for every placeholder in args. If no
+// argument contains a placeholder, path is appended as the last argument.
+// The path always stays a single argument, whatever characters it contains.
+func expandCommand(args []string, path string) []string {
+	out := make([]string, len(args), len(args)+1)
+	found := false
+	for i, a := range args {
+		if strings.Contains(a, execPlaceholder) {
+			found = true
+			a = strings.ReplaceAll(a, execPlaceholder, path)
+		}
+		out[i] = a
+	}
+	if !found {
+		out = append(out, path)
+	}
+	return out
+}
+
+// commandPath returns path as passed to an exec hook: a relative path
+// gets a leading "./", so that a file named like "-rf" extracted into the
+// current directory is never taken for an option.
+func commandPath(path string) string {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "."+string(filepath.Separator)) {
+		return path
+	}
+	return "." + string(filepath.Separator) + path
+}
+
+// execHook runs a command for each extracted file with bounded parallelism.
+type execHook struct {
+	args []string
+	out  io.Writer
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// newExecHook parses command and returns a hook running at most parallel
+// commands at once. A parallel value of zero or less uses one per CPU.
+// Command output goes to out, wrapped so it can be shared safely; callers
+// should write their own messages through the hook's out as well.
+// It returns nil if command is empty.
+func newExecHook(command string, parallel int, out io.Writer) (*execHook, error) {
+	if command == "" {
+		return nil, nil //nolint:nilnil // A nil hook means no command is run.
+	}
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, fmt.Errorf("exec command: %w", err)
+	}
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+	return &execHook{
+		args: args,
+		out:  &lockedWriter{w: out},
+		sem:  make(chan struct{}, parallel),
+	}, nil
+}
+
+// run starts the command for path, blocking while the parallelism limit
+// is reached.
+func (h *execHook) run(path string) {
+	if h == nil {
+		return
+	}
+	path = commandPath(path)
+	argv := expandCommand(h.args, path)
+	h.sem <- struct{}{}
+	h.wg.Add(1)
+	go func() {
+		defer func() {
+			<-h.sem
+			h.wg.Done()
+		}()
+		cmd := exec.Command(argv[0], argv[1:]...) //nolint:gosec // Running the user's command is the purpose of the hook.
+		cmd.Stdout = h.out
+		cmd.Stderr = h.out
+		if err := cmd.Run(); err != nil {
+			h.mu.Lock()
+			h.errs = append(h.errs, fmt.Errorf("exec %s: %w", path, err))
+			h.mu.Unlock()
+		}
+	}()
+}
+
+// wait blocks until all started commands finish and returns their errors.
+func (h *execHook) wait() error {
+	if h == nil {
+		return nil
+	}
+	h.wg.Wait()
+	return errors.Join(h.errs...)
+}
+
+// lockedWriter serializes writes from concurrently running commands.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	n, err := lw.w.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("write: %w", err)
+	}
+	return n, nil
+}
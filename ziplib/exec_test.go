@@ -0,0 +1,129 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"echo {}", []string{"echo", "{}"}, false},
+		{"  sha256sum   {}  ", []string{"sha256sum", "{}"}, false},
+		{`sh -c 'echo "$1"' _ {}`, []string{"sh", "-c", `echo "$1"`, "_", "{}"}, false},
+		{`printf "%s\n" {}`, []string{"printf", `%s\n`, "{}"}, false},
+		{`a\ b c`, []string{"a b", "c"}, false},
+		{`echo ''`, []string{"echo", ""}, false},
+		{`echo 'unterminated`, nil, true},
+		{"   ", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := splitCommand(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommand(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitCommand(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	path := "dir/it's; rm -rf $HOME.txt"
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"placeholder", []string{"cat", "{}"}, []string{"cat", path}},
+		{"embedded", []string{"cp", "{}", "{}.bak"}, []string{"cp", path, path + ".bak"}},
+		{"appended", []string{"wc", "-c"}, []string{"wc", "-c", path}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandCommand(tt.args, path); !slices.Equal(got, tt.want) {
+				t.Errorf("expandCommand = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandPath(t *testing.T) {
+	sep := string(filepath.Separator)
+	abs, err := filepath.Abs("x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"-rf":                     "." + sep + "-rf",
+		"--output=x":              "." + sep + "--output=x",
+		filepath.Join("d", "-rf"): "." + sep + filepath.Join("d", "-rf"),
+		"." + sep + "a":           "." + sep + "a",
+		abs:                       abs,
+	} {
+		if got := commandPath(path); got != want {
+			t.Errorf("commandPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestUnzipExecCommand(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo not found in PATH")
+	}
+	zipPath := filepath.Join(t.TempDir(), "exec.zip")
+	extractDir := t.TempDir()
+	writeTestZip(t, zipPath, "data",
+		&zip.FileHeader{Name: "a.txt"},
+		&zip.FileHeader{Name: "b c.txt"},
+		&zip.FileHeader{Name: "d.txt"},
+	)
+
+	var buf bytes.Buffer
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir:    extractDir,
+		ExecCommand:  "echo seen:{}",
+		ExecParallel: 2,
+		Output:       &buf,
+	})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+
+	var seen []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if p, ok := strings.CutPrefix(line, "seen:"); ok {
+			seen = append(seen, filepath.Base(p))
+		}
+	}
+	sort.Strings(seen)
+	if want := []string{"a.txt", "b c.txt", "d.txt"}; !slices.Equal(seen, want) {
+		t.Errorf("exec saw %q, want %q", seen, want)
+	}
+}
+
+func TestUnzipExecCommandFailure(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false not found in PATH")
+	}
+	zipPath := filepath.Join(t.TempDir(), "fail.zip")
+	writeTestZip(t, zipPath, "data", &zip.FileHeader{Name: "a.txt"})
+
+	err := Unzip(zipPath, UnzipOptions{OutputDir: t.TempDir(), ExecCommand: "false"})
+	if err == nil || !strings.Contains(err.Error(), "exec") {
+		t.Fatalf("expected exec error, got: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package ziplib
+
+import "encoding/binary"
+
+// Extra field header IDs understood by this package.
+const (
+	// extraUnixOwner is the Info-ZIP "new Unix" field carrying UID and GID.
+	extraUnixOwner = 0x7875
+	// extraExtTime is the extended timestamp field carrying Unix times.
+	extraExtTime = 0x5455
+	// extraUnicodePath is the Info-ZIP Unicode Path field with a UTF-8 name.
+	extraUnicodePath = 0x7075
+)
+
+// extraField is a single record of a zip extra field block.
+type extraField struct {
+	tag  uint16
+	data []byte
+}
+
+// parseExtra splits a raw extra field block into its records. A truncated
+// trailing record is ignored.
+func parseExtra(b []byte) []extraField {
+	var fields []extraField
+	for len(b) >= 4 {
+		tag := binary.LittleEndian.Uint16(b[0:2])
+		size := int(binary.LittleEndian.Uint16(b[2:4]))
+		b = b[4:]
+		if size > len(b) {
+			break
+		}
+		fields = append(fields, extraField{tag: tag, data: b[:size]})
+		b = b[size:]
+	}
+	return fields
+}
+
+// findExtra returns the data of the first record in b with the given tag.
+func findExtra(b []byte, tag uint16) ([]byte, bool) {
+	for _, f := range parseExtra(b) {
+		if f.tag == tag {
+			return f.data, true
+		}
+	}
+	return nil, false
+}
+
+// appendExtra appends a record with the given tag and data to b.
+func appendExtra(b []byte, tag uint16, data []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, tag)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(data))) //nolint:gosec // Record bodies are built by this package and are small.
+	return append(b, data...)
+}
+
+// removeExtra returns b without the records that have the given tag.
+func removeExtra(b []byte, tag uint16) []byte {
+	var out []byte
+	for _, f := range parseExtra(b) {
+		if f.tag != tag {
+			out = appendExtra(out, f.tag, f.data)
+		}
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package ziplib
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseExtra(t *testing.T) {
+	var b []byte
+	b = appendExtra(b, 0x0001, []byte{1, 2, 3, 4})
+	b = appendExtra(b, extraUnixOwner, []byte{9})
+	b = append(b, 0x55, 0x54, 0x10) // truncated trailing record
+
+	fields := parseExtra(b)
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(fields))
+	}
+	if fields[0].tag != 0x0001 || !bytes.Equal(fields[0].data, []byte{1, 2, 3, 4}) {
+		t.Errorf("unexpected first record: %+v", fields[0])
+	}
+
+	data, ok := findExtra(b, extraUnixOwner)
+	if !ok || !bytes.Equal(data, []byte{9}) {
+		t.Errorf("findExtra = %v, %v; want [9], true", data, ok)
+	}
+	if _, ok := findExtra(b, 0x5455); ok {
+		t.Error("findExtra should not return a truncated record")
+	}
+}
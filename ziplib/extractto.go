@@ -0,0 +1,73 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrEntryNotFound is returned by ExtractTo when the archive has no entry
+// with the requested name.
+var ErrEntryNotFound = errors.New("entry not found")
+
+// ExtractTo writes the contents of the entry named entry in the archive at
+// zipPath to w. Nothing else is written, so w may be standard output in a
+// pipeline. The data is checked against the archived CRC-32, and a
+// *CRCError is returned if it does not match; by then the corrupt data has
+// already been written to w.
+func ExtractTo(zipPath, entry string, w io.Writer) error {
+	r, err := openArchive(zipPath, "")
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return extractTo(r.File, entry, w)
+}
+
+// ExtractMatchingToReader writes the contents of every file entry whose
+// name match accepts, in archive order, to w, for an archive of the given
+// size read from r. The central directory is read once, so of a remote
+// archive only it and the matched entries are fetched. Directories are
+// skipped. It returns the number of entries written; each is checked
+// against its CRC-32 as in ExtractTo.
+func ExtractMatchingToReader(r io.ReaderAt, size int64, match func(name string) bool, w io.Writer) (int, error) {
+	zr, err := openReader(r, size, "")
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !match(f.Name) {
+			continue
+		}
+		if err := writeEntry(f, w); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func extractTo(files []*zip.File, entry string, w io.Writer) error {
+	for _, f := range files {
+		if f.Name == entry {
+			return writeEntry(f, w)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrEntryNotFound, entry)
+}
+
+// writeEntry writes the contents of f to w, checking them against the
+// CRC-32.
+func writeEntry(f *zip.File, w io.Writer) error {
+	if u := checkSupported(f); u != nil {
+		return &UnsupportedError{Entries: []UnsupportedEntry{*u}}
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open entry %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return copyVerified(w, rc, f)
+}
@@ -0,0 +1,53 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractTo(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "pipe.zip")
+	t.Chdir(src)
+	if err := Zip(zipPath, []string{"."}, ZipOptions{Recursive: true, CompressionLevel: -1}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExtractTo(zipPath, "sub/nested.txt", &buf); err != nil {
+		t.Fatalf("ExtractTo: %v", err)
+	}
+	if got := buf.String(); got != "nested content\n" {
+		t.Errorf("ExtractTo wrote %q, want %q", got, "nested content\n")
+	}
+
+	err := ExtractTo(zipPath, "missing.txt", &buf)
+	if !errors.Is(err, ErrEntryNotFound) {
+		t.Errorf("ExtractTo(missing) = %v, want ErrEntryNotFound", err)
+	}
+}
+
+func TestExtractMatchingToReader(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "reader.zip")
+	writeTestZip(t, zipPath, "contents\n", &zip.FileHeader{Name: "a.txt"}, &zip.FileHeader{Name: "b.txt"}, &zip.FileHeader{Name: "c.bin"})
+	b, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := ExtractMatchingToReader(bytes.NewReader(b), int64(len(b)), func(name string) bool {
+		return strings.HasSuffix(name, ".txt")
+	}, &buf)
+	if err != nil {
+		t.Fatalf("ExtractMatchingToReader: %v", err)
+	}
+	if want := "contents\ncontents\n"; n != 2 || buf.String() != want {
+		t.Errorf("ExtractMatchingToReader wrote %d entries, %q, want 2, %q", n, buf.String(), want)
+	}
+}
@@ -0,0 +1,339 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Zip record signatures and sizes used when scanning damaged archives.
+const (
+	localHeaderSig     = 0x04034b50
+	dataDescriptorSig  = 0x08074b50
+	localHeaderLen     = 30
+	dataDescriptorLen  = 12 // Without the optional signature.
+	dataDescriptor64   = 20 // Zip64 form, with 8-byte sizes.
+	flagDataDescriptor = 0x8
+	extraZip64         = 0x0001
+)
+
+// scanChunk is the read size used when searching for signatures.
+const scanChunk = 64 << 10
+
+// FixOptions configures the behavior of the Fix function.
+type FixOptions struct {
+	// FullScan ignores the central directory and rebuilds it by scanning
+	// the archive for local file headers, like zip -FF. Use it when the
+	// central directory is missing or damaged, e.g. for truncated
+	// downloads. By default entries are read through the central
+	// directory, like zip -F.
+	FullScan bool
+	// Output is where status messages are written. If nil, messages are discarded.
+	Output io.Writer
+}
+
+// Fix salvages the readable entries of the damaged archive at zipPath into
+// a new archive at outPath. Entries whose data is truncated or fails its
+// CRC check are dropped and reported in Output. It returns an error if no
+// entry could be salvaged.
+func Fix(zipPath, outPath string, opts FixOptions) error {
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	in, err := os.Open(zipPath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("stat archive: %w", err)
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	defer w.Close()
+
+	var salvaged int
+	if opts.FullScan {
+		salvaged, err = fixByScan(w, in, fi.Size(), out)
+	} else {
+		salvaged, err = fixByDirectory(w, in, fi.Size(), out)
+	}
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	if salvaged == 0 {
+		return fmt.Errorf("no entries could be salvaged from %s", zipPath)
+	}
+	fmt.Fprintf(out, "%d entries salvaged to %s\n", salvaged, outPath)
+	return nil
+}
+
+// fixByDirectory copies every entry listed in the central directory whose
+// data can be read and verified.
+func fixByDirectory(w *zip.Writer, r io.ReaderAt, size int64, out io.Writer) (int, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return 0, fmt.Errorf("read central directory (try a full scan): %w", err)
+	}
+	registerDecompressors(zr)
+	var salvaged int
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			if err := verifyEntry(f); err != nil {
+				fmt.Fprintf(out, "   dropping: %s (%v)\n", f.Name, err)
+				continue
+			}
+		}
+		if err := w.Copy(f); err != nil {
+			return salvaged, fmt.Errorf("copy %s: %w", f.Name, err)
+		}
+		fmt.Fprintf(out, " salvaging: %s\n", f.Name)
+		salvaged++
+	}
+	return salvaged, nil
+}
+
+// fixByScan rebuilds the archive from the local file headers found in r.
+// Data following a successfully read entry is not searched, so signatures
+// inside stored data are not mistaken for headers.
+func fixByScan(w *zip.Writer, r io.ReaderAt, size int64, out io.Writer) (int, error) {
+	var salvaged int
+	for pos := int64(0); ; {
+		off, ok := findSignature(r, pos, size, localHeaderSig)
+		if !ok {
+			return salvaged, nil
+		}
+		e, err := readLocalEntry(r, off, size)
+		if err != nil {
+			if e != nil {
+				fmt.Fprintf(out, "   dropping: %s (%v)\n", e.header.Name, err)
+			}
+			pos = off + 1
+			continue
+		}
+		if err := e.write(w); err != nil {
+			return salvaged, err
+		}
+		fmt.Fprintf(out, " salvaging: %s\n", e.header.Name)
+		salvaged++
+		pos = e.end
+	}
+}
+
+// findSignature returns the offset of the first occurrence of the 4-byte
+// little-endian signature sig in r at or after from.
+func findSignature(r io.ReaderAt, from, size int64, sig uint32) (int64, bool) {
+	pattern := binary.LittleEndian.AppendUint32(nil, sig)
+	buf := make([]byte, scanChunk+len(pattern)-1)
+	for from < size {
+		n, err := r.ReadAt(buf, from)
+		if n < len(pattern) {
+			return 0, false
+		}
+		if i := bytes.Index(buf[:n], pattern); i >= 0 {
+			return from + int64(i), true
+		}
+		if err != nil {
+			return 0, false
+		}
+		from += int64(n - len(pattern) + 1)
+	}
+	return 0, false
+}
+
+// localEntry is an entry recovered from its local file header.
+type localEntry struct {
+	header *zip.FileHeader
+	data   *io.SectionReader // Compressed data.
+	end    int64             // Offset just past the entry and its descriptor.
+	zip64  bool              // The descriptor, if any, has 8-byte sizes.
+}
+
+// readLocalEntry parses the local file header at off and locates the end
+// of its data. If the header itself is readable but the data is not, the
+// returned entry carries the name for reporting along with the error.
+func readLocalEntry(r io.ReaderAt, off, size int64) (*localEntry, error) {
+	var h [localHeaderLen]byte
+	if _, err := r.ReadAt(h[:], off); err != nil {
+		return nil, fmt.Errorf("read local header: %w", err)
+	}
+	le := binary.LittleEndian
+	flags := le.Uint16(h[6:8])
+	nameLen := int64(le.Uint16(h[26:28]))
+	extraLen := int64(le.Uint16(h[28:30]))
+	nameExtra := make([]byte, nameLen+extraLen)
+	if _, err := r.ReadAt(nameExtra, off+localHeaderLen); err != nil {
+		return nil, fmt.Errorf("read local header: %w", err)
+	}
+
+	hdr := &zip.FileHeader{
+		Name:               string(nameExtra[:nameLen]),
+		ReaderVersion:      le.Uint16(h[4:6]),
+		Flags:              flags &^ flagDataDescriptor,
+		Method:             le.Uint16(h[8:10]),
+		ModifiedTime:       le.Uint16(h[10:12]),
+		ModifiedDate:       le.Uint16(h[12:14]),
+		CRC32:              le.Uint32(h[14:18]),
+		CompressedSize64:   uint64(le.Uint32(h[18:22])),
+		UncompressedSize64: uint64(le.Uint32(h[22:26])),
+	}
+	extra := nameExtra[nameLen:]
+	z, zip64 := findExtra(extra, extraZip64)
+	if len(z) >= 16 {
+		hdr.UncompressedSize64 = le.Uint64(z[0:8])
+		hdr.CompressedSize64 = le.Uint64(z[8:16])
+	}
+	hdr.Extra = removeExtra(extra, extraZip64)
+
+	e := &localEntry{header: hdr, zip64: zip64}
+	start := off + localHeaderLen + nameLen + extraLen
+	if flags&flagDataDescriptor != 0 {
+		if err := e.readDescriptor(r, start, size); err != nil {
+			return e, err
+		}
+	} else {
+		e.end = start + int64(hdr.CompressedSize64) //nolint:gosec // Checked against the file size below.
+	}
+	if e.end > size || e.end < start {
+		return e, errors.New("entry data truncated")
+	}
+	e.data = io.NewSectionReader(r, start, int64(hdr.CompressedSize64)) //nolint:gosec // Bounded by the file size.
+	if err := e.verify(); err != nil {
+		return e, err
+	}
+	return e, nil
+}
+
+// readDescriptor finds the end of data that starts at start and whose
+// sizes and CRC follow in a data descriptor. Deflated data is
+// self-terminating; for other methods the descriptor is searched for.
+// Entries with a Zip64 extra field have Zip64 descriptors, whose sizes
+// take 8 bytes each.
+func (e *localEntry) readDescriptor(r io.ReaderAt, start, size int64) error {
+	csize := int64(-1)
+	if e.header.Method == zip.Deflate {
+		cr := &countingByteReader{r: bufio.NewReader(io.NewSectionReader(r, start, size-start))}
+		if _, err := io.Copy(io.Discard, flate.NewReader(cr)); err != nil {
+			return fmt.Errorf("inflate: %w", err)
+		}
+		csize = cr.n
+	}
+
+	for pos := start; ; {
+		descOff := start + csize
+		if csize < 0 {
+			off, ok := findSignature(r, pos, size, dataDescriptorSig)
+			if !ok {
+				return errors.New("data descriptor not found")
+			}
+			descOff, pos = off, off+1
+		}
+		descLen := dataDescriptorLen
+		if e.zip64 {
+			descLen = dataDescriptor64
+		}
+		var d [4 + dataDescriptor64]byte
+		n, _ := r.ReadAt(d[:4+descLen], descOff)
+		b := d[:n]
+		if len(b) >= 4 && binary.LittleEndian.Uint32(b) == dataDescriptorSig {
+			b = b[4:]
+		}
+		if len(b) < descLen {
+			return errors.New("data descriptor truncated")
+		}
+		stored, usize := uint64(binary.LittleEndian.Uint32(b[4:8])), uint64(binary.LittleEndian.Uint32(b[8:12]))
+		if e.zip64 {
+			stored, usize = binary.LittleEndian.Uint64(b[4:12]), binary.LittleEndian.Uint64(b[12:20])
+		}
+		// A searched signature only ends the data if the size it records
+		// matches; otherwise it was part of the data.
+		if csize < 0 && stored != uint64(descOff-start) { //nolint:gosec // Offsets are never negative here.
+			continue
+		}
+		e.header.CRC32 = binary.LittleEndian.Uint32(b[0:4])
+		e.header.CompressedSize64 = uint64(descOff - start) //nolint:gosec // Offsets are never negative here.
+		e.header.UncompressedSize64 = usize
+		e.end = descOff + int64(n-len(b)+descLen)
+		return nil
+	}
+}
+
+// verify decompresses stored and deflated data and checks its CRC-32.
+// Data in other methods is kept unverified.
+func (e *localEntry) verify() error {
+	var rc io.Reader
+	switch e.header.Method {
+	case zip.Store:
+		rc = io.NewSectionReader(e.data, 0, e.data.Size())
+	case zip.Deflate:
+		fr := flate.NewReader(io.NewSectionReader(e.data, 0, e.data.Size()))
+		defer fr.Close()
+		rc = fr
+	default:
+		return nil
+	}
+	h := crc32.NewIEEE()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return fmt.Errorf("read data: %w", err)
+	}
+	if uint64(n) != e.header.UncompressedSize64 { //nolint:gosec // Byte counts are never negative.
+		return fmt.Errorf("size %d, should be %d", n, e.header.UncompressedSize64)
+	}
+	if got := h.Sum32(); got != e.header.CRC32 {
+		return &CRCError{Entry: e.header.Name, Want: e.header.CRC32, Got: got}
+	}
+	return nil
+}
+
+// write copies the entry's compressed data unchanged into w.
+func (e *localEntry) write(w *zip.Writer) error {
+	fw, err := w.CreateRaw(e.header)
+	if err != nil {
+		return fmt.Errorf("create header %s: %w", e.header.Name, err)
+	}
+	if _, err := io.Copy(fw, io.NewSectionReader(e.data, 0, e.data.Size())); err != nil {
+		return fmt.Errorf("write %s: %w", e.header.Name, err)
+	}
+	return nil
+}
+
+// countingByteReader counts the bytes consumed by a decompressor. flate
+// reads one byte at a time from an io.ByteReader, so the count is exactly
+// the length of the compressed stream.
+type countingByteReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingByteReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err //nolint:wrapcheck // Pass-through reader.
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err //nolint:wrapcheck // Pass-through reader.
+}
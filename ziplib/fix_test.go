@@ -0,0 +1,141 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeMixedZip creates an archive with deflated and stored entries, all
+// written with data descriptors, and returns its bytes.
+func writeMixedZip(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, h := range []*zip.FileHeader{
+		{Name: "a.txt", Method: zip.Deflate},
+		// Stored data containing a descriptor signature must not end early.
+		{Name: "b.bin", Method: zip.Store},
+		{Name: "c.txt", Method: zip.Deflate},
+	} {
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Repeat(h.Name+" content ", 100)
+		if h.Method == zip.Store {
+			content = "PK\x07\x08" + content
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFixFullScanTruncated(t *testing.T) {
+	data := writeMixedZip(t)
+	dir := t.TempDir()
+	damaged := filepath.Join(dir, "damaged.zip")
+	// Cut into the last entry, losing the central directory.
+	cut := bytes.LastIndex(data, []byte("PK\x03\x04")) + 40
+	if err := os.WriteFile(damaged, data[:cut], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := List(damaged); err == nil {
+		t.Fatal("truncated archive unexpectedly readable")
+	}
+
+	fixed := filepath.Join(dir, "fixed.zip")
+	var out bytes.Buffer
+	if err := Fix(damaged, fixed, FixOptions{FullScan: true, Output: &out}); err != nil {
+		t.Fatalf("Fix: %v\n%s", err, out.String())
+	}
+
+	extractDir := t.TempDir()
+	if err := Unzip(fixed, UnzipOptions{OutputDir: extractDir}); err != nil {
+		t.Fatalf("Unzip fixed archive: %v", err)
+	}
+	if got := readFile(t, filepath.Join(extractDir, "b.bin")); !strings.HasPrefix(got, "PK\x07\x08b.bin content") {
+		t.Errorf("b.bin content = %.20q", got)
+	}
+	entries, err := List(fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "a.txt" || entries[1].Name != "b.bin" {
+		t.Errorf("salvaged entries = %+v, want a.txt and b.bin", entries)
+	}
+}
+
+func TestFixDirectoryDropsCorrupt(t *testing.T) {
+	zipPath, _, _ := writeBadCRCZip(t)
+	var out bytes.Buffer
+	err := Fix(zipPath, filepath.Join(t.TempDir(), "fixed.zip"), FixOptions{Output: &out})
+	if err == nil {
+		t.Fatal("expected error when nothing can be salvaged")
+	}
+	if !strings.Contains(out.String(), "dropping: bad.txt") {
+		t.Errorf("output missing dropped entry:\n%s", out.String())
+	}
+}
+
+func TestFixDirectoryCopiesIntact(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "ok.zip")
+	if err := os.WriteFile(src, writeMixedZip(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fixed := filepath.Join(dir, "fixed.zip")
+	if err := Fix(src, fixed, FixOptions{}); err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	report, err := Diff(src, fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Empty() {
+		t.Errorf("fixed archive differs: %+v", report)
+	}
+}
+
+func TestFixFullScanDataDescriptors(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		signature, zip64 bool
+	}{
+		{"no signature", false, false},
+		{"zip64", true, true},
+		{"zip64 no signature", false, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			damaged := filepath.Join(dir, "damaged.zip")
+			data := descriptorZip(t, "a.txt", strings.Repeat("descriptor ", 50), tt.signature, tt.zip64)
+			// Drop the central directory so only the local headers remain.
+			cut := bytes.Index(data, []byte("PK\x01\x02"))
+			if err := os.WriteFile(damaged, data[:cut], 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			fixed := filepath.Join(dir, "fixed.zip")
+			var out bytes.Buffer
+			if err := Fix(damaged, fixed, FixOptions{FullScan: true, Output: &out}); err != nil {
+				t.Fatalf("Fix: %v\n%s", err, out.String())
+			}
+			var buf bytes.Buffer
+			if err := ExtractTo(fixed, "a.txt", &buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != strings.Repeat("descriptor ", 50) {
+				t.Errorf("a.txt = %.30q", buf.String())
+			}
+		})
+	}
+}
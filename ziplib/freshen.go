@@ -0,0 +1,44 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"time"
+)
+
+// Reasons for entries left out by Freshen, Update and KeepNewer.
+const (
+	errNotExisting   = entrySkipped("not in destination")
+	errNotNewer      = entrySkipped("not newer than existing file")
+	errExistingNewer = entrySkipped("existing file is newer")
+)
+
+// checkFreshen returns an entrySkipped error if Freshen, Update or
+// KeepNewer leave f out: Freshen skips entries whose destination does not
+// exist, both Freshen and Update skip files that are not newer than the
+// existing copy, and KeepNewer skips files whose existing copy is newer.
+// Times are compared to the second, as archives store no finer precision.
+func (x *extractor) checkFreshen(f *zip.File, destPath string) error {
+	if !x.opts.Freshen && !x.opts.Update && !x.opts.KeepNewer {
+		return nil
+	}
+	fi, err := os.Lstat(destPath)
+	if err != nil {
+		if x.opts.Freshen {
+			return errNotExisting
+		}
+		return nil
+	}
+	if f.FileInfo().IsDir() {
+		return nil
+	}
+	_, mtime := entryTimes(f)
+	existing := fi.ModTime().Truncate(time.Second)
+	if (x.opts.Freshen || x.opts.Update) && !mtime.After(existing) {
+		return errNotNewer
+	}
+	if x.opts.KeepNewer && existing.After(mtime) {
+		return errExistingNewer
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUnzipFreshenAndUpdate(t *testing.T) {
+	archived := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "archived",
+		&zip.FileHeader{Name: "stale.txt", Method: zip.Store, Modified: archived},
+		&zip.FileHeader{Name: "current.txt", Method: zip.Store, Modified: archived},
+		&zip.FileHeader{Name: "new.txt", Method: zip.Store, Modified: archived},
+	)
+
+	tests := []struct {
+		name string
+		opts UnzipOptions
+		want map[string]string
+	}{
+		{"freshen", UnzipOptions{Freshen: true}, map[string]string{"stale.txt": "archived", "current.txt": "local", "new.txt": ""}},
+		{"update", UnzipOptions{Update: true}, map[string]string{"stale.txt": "archived", "current.txt": "local", "new.txt": "archived"}},
+		{"keep newer", UnzipOptions{KeepNewer: true, Overwrite: true}, map[string]string{"stale.txt": "archived", "current.txt": "local", "new.txt": "archived"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			for name, mtime := range map[string]time.Time{"stale.txt": archived.Add(-time.Hour), "current.txt": archived.Add(time.Hour)} {
+				path := filepath.Join(dest, name)
+				writeFile(t, path, "local")
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var res Result
+			opts := tt.opts
+			opts.OutputDir, opts.Result = dest, &res
+			if err := Unzip(zipPath, opts); err != nil {
+				t.Fatalf("Unzip: %v", err)
+			}
+			for name, want := range tt.want {
+				path := filepath.Join(dest, name)
+				if want == "" {
+					if _, err := os.Stat(path); !os.IsNotExist(err) {
+						t.Errorf("%s was created: %v", name, err)
+					}
+					continue
+				}
+				if got := readFile(t, path); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if want := 3 - res.Entries; res.Skipped != want {
+				t.Errorf("skipped = %d, want %d", res.Skipped, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Grow adds files to the existing archive at zipPath in place, like
+// zip -g. Existing entries are neither read nor rewritten: the new entries
+// are written over the old central directory and followed by one listing
+// both, so growing a large archive only costs the new data. A file whose
+// entry name is already in the archive replaces that entry; the replaced
+// data stays in the file, unreferenced, until the archive is rebuilt.
+//
+// If adding fails, or there is nothing to add (ErrNothingToDo), the
+// original central directory is put back. If zipPath does not exist, Grow
+// creates it as Zip does.
+func Grow(zipPath string, files []string, opts ZipOptions) (err error) {
+	a, err := newArchiver(opts)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return a.dryRun(files)
+	}
+	f, err := openLocked(zipPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Zip(zipPath, files, opts)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if a.self, err = f.Stat(); err != nil {
+		return fmt.Errorf("stat archive: %w", err)
+	}
+	size := a.self.Size()
+	d, err := centralDirectory(f, size)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+
+	// Everything from the central directory on is overwritten; it is kept
+	// to put back if adding fails.
+	tail := make([]byte, size-d.off)
+	if _, err := f.ReadAt(tail, d.off); err != nil {
+		return fmt.Errorf("read central directory: %w", err)
+	}
+	done := false
+	defer func() {
+		if !done {
+			err = restoreTail(f, tail, d.off, err)
+		}
+	}()
+
+	written, err := a.growEntries(f, d.off-d.base, d.off, files)
+	if err != nil {
+		return err
+	}
+	end, err := a.replaceDirectory(f, tail[:d.length], d.base, written, cmp.Or(opts.Comment, d.comment))
+	if err != nil {
+		return err
+	}
+	done = true
+	a.result.CompressedBytes = uint64(end) //nolint:gosec // Offsets are never negative.
+	if opts.LatestTime {
+		if err := SetLatestTime(zipPath); err != nil {
+			return err
+		}
+	}
+	return a.removeSources()
+}
+
+// restoreTail puts tail, the end of f read from off, back after adding
+// failed with err, and returns err along with any error restoring it.
+func restoreTail(f *os.File, tail []byte, off int64, err error) error {
+	if _, werr := f.WriteAt(tail, off); werr != nil {
+		return errors.Join(err, fmt.Errorf("restore archive: %w", werr))
+	}
+	if terr := f.Truncate(off + int64(len(tail))); terr != nil {
+		return errors.Join(err, fmt.Errorf("restore archive: %w", terr))
+	}
+	return err
+}
+
+// growEntries writes the entries of files to f from off, where the
+// archive's entries are base bytes into it, and returns where the
+// central directory of just the new entries that ends them ends.
+func (a *archiver) growEntries(f *os.File, base, off int64, files []string) (int64, error) {
+	var w io.Writer = io.NewOffsetWriter(f, off)
+	if l := newRateLimiter(a.opts.RateLimit); l != nil {
+		w = &throttledWriter{w: w, l: l}
+	}
+	cw := &countingWriter{w: w}
+	zw := zip.NewWriter(cw)
+	zw.SetOffset(base)
+	a.w = zw
+	if err := a.addAll(files); err != nil {
+		return 0, err
+	}
+	if a.empty() {
+		return 0, ErrNothingToDo
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("finish archive: %w", err)
+	}
+	return off + cw.n, nil
+}
+
+// replaceDirectory replaces the central directory of just the new entries
+// that ends f at written with one that also lists the old entries, whose
+// records are old, and returns the new end of the archive. The archive's
+// entries are base bytes into f.
+func (a *archiver) replaceDirectory(f *os.File, old []byte, base, written int64, comment string) (int64, error) {
+	nd, err := centralDirectory(io.NewSectionReader(f, 0, written), written)
+	if err != nil {
+		return 0, fmt.Errorf("read new central directory: %w", err)
+	}
+	added := make([]byte, nd.length)
+	if _, err := f.ReadAt(added, nd.off); err != nil {
+		return 0, fmt.Errorf("read new central directory: %w", err)
+	}
+	if added, _, err = a.directory(added); err != nil {
+		return 0, err
+	}
+	dir, count, err := mergeDirectories(old, added)
+	if err != nil {
+		return 0, err
+	}
+	dir = appendDirEnd(dir, count, int64(len(dir)), nd.off-base, comment)
+	if _, err := f.WriteAt(dir, nd.off); err != nil {
+		return 0, fmt.Errorf("write central directory: %w", err)
+	}
+	end := nd.off + int64(len(dir))
+	if err := f.Truncate(end); err != nil {
+		return 0, fmt.Errorf("truncate archive: %w", err)
+	}
+	return end, nil
+}
+
+// mergeDirectories returns the central directory records of old whose
+// names are not in added, followed by those of added, and their count.
+func mergeDirectories(old, added []byte) ([]byte, uint64, error) {
+	names := make(map[string]bool)
+	if err := centralRecords(added, func(name string, _ []byte) {
+		names[name] = true
+	}); err != nil {
+		return nil, 0, err
+	}
+	merged := make([]byte, 0, len(old)+len(added))
+	var count uint64
+	keep := func(name string, rec []byte) {
+		merged = append(merged, rec...)
+		count++
+	}
+	if err := centralRecords(old, func(name string, rec []byte) {
+		if !names[name] {
+			keep(name, rec)
+		}
+	}); err != nil {
+		return nil, 0, err
+	}
+	if err := centralRecords(added, keep); err != nil {
+		return nil, 0, err
+	}
+	return merged, count, nil
+}
+
+// centralRecords calls fn with the raw name and bytes of each record in
+// the central directory b.
+func centralRecords(b []byte, fn func(name string, rec []byte)) error {
+	r := bytes.NewReader(b)
+	for r.Len() > 0 {
+		start := len(b) - r.Len()
+		h, _, err := readCentralHeader(r)
+		if err != nil {
+			return fmt.Errorf("read central directory: %w", err)
+		}
+		fn(h.Name, b[start:len(b)-r.Len()])
+	}
+	return nil
+}
+
+// appendDirEnd appends the end of central directory record for a
+// directory of count entries and length bytes at offset off to b,
+// preceded by the Zip64 end record and locator when the counts or
+// offsets do not fit the classic record.
+func appendDirEnd(b []byte, count uint64, length, off int64, comment string) []byte {
+	le := binary.LittleEndian
+	count16, length32, off32 := count, uint64(length), uint64(off) //nolint:gosec // Sizes and offsets are never negative.
+	if count >= 0xffff || length32 >= 0xffffffff || off32 >= 0xffffffff {
+		end64 := off32 + length32
+		b = le.AppendUint32(b, dir64EndSig)
+		b = le.AppendUint64(b, dir64EndLen-12) // Size of the rest of the record.
+		b = le.AppendUint16(b, 45)             // Version made by.
+		b = le.AppendUint16(b, 45)             // Version needed.
+		b = le.AppendUint32(b, 0)              // Disk numbers.
+		b = le.AppendUint32(b, 0)
+		b = le.AppendUint64(b, count)
+		b = le.AppendUint64(b, count)
+		b = le.AppendUint64(b, length32)
+		b = le.AppendUint64(b, off32)
+
+		b = le.AppendUint32(b, dir64LocatorSig)
+		b = le.AppendUint32(b, 0) // Disk holding the Zip64 end record.
+		b = le.AppendUint64(b, end64)
+		b = le.AppendUint32(b, 1) // Total disks.
+		count16, length32, off32 = min(count, 0xffff), min(length32, 0xffffffff), min(off32, 0xffffffff)
+	}
+	b = le.AppendUint32(b, dirEndSig)
+	b = le.AppendUint32(b, 0)                    // Disk numbers.
+	b = le.AppendUint16(b, uint16(count16))      //nolint:gosec // Capped above.
+	b = le.AppendUint16(b, uint16(count16))      //nolint:gosec // Capped above.
+	b = le.AppendUint32(b, uint32(length32))     //nolint:gosec // Capped above.
+	b = le.AppendUint32(b, uint32(off32))        //nolint:gosec // Capped above.
+	b = le.AppendUint16(b, uint16(len(comment))) //nolint:gosec // Read from a 16-bit length.
+	return append(b, comment...)
+}
@@ -0,0 +1,123 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// zipContents returns the contents of every file entry in the archive at
+// path, read with archive/zip.
+func zipContents(t *testing.T, path string) (map[string]string, string) {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer r.Close()
+	contents := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		contents[f.Name] = string(b)
+	}
+	return contents, r.Comment
+}
+
+func TestGrow(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "old a")
+	writeFile(t, filepath.Join(src, "b.txt"), "b")
+	t.Chdir(src)
+
+	var archive bytes.Buffer
+	w := zip.NewWriter(&archive)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(fw, readFile(t, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.SetComment("kept comment")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(t.TempDir(), "grow.zip")
+	writeFile(t, zipPath, archive.String())
+
+	writeFile(t, filepath.Join(src, "a.txt"), "new a")
+	writeFile(t, filepath.Join(src, "c.txt"), "c")
+	if err := Grow(zipPath, []string{"a.txt", "c.txt"}, ZipOptions{CompressionLevel: -1}); err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+	got, comment := zipContents(t, zipPath)
+	want := map[string]string{"a.txt": "new a", "b.txt": "b", "c.txt": "c"}
+	if len(got) != len(want) || got["a.txt"] != want["a.txt"] || got["b.txt"] != want["b.txt"] || got["c.txt"] != want["c.txt"] {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+	if comment != "kept comment" {
+		t.Errorf("comment = %q, want it kept", comment)
+	}
+}
+
+func TestGrowPrependedData(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	writeFile(t, filepath.Join(src, "b.txt"), "b")
+	t.Chdir(src)
+
+	// Offsets stay relative to the archive proper, as in a
+	// self-extracting archive made by prepending a stub.
+	var archive bytes.Buffer
+	if err := ZipTo(&archive, []string{"a.txt"}, ZipOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(t.TempDir(), "sfx.zip")
+	writeFile(t, zipPath, "#!/bin/sh\nexit 0\n"+archive.String())
+
+	if err := Grow(zipPath, []string{"b.txt"}, ZipOptions{}); err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+	got, _ := zipContents(t, zipPath)
+	if got["a.txt"] != "a" || got["b.txt"] != "b" {
+		t.Errorf("contents = %v", got)
+	}
+}
+
+func TestGrowFailureRestoresArchive(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "grow.zip")
+	if err := Zip(zipPath, []string{"a.txt"}, ZipOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Grow(zipPath, []string{"a.txt", "missing.txt"}, ZipOptions{}); err == nil {
+		t.Fatal("expected an error for a missing input")
+	}
+	after, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("archive changed by a failed Grow")
+	}
+}
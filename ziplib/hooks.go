@@ -0,0 +1,89 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"time"
+)
+
+// EntryEvent describes an entry passed to the OnEntryStart, OnEntryDone
+// and OnSkip hooks of ZipOptions and UnzipOptions.
+type EntryEvent struct {
+	// Name is the entry name in the archive, with forward slashes.
+	Name string
+	// Path is the file the entry is read from or extracted to. It is
+	// empty if the entry was skipped before its destination was resolved.
+	Path string
+	// Size is the uncompressed size of the entry in bytes.
+	Size  uint64
+	IsDir bool
+	// Duration is the time spent on the entry, set for OnEntryDone.
+	Duration time.Duration
+	// Err is the error the entry failed with, set for OnEntryDone. An
+	// entry skipped by UnzipOptions.OnError is reported with its error.
+	Err error
+	// Reason says why the entry was left out, set for OnSkip.
+	Reason string
+}
+
+// entryHooks calls the per-entry hooks of the options, if set.
+type entryHooks struct {
+	start, done, skip func(EntryEvent)
+}
+
+func (h entryHooks) started(ev EntryEvent) {
+	if h.start != nil {
+		h.start(ev)
+	}
+}
+
+// finished reports the outcome of an entry started at start.
+func (h entryHooks) finished(ev EntryEvent, start time.Time, err error) {
+	if h.done != nil {
+		ev.Duration = time.Since(start)
+		ev.Err = err
+		h.done(ev)
+	}
+}
+
+func (h entryHooks) skipped(ev EntryEvent, reason string) {
+	if h.skip != nil {
+		ev.Reason = reason
+		h.skip(ev)
+	}
+}
+
+// skipped counts path as left out of the archive for reason and reports
+// it to OnSkip.
+func (a *archiver) skipped(path string, isDir bool, reason string) {
+	a.result.Skipped++
+	a.hooks.skipped(EntryEvent{Name: fileEntryName(path, isDir), Path: path, IsDir: isDir}, reason)
+}
+
+// skipped counts f as not extracted for reason and reports it to OnSkip.
+// destPath is empty if the destination is not known.
+func (x *extractor) skipped(f *zip.File, destPath, reason string) {
+	x.mu.Lock()
+	x.result.Skipped++
+	x.mu.Unlock()
+	x.hooks.skipped(fileEvent(f, destPath), reason)
+}
+
+// fileEvent returns the event describing f extracted to destPath.
+func fileEvent(f *zip.File, destPath string) EntryEvent {
+	return EntryEvent{
+		Name:  f.Name,
+		Path:  destPath,
+		Size:  f.UncompressedSize64,
+		IsDir: f.FileInfo().IsDir(),
+	}
+}
+
+// fileEntryName returns the entry name of the file or directory at path.
+func fileEntryName(path string, isDir bool) string {
+	name := filepath.ToSlash(path)
+	if isDir {
+		name += "/"
+	}
+	return name
+}
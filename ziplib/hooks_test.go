@@ -0,0 +1,71 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipEntryHooks(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "hello")
+	writeFile(t, filepath.Join(src, "b.log"), "skip")
+	t.Chdir(src)
+
+	var started, done, skipped []EntryEvent
+	err := Zip(filepath.Join(t.TempDir(), "out.zip"), []string{"a.txt", "b.log"}, ZipOptions{
+		ExcludePatterns: []string{"*.log"},
+		OnEntryStart:    func(ev EntryEvent) { started = append(started, ev) },
+		OnEntryDone:     func(ev EntryEvent) { done = append(done, ev) },
+		OnSkip:          func(ev EntryEvent) { skipped = append(skipped, ev) },
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if len(started) != 1 || started[0].Name != "a.txt" || started[0].Size != 5 {
+		t.Errorf("started = %+v, want a.txt of 5 bytes", started)
+	}
+	if len(done) != 1 || done[0].Name != "a.txt" || done[0].Err != nil {
+		t.Errorf("done = %+v, want a.txt without error", done)
+	}
+	if len(skipped) != 1 || skipped[0].Name != "b.log" || skipped[0].Reason != "excluded" {
+		t.Errorf("skipped = %+v, want b.log excluded", skipped)
+	}
+}
+
+func TestUnzipEntryHooks(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "x",
+		&zip.FileHeader{Name: "a.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "b.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "c.bin", Method: zip.Store, Flags: flagEncrypted})
+
+	dest := t.TempDir()
+	writeFile(t, filepath.Join(dest, "b.txt"), "existing")
+	var started, skipped []EntryEvent
+	done := make(map[string]error)
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir:    dest,
+		OnError:      func(string, error) ErrorAction { return ErrorSkip },
+		OnEntryStart: func(ev EntryEvent) { started = append(started, ev) },
+		OnEntryDone:  func(ev EntryEvent) { done[ev.Name] = ev.Err },
+		OnSkip:       func(ev EntryEvent) { skipped = append(skipped, ev) },
+	})
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Unzip error = %v, want an *UnsupportedError", err)
+	}
+	if len(started) != 2 || started[0].Path != filepath.Join(dest, "a.txt") {
+		t.Errorf("started = %+v, want a.txt and b.txt", started)
+	}
+	if err, ok := done["a.txt"]; !ok || err != nil {
+		t.Errorf("a.txt done with %v (reported: %t), want success", err, ok)
+	}
+	if done["b.txt"] == nil {
+		t.Error("b.txt done without the file exists error")
+	}
+	if len(skipped) != 1 || skipped[0].Name != "c.bin" || skipped[0].Reason == "" {
+		t.Errorf("skipped = %+v, want c.bin with a reason", skipped)
+	}
+}
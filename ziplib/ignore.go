@@ -0,0 +1,61 @@
+package ziplib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileNames are the files read from each directory when
+// ZipOptions.UseIgnoreFiles is set.
+var ignoreFileNames = []string{".gitignore", ".zipignore"}
+
+// ignoreSet holds the gitignore rules loaded while walking, keyed by the
+// directory whose ignore files they came from.
+type ignoreSet map[string]gitignoreMatcher
+
+// load reads the ignore files in dir, if any.
+func (s ignoreSet) load(dir string) error {
+	var patterns []string
+	for _, name := range ignoreFileNames {
+		data, err := os.ReadFile(filepath.Join(dir, name)) //nolint:gosec // Reading ignore files inside the tree being archived.
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read ignore file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			patterns = append(patterns, strings.TrimSuffix(line, "\r"))
+		}
+	}
+	if m := newGitignoreMatcher(patterns); len(m) > 0 {
+		s[dir] = m
+	}
+	return nil
+}
+
+// match reports whether p is ignored. As in git, the rules of the nearest
+// directory that has a rule matching p decide, so a deeper ignore file can
+// re-include a path with a negated pattern. Paths inside ignored
+// directories are never reached, since the walk skips those directories.
+func (s ignoreSet) match(p string, isDir bool) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for dir := filepath.Dir(p); ; dir = filepath.Dir(dir) {
+		if m, ok := s[dir]; ok {
+			if rel, err := filepath.Rel(dir, p); err == nil {
+				if ignored, matched := m.ignored(strings.Split(cleanName(rel), "/"), isDir); matched {
+					return ignored
+				}
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			return false
+		}
+	}
+}
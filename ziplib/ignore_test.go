@@ -0,0 +1,43 @@
+package ziplib
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestZipUseIgnoreFiles(t *testing.T) {
+	src := t.TempDir()
+	for _, dir := range []string{"build", "sub/cache"} {
+		if err := os.MkdirAll(filepath.Join(src, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(src, ".gitignore"), "*.log\r\nbuild/\n")
+	writeFile(t, filepath.Join(src, "main.go"), "package main\n")
+	writeFile(t, filepath.Join(src, "debug.log"), "log\n")
+	writeFile(t, filepath.Join(src, "build", "out.bin"), "bin\n")
+	writeFile(t, filepath.Join(src, "sub", ".zipignore"), "# local rules\ncache\n!keep.log\n")
+	writeFile(t, filepath.Join(src, "sub", "keep.log"), "re-included\n")
+	writeFile(t, filepath.Join(src, "sub", "cache", "x"), "x\n")
+	writeFile(t, filepath.Join(src, "sub", "a.txt"), "a\n")
+	t.Chdir(src)
+
+	zipPath := filepath.Join(t.TempDir(), "ignore.zip")
+	if err := Zip(zipPath, []string{"."}, ZipOptions{Recursive: true, UseIgnoreFiles: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	want := []string{".gitignore", "main.go", "sub/.zipignore", "sub/a.txt", "sub/keep.log"}
+	if !slices.Equal(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,269 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ManifestName is the entry under which Zip stores the manifest of an
+// archive made with ZipOptions.Manifest or BaseManifest.
+const ManifestName = ".gozip-manifest.json"
+
+// Manifest records the files of a backup, so that a later incremental
+// backup can store only what changed since. A chain of archives, a full
+// backup followed by deltas each based on the one before, is restored
+// with Restore.
+type Manifest struct {
+	// Created is when the backup was made.
+	Created time.Time `json:"created"`
+	// Base is the Created time of the backup this one is a delta of, or
+	// zero for a full backup.
+	Base time.Time `json:"base,omitzero"`
+	// Files maps the entry name of every file of the backup, whether it
+	// is stored in this archive or in an earlier one of the chain, to its
+	// state when it was stored.
+	Files map[string]ManifestFile `json:"files"`
+	// Deleted lists the files of the base backup that are no longer part
+	// of this one.
+	Deleted []string `json:"deleted,omitempty"`
+}
+
+// ManifestFile is the state of a file recorded in a Manifest.
+type ManifestFile struct {
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// SHA256 is the hex-encoded SHA-256 of the contents. It is empty for
+	// special files, which have none.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// errNoManifest is returned for archives without a ManifestName entry.
+var errNoManifest = errors.New("no backup manifest in archive")
+
+// ReadManifest reads a manifest written by WriteManifest.
+func ReadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// WriteManifest writes m to path as JSON, replacing any existing file
+// only once the new one is complete.
+func WriteManifest(path string, m *Manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	defer os.Remove(f.Name()) // Fails harmlessly once the manifest is installed.
+	defer f.Close()
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return installFile(f.Name(), path, true)
+}
+
+// ReadArchiveManifest returns the manifest stored in the archive at
+// zipPath.
+func ReadArchiveManifest(zipPath string) (*Manifest, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer r.Close()
+	rc, err := r.Open(ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", zipPath, errNoManifest)
+	}
+	defer rc.Close()
+	m := new(Manifest)
+	if err := json.NewDecoder(rc).Decode(m); err != nil {
+		return nil, fmt.Errorf("read manifest of %s: %w", zipPath, err)
+	}
+	return m, nil
+}
+
+// newManifest returns the manifest to fill for opts, or nil if none is
+// wanted.
+func newManifest(opts ZipOptions) *Manifest {
+	m := opts.Manifest
+	if m == nil {
+		if opts.BaseManifest == nil {
+			return nil
+		}
+		m = new(Manifest)
+	}
+	*m = Manifest{Created: time.Now().UTC(), Files: make(map[string]ManifestFile)}
+	if opts.BaseManifest != nil {
+		m.Base = opts.BaseManifest.Created
+	}
+	return m
+}
+
+// unchanged reports whether the file at path, stored as name, has the
+// size and modification time recorded in BaseManifest. It is then carried
+// over to the new manifest instead of being stored again.
+func (a *archiver) unchanged(name string, info os.FileInfo) bool {
+	if a.opts.BaseManifest == nil {
+		return false
+	}
+	old, ok := a.opts.BaseManifest.Files[name]
+	if !ok || old.Size != info.Size() || !old.Modified.Equal(info.ModTime()) {
+		return false
+	}
+	a.manifest.Files[name] = old
+	return true
+}
+
+// recordFile records the file stored as name in the manifest, with the
+// checksum sum of its contents, which is nil for special files.
+func (a *archiver) recordFile(name string, info os.FileInfo, sum []byte) {
+	if a.manifest == nil {
+		return
+	}
+	a.manifest.Files[name] = ManifestFile{
+		Size:     info.Size(),
+		Modified: info.ModTime().UTC(),
+		SHA256:   hex.EncodeToString(sum),
+	}
+}
+
+// hashing returns r wrapped to hash what is read through it, if the
+// contents are needed for Deduplicate or the manifest.
+func (a *archiver) hashing(r io.Reader) (io.Reader, hash.Hash) {
+	if a.dedup == nil && a.manifest == nil {
+		return r, nil
+	}
+	h := sha256.New()
+	return io.TeeReader(r, h), h
+}
+
+// writeManifest lists the files of BaseManifest missing from the new
+// manifest as deleted, and stores the manifest as the last entry of the
+// archive.
+func (a *archiver) writeManifest() error {
+	m := a.manifest
+	if m == nil || a.opts.DryRun {
+		return nil
+	}
+	if base := a.opts.BaseManifest; base != nil {
+		for name := range base.Files {
+			if _, ok := m.Files[name]; !ok {
+				m.Deleted = append(m.Deleted, name)
+			}
+		}
+		slices.Sort(m.Deleted)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	header := &zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: m.Created}
+	header.SetMode(0o644)
+	w, err := a.w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	a.dedup.created()
+	return nil
+}
+
+// Restore extracts a chain of backups into opts.OutputDir: a full backup
+// followed by the incremental backups made on top of it with
+// ZipOptions.BaseManifest, in the order they were made. Files of each
+// archive replace those of the archives before it, and the files it
+// records as deleted are removed, so the result matches the last backup.
+//
+// Every archive must hold a manifest, and each must be based on the one
+// before; Restore checks the whole chain before extracting anything.
+// Deleted files are removed by entry name, relative to OutputDir; names
+// that would leave it are ignored.
+func Restore(zipPaths []string, opts UnzipOptions) error {
+	manifests, err := readChain(zipPaths)
+	if err != nil {
+		return err
+	}
+
+	outputDir := opts.OutputDir
+	if outputDir == "" {
+		outputDir = "."
+	}
+	opts.SkipManifest = true
+	for i, p := range zipPaths {
+		o := opts
+		if i > 0 {
+			o.Overwrite = true
+		}
+		if err := Unzip(p, o); err != nil {
+			return err
+		}
+		if opts.DryRun {
+			continue
+		}
+		if err := removeDeleted(outputDir, manifests[i].Deleted); err != nil {
+			return fmt.Errorf("restore %s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// readChain reads the manifests of the archives of a backup chain, and
+// checks that the first is a full backup and each other one is based on
+// the one before it.
+func readChain(zipPaths []string) ([]*Manifest, error) {
+	manifests := make([]*Manifest, len(zipPaths))
+	for i, p := range zipPaths {
+		m, err := ReadArchiveManifest(p)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case i == 0 && !m.Base.IsZero():
+			return nil, fmt.Errorf("restore %s: not a full backup", p)
+		case i > 0 && !m.Base.Equal(manifests[i-1].Created):
+			return nil, fmt.Errorf("restore %s: not based on %s", p, zipPaths[i-1])
+		}
+		manifests[i] = m
+	}
+	return manifests, nil
+}
+
+// removeDeleted removes the files a manifest lists as deleted from
+// outputDir. Names that are not local paths are ignored.
+func removeDeleted(outputDir string, deleted []string) error {
+	for _, name := range deleted {
+		local := filepath.FromSlash(name)
+		if !filepath.IsLocal(local) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(outputDir, local)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package ziplib
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIncrementalBackupChain(t *testing.T) {
+	src := t.TempDir()
+	for _, name := range []string{"keep.txt", "change.txt", "remove.txt"} {
+		writeFile(t, filepath.Join(src, name), "v1 "+name)
+	}
+	t.Chdir(src)
+	dir := t.TempDir()
+	full, delta := filepath.Join(dir, "full.zip"), filepath.Join(dir, "delta.zip")
+	manifestPath := filepath.Join(dir, "backup.json")
+
+	var m1 Manifest
+	if err := Zip(full, []string{"."}, ZipOptions{Recursive: true, Manifest: &m1}); err != nil {
+		t.Fatalf("full backup: %v", err)
+	}
+	if len(m1.Files) != 3 || m1.Files["keep.txt"].SHA256 == "" {
+		t.Fatalf("manifest files = %v", m1.Files)
+	}
+	if err := WriteManifest(manifestPath, &m1); err != nil {
+		t.Fatal(err)
+	}
+	base, err := ReadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(src, "change.txt"), "v2 change.txt, longer")
+	writeFile(t, filepath.Join(src, "new.txt"), "new")
+	if err := os.Remove(filepath.Join(src, "remove.txt")); err != nil {
+		t.Fatal(err)
+	}
+	var m2 Manifest
+	opts := ZipOptions{Recursive: true, Manifest: &m2, BaseManifest: base}
+	if err := Zip(delta, []string{"."}, opts); err != nil {
+		t.Fatalf("incremental backup: %v", err)
+	}
+	got, _ := zipContents(t, delta)
+	if _, ok := got[ManifestName]; len(got) != 3 || got["change.txt"] == "" || got["new.txt"] == "" || !ok {
+		t.Errorf("delta entries = %v, want change.txt, new.txt and the manifest", got)
+	}
+	if len(m2.Deleted) != 1 || m2.Deleted[0] != "remove.txt" || !m2.Base.Equal(m1.Created) {
+		t.Errorf("delta manifest: deleted %v, base %v", m2.Deleted, m2.Base)
+	}
+	if len(m2.Files) != 3 {
+		t.Errorf("delta manifest files = %v, want keep.txt, change.txt and new.txt", m2.Files)
+	}
+
+	out := t.TempDir()
+	if err := Restore([]string{full, delta}, UnzipOptions{OutputDir: out}); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	want := map[string]string{"keep.txt": "v1 keep.txt", "change.txt": "v2 change.txt, longer", "new.txt": "new"}
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("restored %d files, want %d", len(entries), len(want))
+	}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(out, name)); got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+
+	if err := Restore([]string{delta}, UnzipOptions{OutputDir: t.TempDir()}); err == nil {
+		t.Error("Restore accepted a chain starting with a delta")
+	}
+}
+
+func TestRestoreRequiresManifest(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "plain.zip")
+	if err := Zip(zipPath, []string{"a.txt"}, ZipOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore([]string{zipPath}, UnzipOptions{OutputDir: t.TempDir()}); !errors.Is(err, errNoManifest) {
+		t.Errorf("Restore = %v, want errNoManifest", err)
+	}
+}
+
+func TestSkipManifest(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "full.zip")
+	var m Manifest
+	if err := Zip(zipPath, []string{"a.txt"}, ZipOptions{Manifest: &m}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := t.TempDir()
+	var res Result
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: out, SkipManifest: true, Result: &res}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(out, ManifestName)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("manifest extracted: %v", err)
+	}
+	if got := readFile(t, filepath.Join(out, "a.txt")); got != "a" {
+		t.Errorf("a.txt = %q, want %q", got, "a")
+	}
+	if res.Entries != 1 || res.Skipped != 0 {
+		t.Errorf("entries %d, skipped %d, want 1 and 0", res.Entries, res.Skipped)
+	}
+
+	entries, err := ListWithOptions(zipPath, ListOptions{SkipManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "a.txt" {
+		t.Errorf("listed %v, want only a.txt", entries)
+	}
+	if all, err := List(zipPath); err != nil || len(all) != 2 {
+		t.Errorf("List = %v, %v, want a.txt and the manifest", all, err)
+	}
+}
+
+func TestIncrementalUnchangedSkipped(t *testing.T) {
+	src := t.TempDir()
+	path := filepath.Join(src, "a.txt")
+	writeFile(t, path, "a")
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(src)
+	base := &Manifest{Files: map[string]ManifestFile{"a.txt": {Size: 1, Modified: mtime, SHA256: "x"}}}
+	var m Manifest
+	var res Result
+	opts := ZipOptions{Manifest: &m, BaseManifest: base, Result: &res}
+	zipPath := filepath.Join(t.TempDir(), "d.zip")
+	if err := Zip(zipPath, []string{"a.txt"}, opts); !errors.Is(err, ErrNothingToDo) {
+		t.Fatalf("Zip = %v, want ErrNothingToDo", err)
+	}
+	if _, err := os.Stat(zipPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("archive created with nothing to add: %v", err)
+	}
+	if res.Entries != 0 || res.Skipped != 1 || m.Files["a.txt"].SHA256 != "x" {
+		t.Errorf("entries %d, skipped %d, manifest %v", res.Entries, res.Skipped, m.Files)
+	}
+}
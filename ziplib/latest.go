@@ -0,0 +1,47 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SetLatestTime sets the modification time of the archive at zipPath to
+// that of its newest entry, like zip -o, so that the archive looks as old
+// as its contents. The manifest of a backup, stamped with the time it was
+// made, is not counted. An archive without entries is left alone.
+func SetLatestTime(zipPath string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	var latest time.Time
+	for _, f := range r.File {
+		if f.Name == ManifestName {
+			continue
+		}
+		if t := entryModTime(f); t.After(latest) {
+			latest = t
+		}
+	}
+	r.Close()
+	if latest.IsZero() {
+		return nil
+	}
+	if err := os.Chtimes(zipPath, time.Time{}, latest); err != nil {
+		return fmt.Errorf("set archive time: %w", err)
+	}
+	return nil
+}
+
+// entryModTime returns the modification time of f. Without an extended
+// timestamp, archive/zip reads the MS-DOS time, which is local time, as
+// UTC; it is taken as local time instead.
+func entryModTime(f *zip.File) time.Time {
+	t := f.Modified
+	if _, ok := findExtra(f.Extra, extraExtTime); ok || t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+}
@@ -0,0 +1,58 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestZipLatestTime(t *testing.T) {
+	src := setupTestDir(t)
+	older := time.Date(2020, 3, 4, 5, 6, 8, 0, time.Local)
+	newer := time.Date(2021, 6, 7, 8, 9, 10, 0, time.Local)
+	for name, mtime := range map[string]time.Time{"hello.txt": newer, "foo.go": older} {
+		if err := os.Chtimes(filepath.Join(src, name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := []string{filepath.Join(src, "hello.txt"), filepath.Join(src, "foo.go")}
+
+	for _, strip := range []bool{false, true} {
+		zipPath := filepath.Join(t.TempDir(), "latest.zip")
+		opts := ZipOptions{LatestTime: true, StripExtras: strip, Manifest: new(Manifest)}
+		if err := Zip(zipPath, files, opts); err != nil {
+			t.Fatalf("Zip: %v", err)
+		}
+		fi, err := os.Stat(zipPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.ModTime().Equal(newer) {
+			t.Errorf("StripExtras=%v: archive time %v, want %v", strip, fi.ModTime(), newer)
+		}
+	}
+}
+
+func TestGrowLatestTime(t *testing.T) {
+	src := setupTestDir(t)
+	mtime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.Local)
+	path := filepath.Join(src, "hello.txt")
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := filepath.Join(t.TempDir(), "grow.zip")
+	old := &zip.FileHeader{Name: "old.txt", Modified: time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)}
+	writeTestZip(t, zipPath, "old\n", old)
+	if err := Grow(zipPath, []string{path}, ZipOptions{LatestTime: true}); err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+	fi, err := os.Stat(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(mtime) {
+		t.Errorf("archive time %v, want %v", fi.ModTime(), mtime)
+	}
+}
@@ -0,0 +1,57 @@
+package ziplib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LevelOverride sets the compression level of files matching Pattern,
+// e.g. 9 for "*.txt" or 0 to store "*.jpg" uncompressed.
+type LevelOverride struct {
+	Pattern string
+	Level   int
+}
+
+// ParseLevelOverride parses an override written as "pattern=level".
+func ParseLevelOverride(s string) (LevelOverride, error) {
+	i := strings.LastIndex(s, "=")
+	if i <= 0 {
+		return LevelOverride{}, fmt.Errorf("invalid level override %q: use pattern=level", s)
+	}
+	level, err := strconv.Atoi(s[i+1:])
+	if err != nil || level < 0 || level > 9 {
+		return LevelOverride{}, fmt.Errorf("invalid level override %q: level must be 0-9", s)
+	}
+	return LevelOverride{Pattern: s[:i], Level: level}, nil
+}
+
+// levelRule is a compiled LevelOverride.
+type levelRule struct {
+	match Matcher
+	level int
+}
+
+// compileLevels compiles overrides with the given pattern syntax.
+func compileLevels(syntax MatchSyntax, overrides []LevelOverride) ([]levelRule, error) {
+	rules := make([]levelRule, 0, len(overrides))
+	for _, o := range overrides {
+		m, err := NewMatcher(syntax, []string{o.Pattern})
+		if err != nil {
+			return nil, fmt.Errorf("level override %q: %w", o.Pattern, err)
+		}
+		rules = append(rules, levelRule{match: m, level: o.Level})
+	}
+	return rules, nil
+}
+
+// levelFor returns the level of the first rule matching path, or def if
+// none does.
+func levelFor(rules []levelRule, path string, def int) int {
+	for _, r := range rules {
+		if r.match.Match(path, false) {
+			return r.level
+		}
+	}
+	return def
+}
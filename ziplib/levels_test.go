@@ -0,0 +1,64 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevelOverride(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LevelOverride
+		wantErr bool
+	}{
+		{"*.txt=9", LevelOverride{Pattern: "*.txt", Level: 9}, false},
+		{"a=b=0", LevelOverride{Pattern: "a=b", Level: 0}, false},
+		{"*.txt", LevelOverride{}, true},
+		{"=5", LevelOverride{}, true},
+		{"*.bin=10", LevelOverride{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevelOverride(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseLevelOverride(%q) = %+v, %v; want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestZipLevelOverrides(t *testing.T) {
+	src := t.TempDir()
+	content := strings.Repeat("compressible text ", 1000)
+	for _, name := range []string{"a.txt", "b.bin", "c.dat"} {
+		writeFile(t, filepath.Join(src, name), content)
+	}
+	zipPath := filepath.Join(t.TempDir(), "levels.zip")
+	err := Zip(zipPath, []string{src}, ZipOptions{
+		Recursive:        true,
+		CompressionLevel: 1,
+		LevelOverrides: []LevelOverride{
+			{Pattern: "*.bin", Level: 0},
+			{Pattern: "*.txt", Level: 9},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	methods := make(map[string]uint16)
+	for _, f := range r.File {
+		methods[filepath.Base(f.Name)] = f.Method
+	}
+	if methods["b.bin"] != zip.Store {
+		t.Errorf("b.bin method = %d, want store", methods["b.bin"])
+	}
+	if methods["a.txt"] != zip.Deflate || methods["c.dat"] != zip.Deflate {
+		t.Errorf("methods = %v, want deflate for a.txt and c.dat", methods)
+	}
+}
@@ -0,0 +1,108 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"strings"
+)
+
+// LimitError is returned by Unzip when the archive exceeds one of the
+// resource limits in UnzipOptions. It is reported before anything is
+// extracted.
+type LimitError struct {
+	// Entry is the offending entry name, or empty for MaxEntries and
+	// MaxArchiveSize.
+	Entry string
+	// Limit is the name of the exceeded option, e.g. "MaxEntrySize".
+	Limit string
+	// Value is the archive size, entry count, size, depth or length that
+	// was found.
+	Value uint64
+	// Max is the configured limit.
+	Max uint64
+}
+
+func (e *LimitError) Error() string {
+	if e.Entry == "" {
+		return fmt.Sprintf("archive exceeds %s: %d > %d", e.Limit, e.Value, e.Max)
+	}
+	return fmt.Sprintf("%s: exceeds %s: %d > %d", e.Entry, e.Limit, e.Value, e.Max)
+}
+
+// resourceLimits holds the hard caps applied to an archive before extraction.
+// A zero field means unlimited.
+type resourceLimits struct {
+	archive    uint64
+	entries    uint64
+	entrySize  uint64
+	pathDepth  uint64
+	pathLength uint64
+}
+
+func newResourceLimits(opts UnzipOptions) resourceLimits {
+	return resourceLimits{
+		archive:    nonNegative(opts.MaxArchiveSize),
+		entries:    nonNegative(opts.MaxEntries),
+		entrySize:  nonNegative(opts.MaxEntrySize),
+		pathDepth:  nonNegative(opts.MaxPathDepth),
+		pathLength: nonNegative(opts.MaxPathLength),
+	}
+}
+
+func nonNegative[T int | int64](v T) uint64 {
+	if v < 0 {
+		return 0
+	}
+	return uint64(v)
+}
+
+// checkArchive returns a *LimitError if an archive of size bytes exceeds
+// MaxArchiveSize.
+func (l resourceLimits) checkArchive(size int64) error {
+	if l.archive > 0 && nonNegative(size) > l.archive {
+		return &LimitError{Limit: "MaxArchiveSize", Value: nonNegative(size), Max: l.archive}
+	}
+	return nil
+}
+
+// check returns a *LimitError for the first limit exceeded by files. The
+// declared uncompressed size is trusted here because archive/zip refuses to
+// read past it.
+func (l resourceLimits) check(files []*zip.File) error {
+	if l.entries > 0 && uint64(len(files)) > l.entries {
+		return &LimitError{Limit: "MaxEntries", Value: uint64(len(files)), Max: l.entries}
+	}
+	for _, f := range files {
+		if err := l.checkEntry(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l resourceLimits) checkEntry(f *zip.File) error {
+	exceeds := func(limit string, value, limitMax uint64) error {
+		if limitMax == 0 || value <= limitMax {
+			return nil
+		}
+		return &LimitError{Entry: f.Name, Limit: limit, Value: value, Max: limitMax}
+	}
+	if err := exceeds("MaxEntrySize", f.UncompressedSize64, l.entrySize); err != nil {
+		return err
+	}
+	if err := exceeds("MaxPathDepth", pathDepth(f.Name), l.pathDepth); err != nil {
+		return err
+	}
+	return exceeds("MaxPathLength", uint64(len(f.Name)), l.pathLength)
+}
+
+// pathDepth returns the number of non-empty components in an entry name.
+func pathDepth(name string) uint64 {
+	var n uint64
+	for _, c := range strings.Split(name, "/") {
+		if c != "" && c != "." {
+			n++
+		}
+	}
+	return n
+}
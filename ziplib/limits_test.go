@@ -0,0 +1,74 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnzipResourceLimits(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "limits.zip")
+	writeTestZip(t, zipPath, "0123456789",
+		&zip.FileHeader{Name: "a.txt"},
+		&zip.FileHeader{Name: "deep/er/still/b.txt"},
+		&zip.FileHeader{Name: "c.txt"},
+	)
+
+	tests := []struct {
+		name      string
+		opts      UnzipOptions
+		wantLimit string
+		wantEntry string
+	}{
+		{"no limits", UnzipOptions{}, "", ""},
+		{"entries", UnzipOptions{MaxEntries: 2}, "MaxEntries", ""},
+		{"entries at limit", UnzipOptions{MaxEntries: 3}, "", ""},
+		{"entry size", UnzipOptions{MaxEntrySize: 9}, "MaxEntrySize", "a.txt"},
+		{"depth", UnzipOptions{MaxPathDepth: 3}, "MaxPathDepth", "deep/er/still/b.txt"},
+		{"path length", UnzipOptions{MaxPathLength: 10}, "MaxPathLength", "deep/er/still/b.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractDir := t.TempDir()
+			tt.opts.OutputDir = extractDir
+			err := Unzip(zipPath, tt.opts)
+
+			if tt.wantLimit == "" {
+				if err != nil {
+					t.Fatalf("Unzip: %v", err)
+				}
+				return
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Fatalf("expected *LimitError, got: %v", err)
+			}
+			if limitErr.Limit != tt.wantLimit || limitErr.Entry != tt.wantEntry {
+				t.Errorf("LimitError = %+v, want limit %s entry %q", limitErr, tt.wantLimit, tt.wantEntry)
+			}
+			if _, err := os.Stat(filepath.Join(extractDir, "a.txt")); err == nil {
+				t.Error("entries were extracted despite exceeding a limit")
+			}
+		})
+	}
+}
+
+func TestPathDepth(t *testing.T) {
+	tests := []struct {
+		name string
+		want uint64
+	}{
+		{"a.txt", 1},
+		{"a/b/c.txt", 3},
+		{"a/b/", 2},
+		{"./a//b", 2},
+	}
+	for _, tt := range tests {
+		if got := pathDepth(tt.name); got != tt.want {
+			t.Errorf("pathDepth(%q) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
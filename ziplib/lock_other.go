@@ -0,0 +1,10 @@
+//go:build (!unix && !windows) || aix
+
+package ziplib
+
+import "os"
+
+// lockFile does nothing on platforms without flock or LockFileEx.
+func lockFile(*os.File) error {
+	return nil
+}
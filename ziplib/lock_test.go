@@ -0,0 +1,176 @@
+package ziplib
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockFileExcludesOtherHolders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	first, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lockFile(first); err != nil {
+		t.Fatalf("lockFile: %v", err)
+	}
+
+	second, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	locked := make(chan error)
+	go func() { locked <- lockFile(second) }()
+
+	select {
+	case err := <-locked:
+		first.Close()
+		t.Fatalf("second lock acquired while first was held (err: %v)", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	first.Close()
+	if err := <-locked; err != nil {
+		t.Fatalf("lockFile after release: %v", err)
+	}
+}
+
+func TestZipConcurrentWriters(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "shared.zip")
+
+	names := make([]string, 8)
+	for i := range names {
+		names[i] = filepath.Join(src, fmt.Sprintf("file%d.txt", i))
+		writeFile(t, names[i], fmt.Sprintf("content %d\n", i))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(names))
+	for _, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- Zip(zipPath, []string{name}, ZipOptions{Overwrite: true})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Zip: %v", err)
+		}
+	}
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("archive corrupted by concurrent writers: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+}
+
+func TestGrowFollowsArchiveReplacedByZip(t *testing.T) {
+	src := setupTestDir(t)
+	t.Chdir(src)
+	writeFile(t, "grown.txt", "grown\n")
+	zipPath := filepath.Join(t.TempDir(), "shared.zip")
+	if err := Zip(zipPath, []string{"hello.txt"}, ZipOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the lock so that Grow opens the archive and waits for it.
+	held, err := os.Open(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+	if err := lockFile(held); err != nil {
+		t.Fatal(err)
+	}
+	grown := make(chan error)
+	go func() { grown <- Grow(zipPath, []string{"grown.txt"}, ZipOptions{}) }()
+	time.Sleep(100 * time.Millisecond)
+
+	// Meanwhile Zip replaces the archive that Grow has open.
+	if err := os.Rename(zipPath, zipPath+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Zip(zipPath, []string{"sub"}, ZipOptions{Recursive: true, Overwrite: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	held.Close()
+	if err := <-grown; err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, e := range entries {
+		names[e.Name] = true
+	}
+	if !names["grown.txt"] || !names["sub/nested.txt"] {
+		t.Errorf("entries = %v, want the replacing archive grown by grown.txt", names)
+	}
+}
+
+func TestZipRefusesExistingArchive(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "existing.zip")
+	writeFile(t, zipPath, "not replaced")
+	t.Chdir(src)
+
+	err := Zip(zipPath, []string{"hello.txt"}, ZipOptions{})
+	if !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Zip = %v, want an error matching fs.ErrExist", err)
+	}
+	if got := readFile(t, zipPath); got != "not replaced" {
+		t.Errorf("existing archive = %q, want it untouched", got)
+	}
+
+	// A failed run leaves the existing archive and no temporary file.
+	if err := Zip(zipPath, []string{"missing.txt"}, ZipOptions{Overwrite: true}); err == nil {
+		t.Fatal("expected an error for a missing input")
+	}
+	if got := readFile(t, zipPath); got != "not replaced" {
+		t.Errorf("existing archive = %q after a failed run, want it untouched", got)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(filepath.Dir(zipPath), ".*.tmp")); len(leftover) > 0 {
+		t.Errorf("temporary files left behind: %v", leftover)
+	}
+
+	if err := Zip(zipPath, []string{"hello.txt"}, ZipOptions{Overwrite: true}); err != nil {
+		t.Fatalf("Zip with Overwrite: %v", err)
+	}
+	if entries, err := List(zipPath); err != nil || len(entries) != 1 {
+		t.Errorf("List = %v, %v, want the new archive", entries, err)
+	}
+}
+
+func TestZipSkipsItself(t *testing.T) {
+	src := setupTestDir(t)
+	t.Chdir(src)
+	if err := Zip("self.zip", []string{"."}, ZipOptions{Recursive: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	entries, err := List("self.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name, ".tmp") || strings.HasSuffix(e.Name, ".zip") {
+			t.Errorf("archive contains %s", e.Name)
+		}
+	}
+}
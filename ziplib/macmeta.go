@@ -0,0 +1,28 @@
+package ziplib
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsMacMetadata reports whether the slash-separated name is macOS
+// metadata rather than user content: anything under a __MACOSX directory,
+// where the Finder stores resource forks, .DS_Store files, and AppleDouble
+// files whose base name starts with "._".
+func IsMacMetadata(name string) bool {
+	name = strings.TrimSuffix(name, "/")
+	for _, elem := range strings.Split(name, "/") {
+		if elem == "__MACOSX" {
+			return true
+		}
+	}
+	base := path.Base(name)
+	return base == ".DS_Store" || strings.HasPrefix(base, "._")
+}
+
+// macMetadata reports whether the file at p is left out of the archive
+// by ExcludeMacMetadata.
+func (a *archiver) macMetadata(p string) bool {
+	return a.opts.ExcludeMacMetadata && IsMacMetadata(filepath.ToSlash(a.relPath(p)))
+}
@@ -0,0 +1,74 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestIsMacMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"__MACOSX/", true},
+		{"__MACOSX/dir/._file.txt", true},
+		{"dir/.DS_Store", true},
+		{".DS_Store", true},
+		{"dir/._file.txt", true},
+		{"._dir/", true},
+		{"dir/file.txt", false},
+		{"dir/.DS_Store.txt", false},
+		{"my__MACOSX/file", false},
+		{".hidden", false},
+	}
+	for _, tt := range tests {
+		if got := IsMacMetadata(tt.name); got != tt.want {
+			t.Errorf("IsMacMetadata(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestZipExcludeMacMetadata(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll(filepath.Join("src", "__MACOSX", "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join("src", "a.txt"), "a")
+	writeFile(t, filepath.Join("src", ".DS_Store"), "x")
+	writeFile(t, filepath.Join("src", "._a.txt"), "x")
+	writeFile(t, filepath.Join("src", "__MACOSX", "src", "._a.txt"), "x")
+
+	var res Result
+	got := zipNames(t, []string{"src"}, ZipOptions{Recursive: true, ExcludeMacMetadata: true, Result: &res})
+	if !slices.Equal(got, []string{"src/a.txt"}) {
+		t.Errorf("names = %v, want [src/a.txt]", got)
+	}
+	if res.Skipped != 3 {
+		t.Errorf("skipped = %d, want 3", res.Skipped)
+	}
+}
+
+func TestUnzipExcludeMacMetadata(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "mac.zip")
+	var headers []*zip.FileHeader
+	for _, name := range []string{"a.txt", ".DS_Store", "__MACOSX/", "__MACOSX/._a.txt"} {
+		headers = append(headers, &zip.FileHeader{Name: name, Method: zip.Deflate})
+	}
+	writeTestZip(t, zipPath, "a", headers...)
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, ExcludeMacMetadata: true}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dest, "a.txt")); got != "a" {
+		t.Errorf("a.txt = %q, want %q", got, "a")
+	}
+	for _, name := range []string{".DS_Store", "__MACOSX"} {
+		if _, err := os.Lstat(filepath.Join(dest, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was extracted: %v", name, err)
+		}
+	}
+}
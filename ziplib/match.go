@@ -1,13 +1,378 @@
 package ziplib
 
-import "path/filepath"
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
 
-// matchesAny reports whether name matches any of the given glob patterns.
+// Matcher decides whether an entry name is selected by a set of patterns.
+// Names use forward slashes regardless of platform; isDir reports whether
+// the name refers to a directory.
+type Matcher interface {
+	Match(name string, isDir bool) bool
+}
+
+// MatchSyntax selects how include and exclude patterns are interpreted.
+type MatchSyntax int
+
+const (
+	// SyntaxGlob matches wildcards as Info-ZIP does: "*", "?", bracket
+	// classes negated by "!" or "^", and backslash escapes, with an
+	// unclosed bracket taken literally. Patterns containing a slash match
+	// the whole path, and others match the base name at any directory
+	// level. In path patterns, a "**" segment matches any number of
+	// directories, while "*" stays within one, as with zip -ws.
+	SyntaxGlob MatchSyntax = iota
+	// SyntaxDoublestar matches glob patterns against the whole path, where
+	// "**" spans any number of directories. Patterns without a slash match
+	// the base name.
+	SyntaxDoublestar
+	// SyntaxRegexp matches regular expressions against the whole path.
+	SyntaxRegexp
+	// SyntaxGitignore interprets patterns with .gitignore rules, including
+	// negation with "!" and directory-only patterns ending in "/".
+	SyntaxGitignore
+)
+
+var syntaxNames = map[MatchSyntax]string{
+	SyntaxGlob:       "glob",
+	SyntaxDoublestar: "doublestar",
+	SyntaxRegexp:     "regexp",
+	SyntaxGitignore:  "gitignore",
+}
+
+func (s MatchSyntax) String() string {
+	if name, ok := syntaxNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("MatchSyntax(%d)", int(s))
+}
+
+// ParseMatchSyntax returns the syntax with the given name as reported by
+// MatchSyntax.String.
+func ParseMatchSyntax(name string) (MatchSyntax, error) {
+	for s, n := range syntaxNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown match syntax %q: must be glob, doublestar, regexp or gitignore", name)
+}
+
+// NewMatcher compiles patterns with the given syntax. A matcher built from
+// no patterns matches nothing.
+//
+// In every syntax, a pattern starting with "!" negates: it unselects the
+// names it matches, and later patterns take precedence over earlier ones,
+// so that "build/**" followed by "!build/release-notes.txt" selects all of
+// build except that file. When the list has negated patterns, a pattern
+// matching a directory also matches everything inside, and a directory
+// is only reported as matching when no later negated pattern could
+// unselect something inside it. A leading "\!" matches a literal "!".
+func NewMatcher(syntax MatchSyntax, patterns []string) (Matcher, error) {
+	if syntax != SyntaxGitignore && slices.ContainsFunc(patterns, isNegated) {
+		return newOrderedMatcher(syntax, patterns)
+	}
+	return newMatcher(syntax, patterns)
+}
+
+// newMatcher compiles patterns, none of them negated, with the given
+// syntax.
+func newMatcher(syntax MatchSyntax, patterns []string) (Matcher, error) {
+	switch syntax {
+	case SyntaxGlob:
+		return globMatcher(patterns), nil
+	case SyntaxDoublestar:
+		return newDoublestarMatcher(patterns), nil
+	case SyntaxRegexp:
+		return newRegexpMatcher(patterns)
+	case SyntaxGitignore:
+		return newGitignoreMatcher(patterns), nil
+	default:
+		return nil, fmt.Errorf("unknown match syntax %v", syntax)
+	}
+}
+
+// NewCaseInsensitiveMatcher is like NewMatcher, but the patterns match
+// names regardless of case, as with unzip -C.
+func NewCaseInsensitiveMatcher(syntax MatchSyntax, patterns []string) (Matcher, error) {
+	folded := make([]string, len(patterns))
+	if syntax == SyntaxRegexp {
+		for i, p := range patterns {
+			if isNegated(p) {
+				folded[i] = "!(?i)" + p[1:]
+			} else {
+				folded[i] = "(?i)" + p
+			}
+		}
+		return NewMatcher(syntax, folded)
+	}
+	for i, p := range patterns {
+		folded[i] = strings.ToLower(p)
+	}
+	m, err := NewMatcher(syntax, folded)
+	if err != nil {
+		return nil, err
+	}
+	return foldMatcher{m}, nil
+}
+
+// foldMatcher matches lower-cased names against lower-cased patterns.
+type foldMatcher struct {
+	m Matcher
+}
+
+func (f foldMatcher) Match(name string, isDir bool) bool {
+	return f.m.Match(strings.ToLower(name), isDir)
+}
+
+// matchesAny reports whether name matches any of the given glob patterns,
+// interpreted as with SyntaxGlob.
 func matchesAny(name string, patterns []string) bool {
+	return globMatcher(patterns).Match(name, false)
+}
+
+// cleanName converts name to the slash-separated, root-relative form all
+// matchers operate on.
+func cleanName(name string) string {
+	name = filepath.ToSlash(name)
+	for strings.HasPrefix(name, "./") {
+		name = name[2:]
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, "/"), "/")
+}
+
+// globMatcher matches wildcard patterns against the whole path if they
+// contain a slash, or against the base name otherwise.
+type globMatcher []string
+
+func (m globMatcher) Match(name string, _ bool) bool {
+	name = cleanName(name)
+	base := path.Base(name)
+	var segs []string
+	for _, p := range m {
+		if !strings.Contains(p, "/") {
+			if matchWildcard(p, base) {
+				return true
+			}
+			continue
+		}
+		if segs == nil {
+			segs = strings.Split(name, "/")
+		}
+		if matchSegments(strings.Split(strings.TrimPrefix(p, "/"), "/"), segs) {
+			return true
+		}
+	}
+	return false
+}
+
+// doublestarMatcher matches slash-separated glob patterns segment by segment.
+type doublestarMatcher [][]string
+
+func newDoublestarMatcher(patterns []string) doublestarMatcher {
+	m := make(doublestarMatcher, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimPrefix(p, "/")
+		if !strings.Contains(p, "/") {
+			p = "**/" + p
+		}
+		m = append(m, strings.Split(p, "/"))
+	}
+	return m
+}
+
+func (m doublestarMatcher) Match(name string, _ bool) bool {
+	segs := strings.Split(cleanName(name), "/")
+	for _, p := range m {
+		if matchSegments(p, segs) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" pattern segment matches zero or more path segments.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := len(segs); i >= 0; i-- {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if !matchWildcard(pat[0], segs[0]) {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// regexpMatcher matches regular expressions against the whole path.
+type regexpMatcher []*regexp.Regexp
+
+func newRegexpMatcher(patterns []string) (regexpMatcher, error) {
+	m := make(regexpMatcher, 0, len(patterns))
 	for _, p := range patterns {
-		if matched, err := filepath.Match(p, filepath.Base(name)); err == nil && matched {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile pattern %q: %w", p, err)
+		}
+		m = append(m, re)
+	}
+	return m, nil
+}
+
+func (m regexpMatcher) Match(name string, _ bool) bool {
+	name = cleanName(name)
+	for _, re := range m {
+		if re.MatchString(name) {
 			return true
 		}
 	}
 	return false
 }
+
+// isNegated reports whether pattern starts with the "!" of a negated
+// pattern.
+func isNegated(pattern string) bool {
+	return strings.HasPrefix(pattern, "!")
+}
+
+// orderedRule is a single pattern of an orderedMatcher.
+type orderedRule struct {
+	m      Matcher
+	negate bool
+}
+
+// orderedMatcher applies a list of patterns with negations; the last
+// pattern matching a name or one of its directories wins.
+type orderedMatcher []orderedRule
+
+func newOrderedMatcher(syntax MatchSyntax, patterns []string) (orderedMatcher, error) {
+	m := make(orderedMatcher, 0, len(patterns))
+	for _, p := range patterns {
+		var r orderedRule
+		if isNegated(p) {
+			r.negate = true
+			p = p[1:]
+		}
+		var err error
+		if r.m, err = newMatcher(syntax, []string{p}); err != nil {
+			return nil, err
+		}
+		m = append(m, r)
+	}
+	return m, nil
+}
+
+func (m orderedMatcher) Match(name string, isDir bool) bool {
+	name = cleanName(name)
+	last := -1
+	for i, r := range m {
+		if r.matches(name, isDir) {
+			last = i
+		}
+	}
+	if last < 0 || m[last].negate {
+		return false
+	}
+	if isDir {
+		// Skipping the directory would hide what the negated patterns
+		// select inside it.
+		for _, r := range m[last+1:] {
+			if r.negate {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// matches reports whether the pattern of r matches name or one of the
+// directories it is in.
+func (r orderedRule) matches(name string, isDir bool) bool {
+	for i := range len(name) {
+		if name[i] == '/' && r.m.Match(name[:i], true) {
+			return true
+		}
+	}
+	return r.m.Match(name, isDir)
+}
+
+// gitignoreRule is a single compiled line of a .gitignore file.
+type gitignoreRule struct {
+	segs    []string
+	negate  bool
+	dirOnly bool
+}
+
+// gitignoreMatcher applies .gitignore rules; the last matching rule wins.
+type gitignoreMatcher []gitignoreRule
+
+func newGitignoreMatcher(patterns []string) gitignoreMatcher {
+	var m gitignoreMatcher
+	for _, p := range patterns {
+		p = strings.TrimRight(p, " ")
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+		var r gitignoreRule
+		if strings.HasPrefix(p, "!") {
+			r.negate = true
+			p = p[1:]
+		} else if strings.HasPrefix(p, `\!`) || strings.HasPrefix(p, `\#`) {
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			r.dirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			p = strings.TrimPrefix(p, "/")
+		} else {
+			p = "**/" + p
+		}
+		r.segs = strings.Split(p, "/")
+		m = append(m, r)
+	}
+	return m
+}
+
+// Match reports whether name is ignored. As in git, a path inside an
+// ignored directory is ignored too, and cannot be re-included.
+func (m gitignoreMatcher) Match(name string, isDir bool) bool {
+	segs := strings.Split(cleanName(name), "/")
+	for i := 1; i < len(segs); i++ {
+		if ignored, _ := m.ignored(segs[:i], true); ignored {
+			return true
+		}
+	}
+	ignored, _ := m.ignored(segs, isDir)
+	return ignored
+}
+
+// ignored applies the rules to the path segs. matched reports whether any
+// rule matched, so that callers can fall back to other rule sets.
+func (m gitignoreMatcher) ignored(segs []string, isDir bool) (ignored, matched bool) {
+	for _, r := range m {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(r.segs, segs) {
+			ignored, matched = !r.negate, true
+		}
+	}
+	return ignored, matched
+}
@@ -1,6 +1,11 @@
 package ziplib
 
-import "testing"
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestMatchesAny(t *testing.T) {
 	tests := []struct {
@@ -18,6 +23,8 @@ func TestMatchesAny(t *testing.T) {
 		{"multiple patterns second", "foo.go", []string{"*.txt", "*.go"}, true},
 		{"multiple patterns none", "foo.rs", []string{"*.txt", "*.go"}, false},
 		{"path uses base name", "dir/foo.txt", []string{"*.txt"}, true},
+		{"slash pattern uses full path", "dir/foo.txt", []string{"dir/*.txt"}, true},
+		{"slash pattern other dir", "other/foo.txt", []string{"dir/*.txt"}, false},
 		{"invalid pattern ignored", "foo.txt", []string{"[invalid"}, false},
 		{"question mark", "foo.txt", []string{"fo?.txt"}, true},
 	}
@@ -31,3 +38,143 @@ func TestMatchesAny(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		syntax   MatchSyntax
+		patterns []string
+		input    string
+		isDir    bool
+		want     bool
+	}{
+		{"glob base name", SyntaxGlob, []string{"*.txt"}, "a/b/c.txt", false, true},
+		{"glob path pattern", SyntaxGlob, []string{"a/*.txt"}, "a/c.txt", false, true},
+		{"glob path pattern anchored", SyntaxGlob, []string{"a/*.txt"}, "x/a/c.txt", false, false},
+		{"glob path pattern no deep", SyntaxGlob, []string{"a/*.txt"}, "a/b/c.txt", false, false},
+		{"glob path pattern leading dot slash", SyntaxGlob, []string{"sub/*.txt"}, "./sub/n.txt", false, true},
+		{"glob path pattern dir", SyntaxGlob, []string{"docs/*"}, "docs/img", true, true},
+		{"glob globstar", SyntaxGlob, []string{"a/**/*.txt"}, "a/b/c/d.txt", false, true},
+		{"glob globstar zero dirs", SyntaxGlob, []string{"a/**/*.txt"}, "a/d.txt", false, true},
+		{"glob trailing globstar", SyntaxGlob, []string{"node_modules/**"}, "node_modules/x/y.js", false, true},
+		{"glob trailing globstar matches dir", SyntaxGlob, []string{"node_modules/**"}, "node_modules", true, true},
+		{"glob leading globstar", SyntaxGlob, []string{"**/testdata/*"}, "a/b/testdata/f", false, true},
+		{"glob negated class at any level", SyntaxGlob, []string{"[!a]*.txt"}, "a/b.txt", false, true},
+		{"glob class in path pattern", SyntaxGlob, []string{"v[0-9]/*.go"}, "v2/main.go", false, true},
+		{"glob escaped wildcard", SyntaxGlob, []string{`docs/\*`}, "docs/x", false, false},
+		// Info-ZIP zip -x "dir/*" also matches deeper paths, as its "*"
+		// crosses slashes unless -ws is given; here "dir/**" does that.
+		{"glob star stays in its directory", SyntaxGlob, []string{"dir/*"}, "dir/sub/f", false, false},
+
+		{"doublestar base name", SyntaxDoublestar, []string{"*.txt"}, "a/b/c.txt", false, true},
+		{"doublestar anchored", SyntaxDoublestar, []string{"a/*.txt"}, "a/c.txt", false, true},
+		{"doublestar anchored no deep", SyntaxDoublestar, []string{"a/*.txt"}, "a/b/c.txt", false, false},
+		{"doublestar globstar", SyntaxDoublestar, []string{"a/**/*.txt"}, "a/b/c/d.txt", false, true},
+		{"doublestar globstar zero dirs", SyntaxDoublestar, []string{"a/**/*.txt"}, "a/d.txt", false, true},
+		{"doublestar trailing globstar", SyntaxDoublestar, []string{"vendor/**"}, "vendor/x/y.go", false, true},
+		{"doublestar leading dot slash", SyntaxDoublestar, []string{"sub/*.txt"}, "./sub/n.txt", false, true},
+
+		{"regexp full path", SyntaxRegexp, []string{`^sub/.*\.txt$`}, "sub/n.txt", false, true},
+		{"regexp no match", SyntaxRegexp, []string{`^sub/.*\.txt$`}, "other/n.txt", false, false},
+
+		{"negation reincludes file", SyntaxGlob, []string{"build/**", "!build/notes.txt"}, "build/notes.txt", false, false},
+		{"negation leaves others", SyntaxGlob, []string{"build/**", "!build/notes.txt"}, "build/out.o", false, true},
+		{"negation keeps dir walked", SyntaxGlob, []string{"build/**", "!build/notes.txt"}, "build", true, false},
+		{"negation last rule wins", SyntaxGlob, []string{"!*.log", "*.log"}, "a.log", false, true},
+		{"negation dir pattern covers contents", SyntaxGlob, []string{"tmp", "!keep.txt"}, "tmp/x/a.txt", false, true},
+		{"negation reincludes under dir", SyntaxGlob, []string{"tmp", "!keep.txt"}, "tmp/x/keep.txt", false, false},
+		{"negation later positive dir", SyntaxGlob, []string{"!keep.txt", "tmp"}, "tmp", true, true},
+		{"negation alone matches nothing", SyntaxGlob, []string{"!*.txt"}, "a.txt", false, false},
+		{"escaped negation is literal", SyntaxGlob, []string{`\!x`}, "!x", false, true},
+		{"negation doublestar", SyntaxDoublestar, []string{"**/*.go", "!**/*_test.go"}, "a/b_test.go", false, false},
+		{"negation regexp", SyntaxRegexp, []string{`\.txt$`, `!^keep/`}, "keep/a.txt", false, false},
+
+		{"gitignore base name", SyntaxGitignore, []string{"*.log"}, "a/b/x.log", false, true},
+		{"gitignore negation", SyntaxGitignore, []string{"*.log", "!keep.log"}, "a/keep.log", false, false},
+		{"gitignore last rule wins", SyntaxGitignore, []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"gitignore dir only skips files", SyntaxGitignore, []string{"build/"}, "build", false, false},
+		{"gitignore dir only matches dir", SyntaxGitignore, []string{"build/"}, "build", true, true},
+		{"gitignore contents of dir", SyntaxGitignore, []string{"build/"}, "src/build/out.o", false, true},
+		{"gitignore anchored", SyntaxGitignore, []string{"/root.txt"}, "sub/root.txt", false, false},
+		{"gitignore anchored top", SyntaxGitignore, []string{"/root.txt"}, "root.txt", false, true},
+		{"gitignore no reinclude under dir", SyntaxGitignore, []string{"tmp/", "!tmp/keep"}, "tmp/keep", false, true},
+		{"gitignore comment", SyntaxGitignore, []string{"# *.txt"}, "a.txt", false, false},
+		{"gitignore escaped hash", SyntaxGitignore, []string{`\#notes`}, "#notes", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(tt.syntax, tt.patterns)
+			if err != nil {
+				t.Fatalf("NewMatcher: %v", err)
+			}
+			if got := m.Match(tt.input, tt.isDir); got != tt.want {
+				t.Errorf("%v %v Match(%q, %v) = %v, want %v", tt.syntax, tt.patterns, tt.input, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMatcherInvalidRegexp(t *testing.T) {
+	if _, err := NewMatcher(SyntaxRegexp, []string{"("}); err == nil {
+		t.Fatal("expected error for invalid regexp")
+	}
+}
+
+func TestNewCaseInsensitiveMatcher(t *testing.T) {
+	tests := []struct {
+		syntax   MatchSyntax
+		patterns []string
+		name     string
+	}{
+		{SyntaxGlob, []string{"*.TXT"}, "Docs/README.txt"},
+		{SyntaxGlob, []string{"docs/*"}, "DOCS/Readme.txt"},
+		{SyntaxDoublestar, []string{"**/readme.*"}, "Docs/README.TXT"},
+		{SyntaxRegexp, []string{`^docs/.*\.txt$`}, "DOCS/README.TXT"},
+		{SyntaxGitignore, []string{"Build/"}, "build/out.o"},
+		{SyntaxRegexp, []string{`^DOCS/`, `!\.bin$`}, "docs/a.txt"},
+	}
+	for _, tt := range tests {
+		m, err := NewCaseInsensitiveMatcher(tt.syntax, tt.patterns)
+		if err != nil {
+			t.Fatalf("%v %q: %v", tt.syntax, tt.patterns, err)
+		}
+		if !m.Match(tt.name, false) {
+			t.Errorf("%v %q did not match %q", tt.syntax, tt.patterns, tt.name)
+		}
+		if m.Match("other/file.bin", false) {
+			t.Errorf("%v %q matched other/file.bin", tt.syntax, tt.patterns)
+		}
+	}
+}
+
+func TestParseMatchSyntax(t *testing.T) {
+	for _, s := range []MatchSyntax{SyntaxGlob, SyntaxDoublestar, SyntaxRegexp, SyntaxGitignore} {
+		got, err := ParseMatchSyntax(s.String())
+		if err != nil || got != s {
+			t.Errorf("ParseMatchSyntax(%q) = %v, %v; want %v", s.String(), got, err, s)
+		}
+	}
+	if _, err := ParseMatchSyntax("bogus"); err == nil {
+		t.Error("expected error for unknown syntax")
+	}
+}
+
+func TestUnzipCaseInsensitive(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "x",
+		&zip.FileHeader{Name: "README.TXT", Method: zip.Store},
+		&zip.FileHeader{Name: "Setup.EXE", Method: zip.Store},
+	)
+	dest := t.TempDir()
+	err := Unzip(zipPath, UnzipOptions{OutputDir: dest, FilePatterns: []string{"*.txt"}, CaseInsensitive: true})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "README.TXT")); err != nil {
+		t.Errorf("README.TXT not extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "Setup.EXE")); !os.IsNotExist(err) {
+		t.Errorf("Setup.EXE extracted: %v", err)
+	}
+}
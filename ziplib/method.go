@@ -0,0 +1,372 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	dsbzip2 "github.com/dsnet/compress/bzip2"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Compression method IDs beyond those defined by archive/zip.
+const (
+	methodBzip2 uint16 = 12
+	methodLZMA  uint16 = 14
+	methodXZ    uint16 = 95
+)
+
+// flagLZMAEOS is the general purpose bit flag announcing that LZMA data
+// ends with an end-of-stream marker.
+const flagLZMAEOS = 0x2
+
+// lzmaVersion is the LZMA SDK version recorded in the header of LZMA
+// entries. Readers only check the properties that follow it.
+var lzmaVersion = [2]byte{9, 20}
+
+// Method selects the compression method of entries written by Zip.
+type Method int
+
+// Supported compression methods.
+const (
+	// MethodDeflate compresses entries with deflate (method 8), which
+	// every zip tool can read. It is the default.
+	MethodDeflate Method = iota
+	// MethodStore stores entries uncompressed (method 0).
+	MethodStore
+	// MethodBzip2 compresses entries with bzip2 (method 12), as written by
+	// Info-ZIP zip -Z bzip2.
+	MethodBzip2
+	// MethodLZMA compresses entries with LZMA (method 14), as written by
+	// 7-Zip. The compression level is ignored.
+	MethodLZMA
+	// MethodXZ compresses entries as xz streams (method 95). The
+	// compression level is ignored.
+	MethodXZ
+)
+
+// ParseMethod converts a method name ("deflate", "store", "bzip2", "lzma"
+// or "xz") to a Method.
+func ParseMethod(s string) (Method, error) {
+	switch s {
+	case "deflate", "":
+		return MethodDeflate, nil
+	case "store":
+		return MethodStore, nil
+	case "bzip2":
+		return MethodBzip2, nil
+	case "lzma":
+		return MethodLZMA, nil
+	case "xz":
+		return MethodXZ, nil
+	default:
+		return 0, fmt.Errorf("invalid method %q: must be deflate, store, bzip2, lzma or xz", s)
+	}
+}
+
+// String returns the name of m as accepted by ParseMethod.
+func (m Method) String() string {
+	switch m {
+	case MethodDeflate:
+		return "deflate"
+	case MethodStore:
+		return "store"
+	case MethodBzip2:
+		return "bzip2"
+	case MethodLZMA:
+		return "lzma"
+	case MethodXZ:
+		return "xz"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// setHeader records m as the compression method of h.
+func (m Method) setHeader(h *zip.FileHeader) {
+	switch m {
+	case MethodStore:
+		h.Method = zip.Store
+	case MethodBzip2:
+		h.Method = methodBzip2
+	case MethodLZMA:
+		h.Method = methodLZMA
+		h.Flags |= flagLZMAEOS
+	case MethodXZ:
+		h.Method = methodXZ
+	default:
+		h.Method = zip.Deflate
+	}
+}
+
+// compressors returns the compressors of every supported method at the
+// given level. A level outside 1-9 means each method's default.
+func compressors(level int) map[uint16]zip.Compressor {
+	if level < -1 || level > 9 {
+		level = -1
+	}
+	return map[uint16]zip.Compressor{
+		zip.Store: func(out io.Writer) (io.WriteCloser, error) {
+			return nopWriteCloser{out}, nil
+		},
+		zip.Deflate: func(out io.Writer) (io.WriteCloser, error) {
+			return newFlateWriter(out, level)
+		},
+		methodBzip2: func(out io.Writer) (io.WriteCloser, error) {
+			conf := &dsbzip2.WriterConfig{}
+			if level >= dsbzip2.BestSpeed {
+				conf.Level = level
+			}
+			return dsbzip2.NewWriter(out, conf)
+		},
+		methodLZMA: func(out io.Writer) (io.WriteCloser, error) {
+			return &lazyWriter{open: func() (io.WriteCloser, error) { return newLZMAWriter(out) }}, nil
+		},
+		methodXZ: func(out io.Writer) (io.WriteCloser, error) {
+			return &lazyWriter{open: func() (io.WriteCloser, error) { return xz.NewWriter(out) }}, nil
+		},
+	}
+}
+
+// registerCompressors installs comps on w. If track is non-nil, each
+// compressor is wrapped in an entryCompressor stored in *track when an
+// entry opens it. If seal is non-nil, the compressed output is written
+// through the writer it returns, which is closed after the compressor.
+func registerCompressors(w *zip.Writer, comps map[uint16]zip.Compressor, track **entryCompressor, seal func(io.Writer) io.WriteCloser) {
+	register := func(method uint16, comp zip.Compressor) {
+		if track != nil {
+			inner := comp
+			comp = func(out io.Writer) (io.WriteCloser, error) {
+				cw := &countingWriter{w: out}
+				wc, err := inner(cw)
+				if err != nil {
+					return nil, err
+				}
+				*track = &entryCompressor{WriteCloser: wc, out: cw}
+				return *track, nil
+			}
+		}
+		if seal != nil {
+			inner := comp
+			comp = func(out io.Writer) (io.WriteCloser, error) {
+				sealed := seal(out)
+				wc, err := inner(sealed)
+				if err != nil {
+					return nil, err
+				}
+				return sealedCompressor{wc, sealed}, nil
+			}
+		}
+		w.RegisterCompressor(method, comp)
+	}
+	for method, comp := range comps {
+		register(method, comp)
+	}
+}
+
+// entryCompressor counts the compressed output of one entry, and lets
+// writeFile close the compressor to learn its final size before
+// archive/zip closes it when the next entry starts. Closing it again does
+// nothing.
+type entryCompressor struct {
+	io.WriteCloser
+	out    *countingWriter
+	closed bool
+	err    error
+}
+
+func (c *entryCompressor) Close() error {
+	if !c.closed {
+		c.closed = true
+		c.err = c.WriteCloser.Close()
+	}
+	return c.err
+}
+
+// nopWriteCloser is the compressor of stored entries.
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// sealedCompressor closes a compressor, then the writer its output goes
+// through.
+type sealedCompressor struct {
+	io.WriteCloser
+	sealed io.Closer
+}
+
+func (c sealedCompressor) Close() error {
+	return errors.Join(c.WriteCloser.Close(), c.sealed.Close())
+}
+
+// verb returns the word zip uses for entries compressed with m, as in
+// "deflated 54%".
+func (m Method) verb() string {
+	switch m {
+	case MethodStore:
+		return "stored"
+	case MethodBzip2:
+		return "bzipped"
+	case MethodDeflate:
+		return "deflated"
+	default:
+		return "compressed"
+	}
+}
+
+// savedPercent returns how much smaller out is than in, as a whole
+// percentage, or 0 if it is not smaller.
+func savedPercent(in, out uint64) uint64 {
+	if in == 0 || out >= in {
+		return 0
+	}
+	return 100 - out*100/in
+}
+
+// decompressors are the decompressors for the methods that archive/zip
+// does not support itself.
+var decompressors = map[uint16]zip.Decompressor{
+	methodBzip2: func(in io.Reader) io.ReadCloser {
+		return io.NopCloser(bzip2.NewReader(in))
+	},
+	methodLZMA: newLZMAReader,
+	methodXZ: func(in io.Reader) io.ReadCloser {
+		return &lazyReader{open: func() (io.Reader, error) { return xz.NewReader(in) }}
+	},
+}
+
+// registerDecompressors installs decompressors on r.
+func registerDecompressors(r *zip.Reader) {
+	for method, d := range decompressors {
+		r.RegisterDecompressor(method, d)
+	}
+}
+
+// canDecompress reports whether entries stored with method can be read:
+// archive/zip reads stored and deflated entries, and decompressors the
+// others.
+func canDecompress(method uint16) bool {
+	_, ok := decompressors[method]
+	return ok || method == zip.Store || method == zip.Deflate
+}
+
+// newLZMAWriter returns a writer producing LZMA data in the layout used by
+// zip: a 4-byte header holding the SDK version and the size of the
+// properties, then the 5-byte properties and the raw stream, which always
+// ends with an end-of-stream marker.
+func newLZMAWriter(out io.Writer) (io.WriteCloser, error) {
+	hw := &lzmaHeaderWriter{w: out}
+	lw, err := lzma.WriterConfig{EOSMarker: true}.NewWriter(hw)
+	if err != nil {
+		return nil, fmt.Errorf("lzma: %w", err)
+	}
+	return lw, nil
+}
+
+// lzmaHeaderWriter replaces the 13-byte header of a classic LZMA stream,
+// which ends with the uncompressed size, by the zip LZMA header.
+type lzmaHeaderWriter struct {
+	w      io.Writer
+	header []byte
+}
+
+func (hw *lzmaHeaderWriter) Write(p []byte) (int, error) {
+	n := 0
+	if len(hw.header) < lzma.HeaderLen {
+		k := min(lzma.HeaderLen-len(hw.header), len(p))
+		hw.header = append(hw.header, p[:k]...)
+		p, n = p[k:], k
+		if len(hw.header) < lzma.HeaderLen {
+			return n, nil
+		}
+		zipHeader := []byte{lzmaVersion[0], lzmaVersion[1], 5, 0}
+		if _, err := hw.w.Write(append(zipHeader, hw.header[:5]...)); err != nil {
+			return n, fmt.Errorf("write lzma header: %w", err)
+		}
+	}
+	m, err := hw.w.Write(p)
+	if err != nil {
+		return n + m, fmt.Errorf("write lzma: %w", err)
+	}
+	return n + m, nil
+}
+
+// newLZMAReader decodes LZMA data in the zip layout written by
+// newLZMAWriter. The stream may or may not end with an end-of-stream
+// marker; archive/zip checks the uncompressed size and CRC either way.
+func newLZMAReader(in io.Reader) io.ReadCloser {
+	return &lazyReader{open: func() (io.Reader, error) {
+		var h [4]byte
+		if _, err := io.ReadFull(in, h[:]); err != nil {
+			return nil, fmt.Errorf("lzma header: %w", err)
+		}
+		props := make([]byte, binary.LittleEndian.Uint16(h[2:4]))
+		if _, err := io.ReadFull(in, props); err != nil || len(props) != 5 {
+			return nil, errors.New("lzma header: bad properties")
+		}
+		// Rebuild a classic header with an unknown uncompressed size.
+		classic := append(props, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+		return lzma.NewReader(io.MultiReader(bytes.NewReader(classic), in)) //nolint:wrapcheck // Reported by archive/zip.
+	}}
+}
+
+// lazyReader defers creating a decoder until the first read, since
+// decompressors registered with archive/zip cannot return an error.
+type lazyReader struct {
+	open func() (io.Reader, error)
+	r    io.Reader
+	err  error
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+	if l.r == nil && l.err == nil {
+		l.r, l.err = l.open()
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+	return l.r.Read(p) //nolint:wrapcheck // Reported by archive/zip.
+}
+
+func (l *lazyReader) Close() error {
+	return nil
+}
+
+// lazyWriter defers creating an encoder until the first write or close.
+// archive/zip creates the compressor before writing the local file header,
+// so encoders that emit a header on creation must be delayed.
+type lazyWriter struct {
+	open func() (io.WriteCloser, error)
+	w    io.WriteCloser
+}
+
+func (l *lazyWriter) init() error {
+	if l.w != nil {
+		return nil
+	}
+	w, err := l.open()
+	if err != nil {
+		return fmt.Errorf("create encoder: %w", err)
+	}
+	l.w = w
+	return nil
+}
+
+func (l *lazyWriter) Write(p []byte) (int, error) {
+	if err := l.init(); err != nil {
+		return 0, err
+	}
+	return l.w.Write(p) //nolint:wrapcheck // Reported by archive/zip.
+}
+
+func (l *lazyWriter) Close() error {
+	if err := l.init(); err != nil {
+		return err
+	}
+	return l.w.Close() //nolint:wrapcheck // Reported by archive/zip.
+}
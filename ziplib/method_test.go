@@ -0,0 +1,131 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Method
+		wantErr bool
+	}{
+		{"", MethodDeflate, false},
+		{"deflate", MethodDeflate, false},
+		{"store", MethodStore, false},
+		{"bzip2", MethodBzip2, false},
+		{"lzma", MethodLZMA, false},
+		{"xz", MethodXZ, false},
+		{"lzw", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMethod(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMethod(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestZipUnzipMethods(t *testing.T) {
+	tests := []struct {
+		method Method
+		id     uint16
+	}{
+		{MethodBzip2, methodBzip2},
+		{MethodLZMA, methodLZMA},
+		{MethodXZ, methodXZ},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.id), func(t *testing.T) {
+			src := t.TempDir()
+			content := strings.Repeat("method round trip\n", 500)
+			writeFile(t, filepath.Join(src, "data.txt"), content)
+			zipPath := filepath.Join(t.TempDir(), "method.zip")
+
+			err := Zip(zipPath, []string{filepath.Join(src, "data.txt")}, ZipOptions{Method: tt.method, CompressionLevel: 9})
+			if err != nil {
+				t.Fatalf("Zip: %v", err)
+			}
+
+			r, err := zip.OpenReader(zipPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			method := r.File[0].Method
+			r.Close()
+			if method != tt.id {
+				t.Fatalf("method = %d, want %d", method, tt.id)
+			}
+
+			extractDir := t.TempDir()
+			if err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir}); err != nil {
+				t.Fatalf("Unzip: %v", err)
+			}
+			if got := readFile(t, filepath.Join(extractDir, src, "data.txt")); got != content {
+				t.Errorf("content mismatch after round trip")
+			}
+			if err := Verify(zipPath, VerifyOptions{}); err != nil {
+				t.Errorf("Verify: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnzipLZMAWithoutEOSMarker(t *testing.T) {
+	content := []byte(strings.Repeat("no end marker ", 300))
+	var classic bytes.Buffer
+	lw, err := lzma.WriterConfig{}.NewWriter(&classic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Convert the classic 13-byte header to the zip layout.
+	data := append([]byte{9, 20, 5, 0}, classic.Bytes()[:5]...)
+	data = append(data, classic.Bytes()[lzma.HeaderLen:]...)
+
+	zipPath := filepath.Join(t.TempDir(), "lzma.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	fw, err := w.CreateRaw(&zip.FileHeader{
+		Name:               "plain.txt",
+		Method:             methodLZMA,
+		CRC32:              crc32.ChecksumIEEE(content),
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(len(content)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	extractDir := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if got := readFile(t, filepath.Join(extractDir, "plain.txt")); got != string(content) {
+		t.Error("content mismatch")
+	}
+}
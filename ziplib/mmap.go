@@ -0,0 +1,62 @@
+package ziplib
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// archiveFile is an archive file opened for random access, either through
+// ordinary reads or through a read-only memory mapping.
+type archiveFile struct {
+	io.ReaderAt
+	size  int64
+	close func() error
+}
+
+func (a *archiveFile) Close() error {
+	return a.close()
+}
+
+// openArchiveFile opens the archive at path. With mmap set, the file is
+// mapped into memory so that reads are plain copies instead of system
+// calls. Mapping needs a 64-bit platform with mmap; elsewhere, and for
+// empty files, the file is read normally.
+func openArchiveFile(path string, mmap bool) (*archiveFile, error) {
+	f, err := os.Open(path) //nolint:gosec // Archive path is chosen by the caller.
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	size := fi.Size()
+	if mmap && strconv.IntSize == 64 && size > 0 && size <= math.MaxInt {
+		if data, err := mapFile(f, int(size)); err == nil {
+			f.Close()
+			return &archiveFile{ReaderAt: mappedReader(data), size: size, close: func() error { return unmapFile(data) }}, nil
+		}
+	}
+	return &archiveFile{ReaderAt: f, size: size, close: f.Close}, nil
+}
+
+// mappedReader reads from a memory-mapped file.
+type mappedReader []byte
+
+func (m mappedReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("read mapped archive: negative offset %d", off)
+	}
+	if off >= int64(len(m)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package ziplib
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile reports that memory mapping is not supported, so archives are
+// read normally.
+func mapFile(*os.File, int) ([]byte, error) {
+	return nil, errors.New("memory mapping is not supported on this platform")
+}
+
+// unmapFile is never called, as mapFile never succeeds.
+func unmapFile([]byte) error {
+	return nil
+}
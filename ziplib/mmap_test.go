@@ -0,0 +1,49 @@
+package ziplib
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestMappedReaderReadAt(t *testing.T) {
+	m := mappedReader("hello world")
+	buf := make([]byte, 5)
+	if n, err := m.ReadAt(buf, 6); n != 5 || err != nil || string(buf) != "world" {
+		t.Errorf("ReadAt(6) = %d, %v, %q", n, err, buf)
+	}
+	if n, err := m.ReadAt(buf, 8); n != 3 || !errors.Is(err, io.EOF) || string(buf[:n]) != "rld" {
+		t.Errorf("ReadAt(8) = %d, %v, want 3 bytes and EOF", n, err)
+	}
+	if _, err := m.ReadAt(buf, 11); !errors.Is(err, io.EOF) {
+		t.Errorf("ReadAt(11) error = %v, want EOF", err)
+	}
+	if _, err := m.ReadAt(buf, -1); err == nil {
+		t.Error("expected an error for a negative offset")
+	}
+}
+
+func TestUnzipMmap(t *testing.T) {
+	dir := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "test.zip")
+	if err := Zip(zipPath, []string{dir}, ZipOptions{Recursive: true, CompressionLevel: -1}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	entries, err := ListWithOptions(zipPath, ListOptions{Mmap: true})
+	if err != nil {
+		t.Fatalf("ListWithOptions: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("listed %d entries, want 3", len(entries))
+	}
+
+	dest := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: dest, Mmap: true, JunkPaths: true, Concurrency: 4}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dest, "nested.txt")); got != "nested content\n" {
+		t.Errorf("nested.txt = %q", got)
+	}
+}
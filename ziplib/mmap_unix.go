@@ -0,0 +1,19 @@
+//go:build unix
+
+package ziplib
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapFile maps the first size bytes of f into memory for reading.
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return unix.Mmap(int(f.Fd()), 0, size, unix.PROT_READ, unix.MAP_SHARED) //nolint:wrapcheck // Callers fall back to reads.
+}
+
+// unmapFile releases a mapping made by mapFile.
+func unmapFile(data []byte) error {
+	return unix.Munmap(data) //nolint:wrapcheck // Unmapping a valid mapping does not fail.
+}
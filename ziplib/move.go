@@ -0,0 +1,39 @@
+package ziplib
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// added records a file written to the archive, for Move to delete.
+func (a *archiver) added(path string) {
+	if a.opts.Move {
+		a.moved = append(a.moved, path)
+	}
+}
+
+// addedDir records a directory walked for files, for Move to delete if
+// it ends up empty.
+func (a *archiver) addedDir(path string) {
+	if a.opts.Move {
+		a.dirs = append(a.dirs, path)
+	}
+}
+
+// removeSources deletes the files added to the archive and then, deepest
+// first, the walked directories they leave empty, like zip -m. It is only
+// called once the archive is complete, so a failed run deletes nothing.
+// Directories still holding excluded or skipped files are kept.
+func (a *archiver) removeSources() error {
+	var errs []error
+	for _, p := range a.moved {
+		if err := os.Remove(p); err != nil {
+			errs = append(errs, fmt.Errorf("move: %w", err))
+		}
+	}
+	for i := len(a.dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(a.dirs[i]) // Fails for directories that are not empty.
+	}
+	return errors.Join(errs...)
+}
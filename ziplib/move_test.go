@@ -0,0 +1,54 @@
+package ziplib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipMove(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	for _, d := range []string{filepath.Join(src, "dir", "empty"), filepath.Join(src, "keep")} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, filepath.Join(src, "dir", "b.txt"), "b")
+	writeFile(t, filepath.Join(src, "keep", "c.txt"), "c")
+	writeFile(t, filepath.Join(src, "keep", "d.log"), "d")
+	t.Chdir(src)
+
+	zipPath := filepath.Join(t.TempDir(), "moved.zip")
+	opts := ZipOptions{Recursive: true, Move: true, ExcludePatterns: []string{"*.log"}}
+	if err := Zip(zipPath, []string{"a.txt", "dir", "keep"}, opts); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	for _, gone := range []string{"a.txt", "dir", "keep/c.txt"} {
+		if _, err := os.Lstat(gone); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", gone, err)
+		}
+	}
+	// The excluded file was not archived, so it and its directory stay.
+	if got := readFile(t, filepath.Join("keep", "d.log")); got != "d" {
+		t.Errorf("keep/d.log = %q", got)
+	}
+	entries, err := List(zipPath)
+	if err != nil || len(entries) != 3 {
+		t.Errorf("List = %v, %v, want 3 entries", entries, err)
+	}
+}
+
+func TestZipMoveFailureKeepsSources(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a")
+	t.Chdir(src)
+
+	zipPath := filepath.Join(t.TempDir(), "moved.zip")
+	if err := Zip(zipPath, []string{"a.txt", "missing.txt"}, ZipOptions{Move: true}); err == nil {
+		t.Fatal("expected an error for a missing input")
+	}
+	if got := readFile(t, "a.txt"); got != "a" {
+		t.Errorf("a.txt = %q, want it kept after a failed run", got)
+	}
+}
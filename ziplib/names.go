@@ -0,0 +1,77 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// fsPath returns the filesystem path of the input path, which is relative
+// to BaseDir if one is set.
+func (a *archiver) fsPath(path string) string {
+	if a.base == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(a.base, path)
+}
+
+// relPath returns the filesystem path p relative to BaseDir, the form
+// entry names and patterns use. Paths outside BaseDir, or all paths if it
+// is not set, are returned unchanged.
+func (a *archiver) relPath(p string) string {
+	if a.base == "" {
+		return p
+	}
+	rel, err := filepath.Rel(a.base, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+	return rel
+}
+
+// entryName returns the name under which the file at path is stored. It
+// reports false if NameTransform drops the file.
+func (a *archiver) entryName(path string) (string, bool) {
+	name := filepath.ToSlash(a.relPath(path))
+	if a.opts.JunkPaths {
+		name = filepath.Base(path)
+	}
+	if a.opts.Prefix != "" {
+		name = a.opts.Prefix + strings.TrimPrefix(name, "/")
+	}
+	if a.opts.NameTransform != nil {
+		return a.opts.NameTransform(name)
+	}
+	return name, true
+}
+
+// checkJunked reports an error if another file was already stored as the
+// junked name of path, since zip -j cannot repeat names either.
+func (a *archiver) checkJunked(path, name string) error {
+	if !a.opts.JunkPaths {
+		return nil
+	}
+	if a.junked == nil {
+		a.junked = make(map[string]string)
+	}
+	if prev, ok := a.junked[name]; ok {
+		return fmt.Errorf("cannot repeat names in zip file: %s and %s are both stored as %s", prev, path, name)
+	}
+	a.junked[name] = path
+	return nil
+}
+
+// rename records the name f is extracted under, after StripComponents and
+// NameTransform, and reports false if the entry is not extracted at all.
+func (x *extractor) rename(f *zip.File) bool {
+	name, ok := stripComponents(f.Name, x.opts.StripComponents)
+	if ok && x.opts.NameTransform != nil {
+		name, ok = x.opts.NameTransform(name)
+	}
+	if !ok || name == "" {
+		return false
+	}
+	x.names[f] = name
+	return true
+}
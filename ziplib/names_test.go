@@ -0,0 +1,151 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// zipNames zips files with opts from the current directory and returns
+// the entry names of the result.
+func zipNames(t *testing.T, files []string, opts ZipOptions) []string {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	if err := Zip(zipPath, files, opts); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name
+	}
+	return names
+}
+
+func TestZipPrefix(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("src", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "README", "readme")
+	writeFile(t, "src/main.go", "package main")
+
+	got := zipNames(t, []string{"README", "src"}, ZipOptions{
+		Recursive:   true,
+		SortEntries: true,
+		Prefix:      "myproject-1.2.0/",
+	})
+	want := []string{"myproject-1.2.0/README", "myproject-1.2.0/src/main.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+}
+
+func TestZipBaseDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src", "gen"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(root, "src", "main.go"), "package main")
+	writeFile(t, filepath.Join(root, "src", "gen", "out.go"), "package gen")
+	writeFile(t, filepath.Join(root, "LICENSE"), "license")
+	t.Chdir(t.TempDir())
+
+	got := zipNames(t, []string{"src", filepath.Join(root, "LICENSE")}, ZipOptions{
+		Recursive:       true,
+		SortEntries:     true,
+		BaseDir:         root,
+		ExcludePatterns: []string{"src/gen"},
+	})
+	want := []string{"LICENSE", "src/main.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+}
+
+func TestZipNameTransform(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFile(t, "README.TXT", "readme")
+	writeFile(t, "scratch.tmp", "tmp")
+
+	var res Result
+	got := zipNames(t, []string{"README.TXT", "scratch.tmp"}, ZipOptions{
+		Result: &res,
+		NameTransform: func(name string) (string, bool) {
+			if strings.HasSuffix(name, ".tmp") {
+				return "", false
+			}
+			return strings.ToLower(name), true
+		},
+	})
+	if want := []string{"readme.txt"}; !slices.Equal(got, want) {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+	if res.Skipped != 1 {
+		t.Errorf("skipped = %d, want 1", res.Skipped)
+	}
+}
+
+func TestUnzipNameTransform(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "in.zip")
+	writeTestZip(t, zipPath, "data",
+		&zip.FileHeader{Name: "old/a.htm", Method: zip.Store},
+		&zip.FileHeader{Name: "old/b.bak", Method: zip.Store},
+		&zip.FileHeader{Name: "old/c.htm", Method: zip.Store},
+	)
+	extractDir := t.TempDir()
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir: extractDir,
+		NameTransform: func(name string) (string, bool) {
+			if strings.HasSuffix(name, ".bak") {
+				return "", false
+			}
+			if name == "old/c.htm" {
+				return "../escape.html", true
+			}
+			return "new/" + strings.TrimSuffix(strings.TrimPrefix(name, "old/"), ".htm") + ".html", true
+		},
+		OnError: func(string, error) ErrorAction { return ErrorSkip },
+	})
+	var extractErr *ExtractError
+	if !errors.As(err, &extractErr) || len(extractErr.Failures) != 1 {
+		t.Fatalf("Unzip error = %v, want the escaping name to fail", err)
+	}
+	if got := readFile(t, filepath.Join(extractDir, "new", "a.html")); got != "data" {
+		t.Errorf("new/a.html = %q, want %q", got, "data")
+	}
+	for _, name := range []string{"old", "new/b.html"} {
+		if _, err := os.Stat(filepath.Join(extractDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s exists: %v", name, err)
+		}
+	}
+}
+
+func TestZipJunkPaths(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"a", "b"} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, "a/one.txt", "1")
+	writeFile(t, "b/two.txt", "2")
+	writeFile(t, "b/one.txt", "other 1")
+
+	got := zipNames(t, []string{"a/one.txt", "b/two.txt"}, ZipOptions{JunkPaths: true})
+	if want := []string{"one.txt", "two.txt"}; !slices.Equal(got, want) {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+
+	err := Zip(filepath.Join(t.TempDir(), "dup.zip"), []string{"a", "b"}, ZipOptions{Recursive: true, JunkPaths: true})
+	if err == nil || !strings.Contains(err.Error(), "cannot repeat names") {
+		t.Errorf("Zip of duplicate base names = %v, want a repeated name error", err)
+	}
+}
@@ -0,0 +1,103 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrorAction tells Unzip how to proceed after an entry fails to extract.
+type ErrorAction int
+
+const (
+	// ErrorAbort stops extraction and returns the error. It is the
+	// behavior when UnzipOptions.OnError is nil.
+	ErrorAbort ErrorAction = iota
+	// ErrorSkip records the failure and continues with the next entry.
+	// Unzip returns an *ExtractError listing the skipped entries.
+	ErrorSkip
+	// ErrorRetry extracts the entry again.
+	ErrorRetry
+)
+
+// EntryError is the failure of a single entry.
+type EntryError struct {
+	// Entry is the entry name as stored in the archive.
+	Entry string
+	Err   error
+}
+
+func (e EntryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e EntryError) Unwrap() error {
+	return e.Err
+}
+
+// ExtractError is returned by Unzip when entries failed and were skipped
+// at the request of UnzipOptions.OnError. All other entries are extracted
+// before it is returned. errors.As finds errors of individual entries,
+// such as a *CRCError.
+type ExtractError struct {
+	Failures []EntryError
+}
+
+func (e *ExtractError) Error() string {
+	msgs := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		msgs[i] = f.Error()
+	}
+	return fmt.Sprintf("%d entries failed: %s", len(e.Failures), strings.Join(msgs, "; "))
+}
+
+func (e *ExtractError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// extractWithPolicy extracts f, consulting OnError on failure. It returns
+// nil if the entry was extracted or skipped, and the error if extraction
+// must stop.
+func (x *extractor) extractWithPolicy(f *zip.File) error {
+	destPath, _ := x.destination(f) // Errors are reported by extractEntry.
+	ev := fileEvent(f, destPath)
+	x.hooks.started(ev)
+	start := time.Now()
+	for {
+		err := x.extractEntry(f)
+		if err == nil {
+			x.record(f)
+			x.hooks.finished(ev, start, nil)
+			return nil
+		}
+		var skip entrySkipped
+		if errors.As(err, &skip) {
+			x.skipped(f, destPath, string(skip))
+			return nil
+		}
+		action := ErrorAbort
+		if x.opts.OnError != nil {
+			action = x.opts.OnError(f.Name, err)
+		}
+		switch action {
+		case ErrorRetry:
+			continue
+		case ErrorSkip:
+			x.mu.Lock()
+			x.failures = append(x.failures, EntryError{Entry: f.Name, Err: err})
+			x.result.Skipped++
+			x.mu.Unlock()
+			x.hooks.finished(ev, start, err)
+			return nil
+		default:
+			x.hooks.finished(ev, start, err)
+			return err
+		}
+	}
+}
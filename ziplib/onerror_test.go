@@ -0,0 +1,80 @@
+package ziplib
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnzipOnError(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "onerror.zip")
+	t.Chdir(src)
+	if err := Zip(zipPath, []string{"."}, ZipOptions{Recursive: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		dest := t.TempDir()
+		writeFile(t, filepath.Join(dest, "foo.go"), "existing")
+		var res Result
+		err := Unzip(zipPath, UnzipOptions{
+			OutputDir: dest,
+			Result:    &res,
+			OnError:   func(string, error) ErrorAction { return ErrorSkip },
+		})
+		var extractErr *ExtractError
+		if !errors.As(err, &extractErr) {
+			t.Fatalf("Unzip = %v, want *ExtractError", err)
+		}
+		if len(extractErr.Failures) != 1 || extractErr.Failures[0].Entry != "foo.go" {
+			t.Errorf("failures = %+v, want foo.go only", extractErr.Failures)
+		}
+		if got := readFile(t, filepath.Join(dest, "sub", "nested.txt")); got != "nested content\n" {
+			t.Errorf("later entries not extracted: nested.txt = %q", got)
+		}
+		if res.Entries != 2 || res.Skipped != 1 {
+			t.Errorf("result = %+v, want 2 entries and 1 skipped", res)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		dest := t.TempDir()
+		conflict := filepath.Join(dest, "foo.go")
+		writeFile(t, conflict, "existing")
+		calls := 0
+		err := Unzip(zipPath, UnzipOptions{
+			OutputDir: dest,
+			OnError: func(string, error) ErrorAction {
+				calls++
+				if err := os.Remove(conflict); err != nil {
+					return ErrorAbort
+				}
+				return ErrorRetry
+			},
+		})
+		if err != nil {
+			t.Fatalf("Unzip: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("OnError called %d times, want 1", calls)
+		}
+		if got := readFile(t, conflict); got != "package foo\n" {
+			t.Errorf("foo.go = %q after retry", got)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		dest := t.TempDir()
+		writeFile(t, filepath.Join(dest, "foo.go"), "existing")
+		err := Unzip(zipPath, UnzipOptions{
+			OutputDir: dest,
+			OnError:   func(string, error) ErrorAction { return ErrorAbort },
+		})
+		var extractErr *ExtractError
+		if err == nil || errors.As(err, &extractErr) {
+			t.Errorf("Unzip = %v, want the entry's own error", err)
+		}
+	})
+}
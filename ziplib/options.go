@@ -3,6 +3,8 @@ package ziplib
 
 import (
 	"io"
+	"log/slog"
+	"os"
 	"time"
 )
 
@@ -13,8 +15,183 @@ type ZipOptions struct {
 	// CompressionLevel sets the flate compression level (0-9).
 	// -1 means default compression.
 	CompressionLevel int
-	// ExcludePatterns is a list of glob patterns to exclude from the archive.
+	// LevelOverrides sets the compression level of files matching a
+	// pattern, interpreted with MatchSyntax. The first matching override
+	// wins; other files use CompressionLevel.
+	LevelOverrides []LevelOverride
+	// Method is the compression method of entries. Entries whose level is
+	// 0 are always stored uncompressed.
+	Method Method
+	// AutoStore stores a file uncompressed when compressing its leading
+	// 32 KiB saves almost nothing, as for photos, video and archives, like
+	// zip does. Archives of such files then build faster and are no
+	// larger than their input.
+	AutoStore bool
+	// BaseDir, if set, is the directory relative input paths are resolved
+	// against, like tar -C. Entry names and patterns use paths relative to
+	// it, including for absolute inputs inside it, so the archive does not
+	// depend on the working directory.
+	BaseDir string
+	// JunkPaths stores files by their base name only, like zip -j. Zip
+	// fails if two files would be stored under the same name.
+	JunkPaths bool
+	// Prefix is prepended to every entry name, e.g. "myproject-1.2.0/" to
+	// place all entries under a versioned top directory. A leading slash
+	// of the input path is dropped first. Patterns still match the paths
+	// of the input files.
+	Prefix string
+	// NameTransform, if set, rewrites each entry name after BaseDir,
+	// JunkPaths and Prefix are applied. Returning false leaves the file out of the
+	// archive; it is counted as skipped.
+	NameTransform func(name string) (string, bool)
+	// ExcludePatterns is a list of patterns to exclude from the archive.
 	ExcludePatterns []string
+	// IncludePatterns, if non-empty, limits the archive to files matching
+	// at least one pattern. Directories are still traversed, and
+	// ExcludePatterns take precedence.
+	IncludePatterns []string
+	// ExcludeFrom and IncludeFrom name files holding further exclude and
+	// include patterns, one per line; see ReadPatternFile.
+	ExcludeFrom []string
+	IncludeFrom []string
+	// UseIgnoreFiles makes recursive walks skip paths ignored by the
+	// .gitignore and .zipignore files of the directories walked. Rules
+	// apply to the directory holding the file and everything below it.
+	UseIgnoreFiles bool
+	// ExcludeMacMetadata leaves out macOS metadata that is not user
+	// content, as reported by IsMacMetadata: __MACOSX directories,
+	// .DS_Store files and "._" AppleDouble files. They are counted as
+	// skipped.
+	ExcludeMacMetadata bool
+	// ModifiedAfter, if non-zero, leaves out files modified before this
+	// time, like zip -t.
+	ModifiedAfter time.Time
+	// ModifiedBefore, if non-zero, leaves out files modified at or after
+	// this time, like zip -tt.
+	ModifiedBefore time.Time
+	// MinSize leaves out files smaller than this many bytes; 1 skips
+	// empty files.
+	MinSize int64
+	// MaxSize, if positive, leaves out files larger than this many bytes.
+	MaxSize int64
+	// StoreSpecialFiles stores FIFOs, sockets and device nodes as empty
+	// entries recording their Unix mode. By default they are skipped with
+	// a warning, as reading them could block or never end.
+	StoreSpecialFiles bool
+	// MatchSyntax selects how patterns are interpreted. Defaults to
+	// SyntaxGlob, which matches shell globs against the whole path if they
+	// contain a slash, or against the base name at any level otherwise.
+	MatchSyntax MatchSyntax
+	// NameEncoding writes non-ASCII entry names in a legacy code page such
+	// as "cp949" or "cp437" instead of UTF-8, for consumers that cannot read
+	// UTF-8 names. The UTF-8 name is kept in a Unicode Path extra field.
+	// Empty means names are written as UTF-8.
+	NameEncoding string
+	// ExtendedTimestamps also stores the access time, and the creation time
+	// where the system records one, in the local copy of the extended
+	// timestamp extra field. The modification time is always stored.
+	ExtendedTimestamps bool
+	// ConvertLineEndings converts the line endings of files that look like
+	// text, judged from their first 4 KiB, like zip -l and -ll. Converted
+	// entries are marked as text in their internal attributes. Their
+	// sizes, checksums and manifest records are those of the converted
+	// contents.
+	ConvertLineEndings LineEnding
+	// StripExtras omits the extra fields Zip would add for each file, like
+	// zip -X: the extended timestamp and the Unix UID and GID. Only the
+	// MS-DOS modification time, in local time to two-second precision, is
+	// kept. It overrides ExtendedTimestamps. A Unicode Path field needed
+	// for NameEncoding is still written.
+	StripExtras bool
+	// Result, if non-nil, is filled with a summary of the call.
+	Result *Result
+	// SortEntries writes entries sorted lexicographically by slash-separated
+	// name rather than in the order the inputs and directory walks yield
+	// them. Files are then written only after all inputs are walked.
+	SortEntries bool
+	// Deterministic makes the archive depend only on the names and
+	// contents of the input files: entries are sorted by name, their
+	// timestamps are set to DeterministicTime, extra fields are omitted,
+	// and modes are normalized to 0644 or 0755.
+	Deterministic bool
+	// DeterministicTime is the modification time recorded for every entry
+	// in Deterministic mode. Zero means 1980-01-01, the earliest MS-DOS time.
+	DeterministicTime time.Time
+	// DryRun reports the entries that would be added, with their sizes,
+	// without creating or modifying the archive.
+	DryRun bool
+	// Overwrite lets Zip replace an existing archive at zipPath. Without
+	// it Zip refuses to touch an existing file. Either way the archive is
+	// only replaced once the new one is complete.
+	Overwrite bool
+	// Password, if set, encrypts the data of every file entry with the
+	// traditional PKWARE scheme, like zip -e. Names and other metadata
+	// stay readable. The scheme is weak, and only protects against casual
+	// access; it is what every unzip can decrypt. Encrypted archives are
+	// not reproducible, even in Deterministic mode.
+	Password string
+	// Comment is stored as the archive comment, which unzip prints before
+	// extracting. Grow keeps the comment of the existing archive unless
+	// Comment is set.
+	Comment string
+	// Manifest, if non-nil, is filled with the size, modification time
+	// and SHA-256 of every file of the backup, which is also stored in
+	// the archive as the ManifestName entry. Saved with WriteManifest, it
+	// is the BaseManifest of the next incremental backup.
+	Manifest *Manifest
+	// BaseManifest, if non-nil, makes the archive an incremental backup
+	// on top of the one it describes: files whose size and modification
+	// time it records unchanged are left out, counted as skipped, and its
+	// files that are not selected any more are listed as deleted. The
+	// archive always stores its manifest, so that Restore can apply a
+	// chain of such archives in order.
+	BaseManifest *Manifest
+	// Deduplicate stores the data of files with identical contents only
+	// once: later copies get a central directory record pointing at the
+	// data of the first, with their own name, times and attributes. Such
+	// overlapping entries are valid, and archive/zip and gounzip read them,
+	// but Info-ZIP unzip 6.0 with its zip bomb check, as shipped by most
+	// distributions, rejects the archive, and unzip -t reports
+	// "overlapped components". Zip warns once it writes the first one.
+	Deduplicate bool
+	// Move deletes each file added to the archive, and the directories
+	// walked that are left empty, once the archive is completely written,
+	// like zip -m. Nothing is deleted if Zip fails.
+	Move bool
+	// LatestTime sets the modification time of the archive to that of its
+	// newest entry once Zip or Grow is done, like zip -o. See
+	// SetLatestTime.
+	LatestTime bool
+	// Concurrency is the number of files compressed at once. Values below
+	// two compress files one at a time. Either way entries are written in
+	// the same order and, unless encrypted, to the same bytes; hooks are
+	// called from the calling goroutine, except OnProgress. Files over
+	// 8 MiB, and all files with Deduplicate, are compressed one at a time.
+	Concurrency int
+	// RateLimit caps how many bytes of archive are written per second,
+	// so backups on busy hosts leave disk and network bandwidth for
+	// other work. Zero means unlimited.
+	RateLimit int64
+	// OnEntryStart and OnEntryDone, if set, are called before and after
+	// each file is added; OnEntryDone carries the duration and any error.
+	// OnSkip is called for each path left out of the archive, with the
+	// reason. None are called for entries planned in a dry run.
+	OnEntryStart func(EntryEvent)
+	OnEntryDone  func(EntryEvent)
+	OnSkip       func(EntryEvent)
+	// OnProgress, if set, is called as file contents are read into the
+	// archive, with the bytes read so far. Its Total is zero; a dry run
+	// with the same options reports the total in Result.UncompressedBytes.
+	OnProgress func(Progress)
+	// Verbosity selects how much is reported about each entry, to Output
+	// or Logger. VerbosityQuiet leaves only warnings, and VerbositySilent
+	// nothing.
+	Verbosity Verbosity
+	// Logger, if non-nil, receives a structured record for each entry
+	// instead of the text status messages written to Output. The message
+	// is the action, such as "adding", and attributes carry the entry
+	// name, size, compressed size, method, duration and other details.
+	Logger *slog.Logger
 	// Output is where status messages are written. If nil, messages are discarded.
 	Output io.Writer
 }
@@ -23,21 +200,275 @@ type ZipOptions struct {
 type UnzipOptions struct {
 	// OutputDir is the directory to extract files into. Defaults to ".".
 	OutputDir string
-	// Overwrite allows overwriting existing files.
+	// OnConflict selects what happens to entries whose destination file
+	// already exists, or was written by an earlier entry. By default the
+	// entry fails.
+	OnConflict ConflictPolicy
+	// ConflictPrompt, if set, is asked what to do with each existing file
+	// when neither OnConflict nor Overwrite is set, like the interactive
+	// prompt of unzip. Calls are serialized; entries extracted concurrently
+	// wait for the answer.
+	ConflictPrompt func(path string) ConflictDecision
+	// Overwrite allows overwriting existing files. It is shorthand for
+	// OnConflict set to ConflictOverwrite.
 	Overwrite bool
+	// Freshen extracts only entries whose file already exists and is older
+	// than the archived copy, replacing it, like unzip -f. No new files or
+	// directories are created.
+	Freshen bool
+	// Update is like Freshen but also extracts entries whose file does not
+	// exist yet, like unzip -u.
+	Update bool
+	// KeepNewer never replaces an existing file whose modification time
+	// is later than the entry's, protecting local edits during
+	// redeployments; such entries are counted as skipped. Other existing
+	// files are handled by OnConflict as usual.
+	KeepNewer bool
+	// Backup renames each file about to be overwritten to name~, or
+	// name~N~ if that is taken, like unzip -B, so that overwriting can be
+	// undone. Files written earlier in the same call are not backed up.
+	Backup bool
 	// JunkPaths strips directory components from file names on extraction.
 	JunkPaths bool
+	// StripComponents removes that many leading path elements from entry
+	// names on extraction, like tar --strip-components. Entries with no
+	// elements left, such as the top-level directory itself, are ignored.
+	StripComponents int
+	// NameTransform, if set, rewrites each selected entry name after
+	// StripComponents is applied and before JunkPaths. Returning false or
+	// an empty name leaves the entry unextracted. Patterns match the names
+	// stored in the archive, and the result is still confined to
+	// OutputDir.
+	NameTransform func(name string) (string, bool)
+	// CaseCollisions selects what happens to entries whose destinations
+	// differ only by case, such as README and readme, when OutputDir is on
+	// a case-insensitive filesystem. By default Unzip fails before
+	// extracting anything.
+	CaseCollisions CaseCollisionPolicy
+	// ReservedNames selects what happens on Windows to entries whose
+	// path contains a device name such as CON or NUL. By default the
+	// elements are prefixed with an underscore. Paths too long for the
+	// Windows APIs are always extracted using the \\?\ prefix.
+	ReservedNames ReservedNamePolicy
 	// FilePatterns filters which files to extract. Empty means extract all.
 	FilePatterns []string
+	// ExcludePatterns lists entries not to extract, even if they match
+	// FilePatterns.
+	ExcludePatterns []string
+	// MatchSyntax selects how FilePatterns, ExcludePatterns and
+	// PriorityPatterns are interpreted. Defaults to SyntaxGlob.
+	MatchSyntax MatchSyntax
+	// ExcludeMacMetadata skips macOS metadata entries, as reported by
+	// IsMacMetadata, such as the __MACOSX resource forks and .DS_Store
+	// files in archives made by the Finder. Like entries excluded by
+	// patterns, they are not counted as skipped.
+	ExcludeMacMetadata bool
+	// SkipManifest skips the ManifestName entry that Zip stores in
+	// incremental backups, which is bookkeeping rather than content. Like
+	// entries excluded by patterns, it is not counted as skipped. Restore
+	// always skips it.
+	SkipManifest bool
+	// CaseInsensitive makes FilePatterns and ExcludePatterns match entry
+	// names regardless of case, like unzip -C.
+	CaseInsensitive bool
+	// Order selects the order in which entries are extracted.
+	Order ExtractOrder
+	// PriorityPatterns lists patterns whose matching entries are
+	// extracted before all others, e.g. manifests or indexes.
+	PriorityPatterns []string
+	// AllowSetuid preserves the setuid and setgid bits of extracted entries.
+	// By default they are stripped; all other permission bits, including the
+	// sticky bit, are restored as archived.
+	AllowSetuid bool
+	// Encoding is the code page used to decode entry names that are not
+	// flagged as UTF-8, such as "cp949", "cp437", "shift-jis" or "gbk".
+	// Empty means names are used as stored.
+	Encoding string
+	// ConvertText converts the contents of the entries it selects as text:
+	// their line endings to those of this system, CR LF on Windows and LF
+	// elsewhere, and, if Encoding is set, their characters from that code
+	// page to UTF-8, like unzip -a. CRC-32 checks apply to the stored
+	// contents.
+	ConvertText TextConversion
+	// FileMode is the permission applied to files whose entries carry no
+	// Unix permissions, e.g. those created on Windows. Zero means
+	// DefaultFileMode.
+	FileMode os.FileMode
+	// DirMode is the permission applied to directories created implicitly
+	// and to directory entries without Unix permissions. Zero means
+	// DefaultDirMode. Directories stay writable by the owner until all
+	// entries are extracted and get their final modes last, so read-only
+	// directories can still be filled.
+	DirMode os.FileMode
+	// HonorUmask filters all restored permissions through the process
+	// umask. By default permissions are applied exactly as archived or
+	// configured, so results do not depend on the caller's umask.
+	HonorUmask bool
+	// RestoreOwnership restores the UID and GID recorded in the Info-ZIP
+	// Unix extra field. It only takes effect when running as root;
+	// otherwise the entries are reported as degraded.
+	RestoreOwnership bool
+	// KeepCorrupt keeps partially written files whose data is truncated or
+	// fails CRC verification. By default they are deleted; either way Unzip
+	// returns an error, a *CRCError for checksum mismatches.
+	KeepCorrupt bool
+	// MaterializeSymlinks extracts symbolic links as copies of their target
+	// files instead of as links, e.g. for platforms or consumers that do not
+	// support links. Links to directories or missing targets are stored as
+	// text files holding the target.
+	MaterializeSymlinks bool
+	// OnDegraded, if set, is called for every entry whose attributes could
+	// not be restored exactly, such as symlinks, Unix modes, or ownership
+	// when extracting on Windows. A summary is also written to Output.
+	OnDegraded func(Degradation)
+	// ExecCommand is a command run for each extracted file, with every "{}"
+	// replaced by the file's path, or the path appended if there is no "{}".
+	// Relative paths start with "./", so that no file name reads as an
+	// option.
+	// It is split into arguments with shell-like quoting but never run
+	// through a shell, so file names cannot inject commands.
+	ExecCommand string
+	// ExecParallel limits how many ExecCommand processes run at once.
+	// Zero or less means one per CPU.
+	ExecParallel int
+	// Mmap reads the archive through a read-only memory mapping instead of
+	// a system call per read, which speeds up archives with many small
+	// entries. It needs a 64-bit Unix platform; elsewhere the archive is
+	// read normally. The archive must not be truncated while it is
+	// mapped. UnzipReader ignores it.
+	Mmap bool
+	// Concurrency is the number of entries extracted at once. Values
+	// below two extract entries one at a time in order. OnDegraded may be
+	// called from several goroutines when it is greater than one.
+	Concurrency int
+	// RateLimit caps how many bytes of archive are read per second,
+	// across all entries however many are extracted at once. Zero means
+	// unlimited.
+	RateLimit int64
+	// Force skips the free space check made before extraction.
+	Force bool
+	// MaxArchiveSize limits the size of the archive in bytes, including
+	// what UnzipStream buffers to a temporary file. Zero means unlimited.
+	MaxArchiveSize int64
+	// MaxEntries limits how many entries may be extracted. Zero means
+	// unlimited.
+	MaxEntries int
+	// MaxEntrySize limits the uncompressed size of each entry in bytes.
+	// Zero means unlimited.
+	MaxEntrySize int64
+	// MaxPathDepth limits the number of path components in entry names.
+	// Zero means unlimited.
+	MaxPathDepth int
+	// MaxPathLength limits the length of entry names in bytes. Zero means
+	// unlimited.
+	MaxPathLength int
+	// Result, if non-nil, is filled with a summary of the call.
+	Result *Result
+	// DryRun reports which entries would be extracted, skipped or would
+	// collide with existing files, without touching the filesystem.
+	DryRun bool
+	// OnError, if non-nil, is called when an entry fails to extract and
+	// decides whether to abort, skip the entry or retry it. With
+	// Concurrency above one it may be called from several goroutines.
+	OnError func(entry string, err error) ErrorAction
+	// OnEntryStart and OnEntryDone, if set, are called once before and
+	// after each entry is extracted, however often it is retried;
+	// OnEntryDone carries the duration and any error. OnSkip is called for
+	// entries counted as skipped, such as unsupported entries, dry run
+	// collisions, or existing files kept by ConflictSkip; for entries
+	// already started it replaces OnEntryDone. With Concurrency above one
+	// they may be called from several goroutines.
+	OnEntryStart func(EntryEvent)
+	OnEntryDone  func(EntryEvent)
+	OnSkip       func(EntryEvent)
+	// OnProgress, if set, is called as entries are extracted, with the
+	// uncompressed bytes written so far and the total of the selected
+	// entries. Calls are serialized, even with Concurrency above one.
+	OnProgress func(Progress)
+	// Verbosity selects how much is reported about each entry, to Output
+	// or Logger: VerbosityQuiet drops the line for each file extracted,
+	// like unzip -q, and VerbositySilent also drops warnings, like
+	// unzip -qq.
+	Verbosity Verbosity
+	// Logger, if non-nil, receives a structured record for each entry
+	// instead of the text status messages written to Output, as for
+	// ZipOptions.Logger. Output of ExecCommand still goes to Output.
+	Logger *slog.Logger
 	// Output is where status messages are written. If nil, messages are discarded.
 	Output io.Writer
 }
 
+// VerifyOptions configures the behavior of the Verify function.
+type VerifyOptions struct {
+	// SampleFraction, if between 0 and 1, tests only that fraction of the
+	// entries, chosen at random but always including the largest entry and
+	// the entry stored last. Other values test every entry.
+	SampleFraction float64
+	// Seed seeds the sample selection so a run can be repeated. Zero picks
+	// a random seed, which is reported in Output.
+	Seed uint64
+	// FilePatterns, if non-empty, restricts testing to entries matching
+	// at least one of these patterns, as for UnzipOptions.FilePatterns.
+	FilePatterns []string
+	// ExcludePatterns skips entries matching any of these patterns.
+	ExcludePatterns []string
+	// MatchSyntax selects how FilePatterns and ExcludePatterns are
+	// interpreted.
+	MatchSyntax MatchSyntax
+	// CaseInsensitive makes FilePatterns and ExcludePatterns match names
+	// regardless of case.
+	CaseInsensitive bool
+	// Encoding is the code page used to decode entry names that are not
+	// flagged as UTF-8. Empty means names are used as stored.
+	Encoding string
+	// Output is where status messages are written. If nil, messages are discarded.
+	Output io.Writer
+}
+
+// ListOptions configures the behavior of the ListWithOptions function.
+type ListOptions struct {
+	// Encoding is the code page used to decode entry names that are not
+	// flagged as UTF-8. Empty means names are used as stored.
+	Encoding string
+	// Since, if non-zero, omits entries modified before this time.
+	Since time.Time
+	// Until, if non-zero, omits entries modified at or after this time.
+	Until time.Time
+	// SkipManifest omits the ManifestName entry of incremental backups,
+	// as for UnzipOptions.SkipManifest.
+	SkipManifest bool
+	// Mmap reads the archive through a memory mapping, as for
+	// UnzipOptions.Mmap.
+	Mmap bool
+}
+
 // ListEntry holds metadata about a single entry in a zip archive.
 type ListEntry struct {
 	Name             string
 	UncompressedSize uint64
 	CompressedSize   uint64
+	CRC32            uint32
 	Modified         time.Time
 	IsDir            bool
+	// Method is the compression method ID stored in the archive, such as
+	// zip.Store or zip.Deflate, and Flags the general purpose bit flags,
+	// which for deflate record the level class: normal, maximum, fast or
+	// super fast.
+	Method uint16
+	Flags  uint16
+	// CreatorVersion is the "version made by" field: its high byte is the
+	// host system that wrote the entry, such as 0 for MS-DOS or 3 for
+	// Unix, and its low byte the version of the zip specification it
+	// follows, times ten.
+	CreatorVersion uint16
+	// ExternalAttrs holds the file attributes of the host system: the Unix
+	// mode in the high 16 bits for Unix hosts, MS-DOS attributes in the
+	// low byte.
+	ExternalAttrs uint32
+	// Text reports whether the entry is marked as text in its internal
+	// attributes. Only Entries, List and their variants read it.
+	Text bool
+	// HasExtra reports whether the central directory record of the entry
+	// has extra fields.
+	HasExtra bool
 }
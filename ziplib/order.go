@@ -0,0 +1,49 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"cmp"
+	"slices"
+)
+
+// ExtractOrder selects the order in which Unzip extracts entries.
+type ExtractOrder int
+
+const (
+	// OrderArchive extracts entries in the order they appear in the archive.
+	OrderArchive ExtractOrder = iota
+	// OrderSmallestFirst extracts entries by ascending uncompressed size.
+	OrderSmallestFirst
+)
+
+// orderEntries returns files in extraction order. Entries matched by
+// priority, which may be nil, come first; within each group the requested
+// order applies. Sorting is stable, so ties keep their archive order.
+// Without priority patterns, the archive order is files itself.
+func orderEntries(files []*zip.File, order ExtractOrder, priority Matcher) []*zip.File {
+	if priority == nil && order == OrderArchive {
+		return files
+	}
+	first := make(map[*zip.File]bool)
+	if priority != nil {
+		for _, f := range files {
+			if priority.Match(f.Name, f.FileInfo().IsDir()) {
+				first[f] = true
+			}
+		}
+	}
+	sorted := slices.Clone(files)
+	slices.SortStableFunc(sorted, func(a, b *zip.File) int {
+		if first[a] != first[b] {
+			if first[a] {
+				return -1
+			}
+			return 1
+		}
+		if order == OrderSmallestFirst {
+			return cmp.Compare(a.UncompressedSize64, b.UncompressedSize64)
+		}
+		return 0
+	})
+	return sorted
+}
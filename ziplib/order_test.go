@@ -0,0 +1,53 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"slices"
+	"testing"
+)
+
+func TestOrderEntries(t *testing.T) {
+	files := []*zip.File{
+		{FileHeader: zip.FileHeader{Name: "big.bin", UncompressedSize64: 300}},
+		{FileHeader: zip.FileHeader{Name: "data/index.json", UncompressedSize64: 200}},
+		{FileHeader: zip.FileHeader{Name: "small.txt", UncompressedSize64: 10}},
+		{FileHeader: zip.FileHeader{Name: "tiny.txt", UncompressedSize64: 10}},
+		{FileHeader: zip.FileHeader{Name: "MANIFEST", UncompressedSize64: 50}},
+	}
+
+	tests := []struct {
+		name     string
+		order    ExtractOrder
+		priority []string
+		want     []string
+	}{
+		{"archive order", OrderArchive, nil,
+			[]string{"big.bin", "data/index.json", "small.txt", "tiny.txt", "MANIFEST"}},
+		{"smallest first", OrderSmallestFirst, nil,
+			[]string{"small.txt", "tiny.txt", "MANIFEST", "data/index.json", "big.bin"}},
+		{"patterns first", OrderArchive, []string{"MANIFEST", "*.json"},
+			[]string{"data/index.json", "MANIFEST", "big.bin", "small.txt", "tiny.txt"}},
+		{"patterns then smallest", OrderSmallestFirst, []string{"*.json", "MANIFEST"},
+			[]string{"MANIFEST", "data/index.json", "small.txt", "tiny.txt", "big.bin"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var priority Matcher
+			if tt.priority != nil {
+				priority = globMatcher(tt.priority)
+			}
+			got := orderEntries(files, tt.order, priority)
+			names := make([]string, len(got))
+			for i, f := range got {
+				names[i] = f.Name
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("orderEntries = %v, want %v", names, tt.want)
+			}
+			if tt.order == OrderArchive && priority == nil && &got[0] != &files[0] {
+				t.Error("orderEntries copied files in archive order")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// unixOwnerVersion is the only version of the 0x7875 field defined by Info-ZIP.
+const unixOwnerVersion = 1
+
+// encodeUnixOwner builds the body of an Info-ZIP 0x7875 extra field.
+func encodeUnixOwner(uid, gid uint32) []byte {
+	b := []byte{unixOwnerVersion, 4}
+	b = binary.LittleEndian.AppendUint32(b, uid)
+	b = append(b, 4)
+	return binary.LittleEndian.AppendUint32(b, gid)
+}
+
+// decodeUnixOwner parses the body of an Info-ZIP 0x7875 extra field. The
+// UID and GID are stored with variable widths of up to 8 bytes each.
+func decodeUnixOwner(data []byte) (uid, gid int, ok bool) {
+	if len(data) < 1 || data[0] != unixOwnerVersion {
+		return 0, 0, false
+	}
+	data = data[1:]
+	var ids [2]uint64
+	for i := range ids {
+		if len(data) < 1 {
+			return 0, 0, false
+		}
+		n := int(data[0])
+		data = data[1:]
+		if n > 8 || n > len(data) {
+			return 0, 0, false
+		}
+		var buf [8]byte
+		copy(buf[:], data[:n])
+		ids[i] = binary.LittleEndian.Uint64(buf[:])
+		data = data[n:]
+	}
+	return int(ids[0]), int(ids[1]), true //nolint:gosec // IDs larger than int are not meaningful.
+}
+
+// addOwnerExtra appends the owner of path to the header's extra field when
+// the platform exposes it.
+func addOwnerExtra(header *zip.FileHeader, path string) {
+	if uid, gid, ok := fileOwner(path); ok {
+		header.Extra = appendExtra(header.Extra, extraUnixOwner, encodeUnixOwner(uid, gid))
+	}
+}
+
+// restoreOwner changes the owner of path to the UID and GID recorded in f.
+// It does nothing if the entry carries no ownership extra field. Unless the
+// process runs as root, the entry is reported as degraded instead.
+func (x *extractor) restoreOwner(path string, f *zip.File) error {
+	data, ok := findExtra(f.Extra, extraUnixOwner)
+	if !ok {
+		return nil
+	}
+	uid, gid, ok := decodeUnixOwner(data)
+	if !ok {
+		return nil
+	}
+	if euid := os.Geteuid(); euid != 0 {
+		detail := "requires root"
+		if euid < 0 {
+			detail = "not supported on this platform"
+		}
+		x.degrade(Degradation{
+			Name:   f.Name,
+			Kind:   DegradedOwnership,
+			Detail: fmt.Sprintf("owner %d:%d not restored, %s", uid, gid, detail),
+		})
+		return nil
+	}
+	if err := os.Lchown(path, uid, gid); err != nil {
+		return fmt.Errorf("chown %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestUnixOwnerRoundTrip(t *testing.T) {
+	uid, gid, ok := decodeUnixOwner(encodeUnixOwner(1000, 65534))
+	if !ok || uid != 1000 || gid != 65534 {
+		t.Errorf("decodeUnixOwner = %d, %d, %v; want 1000, 65534, true", uid, gid, ok)
+	}
+}
+
+func TestDecodeUnixOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		uid, gid int
+		ok       bool
+	}{
+		{"two byte ids", []byte{1, 2, 0xe8, 0x03, 2, 0x64, 0x00}, 1000, 100, true},
+		{"zero width ids", []byte{1, 0, 0}, 0, 0, true},
+		{"bad version", []byte{2, 1, 0, 1, 0}, 0, 0, false},
+		{"truncated", []byte{1, 4, 0, 0}, 0, 0, false},
+		{"empty", nil, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uid, gid, ok := decodeUnixOwner(tt.data)
+			if ok != tt.ok || uid != tt.uid || gid != tt.gid {
+				t.Errorf("decodeUnixOwner(%v) = %d, %d, %v; want %d, %d, %v",
+					tt.data, uid, gid, ok, tt.uid, tt.gid, tt.ok)
+			}
+		})
+	}
+}
+
+func TestZipWritesOwnerExtra(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix ownership on windows")
+	}
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "owner.zip")
+
+	if err := Zip(zipPath, []string{filepath.Join(src, "hello.txt")}, ZipOptions{}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	data, ok := findExtra(r.File[0].Extra, extraUnixOwner)
+	if !ok {
+		t.Fatal("expected Unix owner extra field")
+	}
+	uid, gid, ok := decodeUnixOwner(data)
+	if !ok || uid != os.Getuid() || gid != os.Getgid() {
+		t.Errorf("owner = %d, %d, %v; want %d, %d, true", uid, gid, ok, os.Getuid(), os.Getgid())
+	}
+}
+
+func TestUnzipRestoresOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("restoring ownership requires root")
+	}
+	zipPath := filepath.Join(t.TempDir(), "chown.zip")
+	extractDir := t.TempDir()
+
+	h := &zip.FileHeader{Name: "owned.txt", Method: zip.Deflate}
+	h.Extra = appendExtra(nil, extraUnixOwner, encodeUnixOwner(1234, 5678))
+	writeTestZip(t, zipPath, "data", h)
+
+	err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, RestoreOwnership: true})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+
+	uid, gid, ok := fileOwner(filepath.Join(extractDir, "owned.txt"))
+	if !ok || uid != 1234 || gid != 5678 {
+		t.Errorf("owner = %d, %d, %v; want 1234, 5678, true", uid, gid, ok)
+	}
+}
@@ -0,0 +1,54 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// extractAll extracts files, with up to n entries in flight at once. Each
+// entry is read through its own f.Open reader, which archive/zip supports
+// concurrently. Entries are started in order; with n of one or less they
+// also finish in order and the first error that OnError does not skip
+// stops extraction. In parallel, no new entries are started after such an
+// error and the errors of all entries already running are joined.
+func (x *extractor) extractAll(files []*zip.File, n int) error {
+	if n <= 1 {
+		for _, f := range files {
+			if err := x.extractWithPolicy(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		failed atomic.Bool
+	)
+	sem := make(chan struct{}, n)
+	for _, f := range files {
+		sem <- struct{}{}
+		if failed.Load() {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := x.extractWithPolicy(f); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				failed.Store(true)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
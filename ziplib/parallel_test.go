@@ -0,0 +1,44 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnzipConcurrency(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "many.zip")
+	var headers []*zip.FileHeader
+	for i := range 50 {
+		headers = append(headers, &zip.FileHeader{Name: fmt.Sprintf("d%d/sub/f%d.txt", i%5, i), Method: zip.Deflate})
+	}
+	writeTestZip(t, zipPath, "parallel content\n", headers...)
+
+	extractDir := t.TempDir()
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, Concurrency: 8}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for _, h := range headers {
+		if got := readFile(t, filepath.Join(extractDir, h.Name)); got != "parallel content\n" {
+			t.Errorf("%s content = %q", h.Name, got)
+		}
+	}
+}
+
+func TestUnzipConcurrencyStopsOnError(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "exists.zip")
+	writeTestZip(t, zipPath, "data", &zip.FileHeader{Name: "a.txt"}, &zip.FileHeader{Name: "b.txt"})
+
+	extractDir := t.TempDir()
+	writeFile(t, filepath.Join(extractDir, "a.txt"), "existing")
+	writeFile(t, filepath.Join(extractDir, "b.txt"), "existing")
+
+	err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, Concurrency: 2})
+	if err == nil {
+		t.Fatal("expected error for existing files")
+	}
+	if got := readFile(t, filepath.Join(extractDir, "a.txt")); got != "existing" {
+		t.Errorf("a.txt overwritten: %q", got)
+	}
+}
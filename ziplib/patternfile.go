@@ -0,0 +1,41 @@
+package ziplib
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ReadPatternFile reads one pattern per line from the file at path.
+// Surrounding whitespace is trimmed, and blank lines and lines starting
+// with "#" are skipped.
+func ReadPatternFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // Pattern file path is chosen by the caller.
+	if err != nil {
+		return nil, fmt.Errorf("read pattern file: %w", err)
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// readPatternFiles returns patterns followed by those read from each of
+// files. The patterns slice itself is never modified.
+func readPatternFiles(patterns, files []string) ([]string, error) {
+	all := slices.Clip(patterns)
+	for _, f := range files {
+		p, err := ReadPatternFile(f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, p...)
+	}
+	return all, nil
+}
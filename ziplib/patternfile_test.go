@@ -0,0 +1,56 @@
+package ziplib
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadPatternFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	writeFile(t, path, "# build output\n*.o\r\n\n  build/**  \n#*.keep\n*.tmp\n")
+	got, err := ReadPatternFile(path)
+	if err != nil {
+		t.Fatalf("ReadPatternFile: %v", err)
+	}
+	want := []string{"*.o", "build/**", "*.tmp"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ReadPatternFile = %q, want %q", got, want)
+	}
+
+	if _, err := ReadPatternFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("ReadPatternFile of a missing file succeeded")
+	}
+}
+
+func TestZipPatternFiles(t *testing.T) {
+	src := setupTestDir(t)
+	lists := t.TempDir()
+	excludeFile := filepath.Join(lists, "exclude.txt")
+	includeFile := filepath.Join(lists, "include.txt")
+	writeFile(t, excludeFile, "# skip nested files\nsub/*\n")
+	writeFile(t, includeFile, "*.txt\n")
+	t.Chdir(src)
+
+	zipPath := filepath.Join(t.TempDir(), "from.zip")
+	excludes := []string{"*.go"}
+	err := Zip(zipPath, []string{"."}, ZipOptions{
+		Recursive:       true,
+		ExcludePatterns: excludes,
+		ExcludeFrom:     []string{excludeFile},
+		IncludeFrom:     []string{includeFile},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "hello.txt" {
+		t.Errorf("entries = %+v, want only hello.txt", entries)
+	}
+	if len(excludes) != 1 {
+		t.Errorf("caller's ExcludePatterns modified: %q", excludes)
+	}
+}
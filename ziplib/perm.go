@@ -0,0 +1,121 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Default modes for entries that carry no Unix permissions and for
+// directories created implicitly as parents of extracted entries.
+const (
+	DefaultFileMode os.FileMode = 0o644
+	DefaultDirMode  os.FileMode = 0o755
+)
+
+// Values of the "version made by" high byte for systems that store Unix
+// permissions in the external attributes.
+const (
+	creatorUnix   = 3
+	creatorMacOSX = 19
+)
+
+// extractMode returns the permission bits to apply to an extracted entry.
+// The permission bits and the sticky bit are always preserved; setuid and
+// setgid are dropped unless allowSetuid is true.
+func extractMode(mode os.FileMode, allowSetuid bool) os.FileMode {
+	m := mode.Perm() | mode&os.ModeSticky
+	if allowSetuid {
+		m |= mode & (os.ModeSetuid | os.ModeSetgid)
+	}
+	return m
+}
+
+// modePolicy decides the permissions of extracted files and directories.
+type modePolicy struct {
+	fileMode    os.FileMode
+	dirMode     os.FileMode
+	umask       os.FileMode
+	allowSetuid bool
+}
+
+func newModePolicy(opts UnzipOptions) modePolicy {
+	p := modePolicy{
+		fileMode:    opts.FileMode.Perm(),
+		dirMode:     opts.DirMode.Perm(),
+		allowSetuid: opts.AllowSetuid,
+	}
+	if p.fileMode == 0 {
+		p.fileMode = DefaultFileMode
+	}
+	if p.dirMode == 0 {
+		p.dirMode = DefaultDirMode
+	}
+	if opts.HonorUmask {
+		p.umask = processUmask().Perm()
+	}
+	return p
+}
+
+// entryMode returns the mode to apply to the extracted entry f. Entries
+// without Unix permissions get the default file or directory mode; a
+// read-only DOS attribute still removes the write bits.
+func (p modePolicy) entryMode(f *zip.File) os.FileMode {
+	mode := f.Mode()
+	creator := f.CreatorVersion >> 8
+	hasUnixMode := (creator == creatorUnix || creator == creatorMacOSX) && f.ExternalAttrs>>16 != 0
+	switch {
+	case hasUnixMode:
+	case mode.IsDir():
+		mode = p.dirMode
+	case f.ExternalAttrs&dosReadOnly != 0:
+		mode = p.fileMode &^ 0o222
+	default:
+		mode = p.fileMode
+	}
+	return extractMode(mode, p.allowSetuid) &^ p.umask
+}
+
+// parentMode returns the mode for directories created implicitly.
+func (p modePolicy) parentMode() os.FileMode {
+	return p.dirMode &^ p.umask
+}
+
+// restoreMode applies mode to path. Calling chmod explicitly is required
+// because the mode passed to open and mkdir is filtered by the process
+// umask and cannot carry the special bits.
+func restoreMode(path string, mode os.FileMode) error {
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("chmod %s: %w", path, err)
+	}
+	return nil
+}
+
+// makeDirs creates path and any missing parents with exactly mode,
+// regardless of the process umask, and passes each directory it creates to
+// created if that is non-nil. Existing directories are left alone.
+func makeDirs(path string, mode os.FileMode, created func(string)) error {
+	fi, err := os.Stat(path)
+	if err == nil {
+		if !fi.IsDir() {
+			return fmt.Errorf("mkdir %s: not a directory", path)
+		}
+		return nil
+	}
+	if parent := filepath.Dir(path); parent != path {
+		if err := makeDirs(parent, mode, created); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(path, mode); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return fmt.Errorf("mkdir %s: %w", path, err)
+	}
+	if created != nil {
+		created(path)
+	}
+	return restoreMode(path, mode)
+}
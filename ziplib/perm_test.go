@@ -0,0 +1,104 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExtractMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        os.FileMode
+		allowSetuid bool
+		want        os.FileMode
+	}{
+		{"plain", 0o644, false, 0o644},
+		{"dir bit dropped", os.ModeDir | 0o755, false, 0o755},
+		{"sticky kept", os.ModeSticky | 0o777, false, os.ModeSticky | 0o777},
+		{"setuid stripped", os.ModeSetuid | 0o755, false, 0o755},
+		{"setgid stripped", os.ModeSetgid | 0o755, false, 0o755},
+		{"setuid allowed", os.ModeSetuid | 0o755, true, os.ModeSetuid | 0o755},
+		{"setgid allowed", os.ModeSetgid | os.ModeSticky | 0o700, true, os.ModeSetgid | os.ModeSticky | 0o700},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractMode(tt.mode, tt.allowSetuid)
+			if got != tt.want {
+				t.Errorf("extractMode(%v, %v) = %v, want %v", tt.mode, tt.allowSetuid, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModePolicyEntryMode(t *testing.T) {
+	unixFile := func(name string, mode os.FileMode) *zip.File {
+		h := &zip.FileHeader{Name: name}
+		h.SetMode(mode)
+		return &zip.File{FileHeader: *h}
+	}
+	dosFile := func(name string, attrs uint32) *zip.File {
+		return &zip.File{FileHeader: zip.FileHeader{Name: name, ExternalAttrs: attrs}}
+	}
+
+	p := modePolicy{fileMode: 0o640, dirMode: 0o750}
+	tests := []struct {
+		name string
+		p    modePolicy
+		f    *zip.File
+		want os.FileMode
+	}{
+		{"unix file kept", p, unixFile("a", 0o600), 0o600},
+		{"unix dir kept", p, unixFile("d/", os.ModeDir|0o700), 0o700},
+		{"dos file default", p, dosFile("a", 0), 0o640},
+		{"dos read-only file", p, dosFile("a", 0x01), 0o440},
+		{"dos dir default", p, dosFile("d/", 0x10), 0o750},
+		{"unix creator without mode", p, &zip.File{FileHeader: zip.FileHeader{Name: "a", CreatorVersion: creatorUnix << 8}}, 0o640},
+		{"umask applied", modePolicy{fileMode: 0o666, dirMode: 0o777, umask: 0o027}, unixFile("a", 0o777), 0o750},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.entryMode(tt.f); got != tt.want {
+				t.Errorf("entryMode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewModePolicyDefaults(t *testing.T) {
+	p := newModePolicy(UnzipOptions{})
+	if p.fileMode != DefaultFileMode || p.dirMode != DefaultDirMode || p.umask != 0 {
+		t.Errorf("newModePolicy defaults = %+v", p)
+	}
+}
+
+func TestMakeDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on windows")
+	}
+	base := t.TempDir()
+	path := filepath.Join(base, "a", "b", "c")
+
+	if err := makeDirs(path, 0o750, nil); err != nil {
+		t.Fatalf("makeDirs: %v", err)
+	}
+	for _, p := range []string{"a", "a/b", "a/b/c"} {
+		fi, err := os.Stat(filepath.Join(base, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0o750 {
+			t.Errorf("%s mode = %v, want %v", p, fi.Mode().Perm(), os.FileMode(0o750))
+		}
+	}
+
+	file := filepath.Join(base, "file")
+	writeFile(t, file, "x")
+	if err := makeDirs(filepath.Join(file, "sub"), 0o755, nil); err == nil {
+		t.Error("expected error when a parent is a file")
+	}
+}
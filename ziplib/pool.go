@@ -0,0 +1,72 @@
+package ziplib
+
+import (
+	"compress/flate"
+	"errors"
+	"io"
+	"sync"
+)
+
+// copyBufSize is the size of the pooled buffers used to copy entry data.
+const copyBufSize = 32 << 10
+
+// copyBufs holds copy buffers shared by all entries, so adding or
+// extracting many small files does not allocate a buffer for each.
+var copyBufs = sync.Pool{New: func() any {
+	b := make([]byte, copyBufSize)
+	return &b
+}}
+
+// copyBuffer copies src to dst like io.Copy, using a pooled buffer.
+// ReaderFrom and WriterTo are bypassed, as their fallbacks allocate a new
+// buffer on every call.
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	bp := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(bp)
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, *bp) //nolint:wrapcheck // Callers wrap with the entry name.
+}
+
+// flateWriters pools flate writers by compression level, from -1 to 9.
+// A flate writer holds several hundred kilobytes of state, which Reset
+// lets every entry compressed at the same level reuse.
+var flateWriters [11]sync.Pool
+
+// newFlateWriter returns a flate writer at level writing to out. Closing
+// it flushes the stream and returns the writer to the pool.
+func newFlateWriter(out io.Writer, level int) (io.WriteCloser, error) {
+	pool := &flateWriters[level+1]
+	if fw, ok := pool.Get().(*flate.Writer); ok {
+		fw.Reset(out)
+		return &pooledFlateWriter{fw: fw, pool: pool}, nil
+	}
+	fw, err := flate.NewWriter(out, level)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Only fails for invalid levels, which are clamped.
+	}
+	return &pooledFlateWriter{fw: fw, pool: pool}, nil
+}
+
+// errWriterClosed is returned for writes to a closed pooled flate writer.
+var errWriterClosed = errors.New("flate: write to closed writer")
+
+type pooledFlateWriter struct {
+	fw   *flate.Writer
+	pool *sync.Pool
+}
+
+func (w *pooledFlateWriter) Write(p []byte) (int, error) {
+	if w.fw == nil {
+		return 0, errWriterClosed
+	}
+	return w.fw.Write(p) //nolint:wrapcheck // Pass-through writer.
+}
+
+func (w *pooledFlateWriter) Close() error {
+	if w.fw == nil {
+		return errWriterClosed
+	}
+	err := w.fw.Close()
+	w.pool.Put(w.fw)
+	w.fw = nil
+	return err //nolint:wrapcheck // Pass-through writer.
+}
@@ -0,0 +1,44 @@
+package ziplib
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPooledFlateWriterReuse(t *testing.T) {
+	for i, content := range []string{strings.Repeat("first ", 1000), "second", ""} {
+		var buf bytes.Buffer
+		w, err := newFlateWriter(&buf, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("x")); err == nil {
+			t.Errorf("stream %d: write after close succeeded", i)
+		}
+		got, err := io.ReadAll(flate.NewReader(&buf))
+		if err != nil {
+			t.Fatalf("stream %d: %v", i, err)
+		}
+		if string(got) != content {
+			t.Errorf("stream %d = %.20q, want %.20q", i, got, content)
+		}
+	}
+}
+
+func TestCopyBuffer(t *testing.T) {
+	content := strings.Repeat("0123456789", copyBufSize/5)
+	var buf bytes.Buffer
+	n, err := copyBuffer(&buf, strings.NewReader(content))
+	if err != nil || n != int64(len(content)) || buf.String() != content {
+		t.Errorf("copyBuffer = %d, %v, want %d bytes copied", n, err, len(content))
+	}
+}
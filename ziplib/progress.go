@@ -0,0 +1,70 @@
+package ziplib
+
+import (
+	"io"
+	"sync"
+)
+
+// Progress reports how far a Zip or Unzip call has got, passed to the
+// OnProgress hooks of ZipOptions and UnzipOptions.
+type Progress struct {
+	// Entry is the name of the entry being read or written.
+	Entry string
+	// Bytes is the number of uncompressed bytes processed so far, over
+	// all entries.
+	Bytes uint64
+	// Total is the number of uncompressed bytes to process, or zero if it
+	// is not known: Zip does not walk its inputs in advance, so callers
+	// wanting a total can get it from a dry run.
+	Total uint64
+}
+
+// progressMeter counts the bytes processed by one call and reports them
+// to its OnProgress hook. A nil *progressMeter does nothing.
+type progressMeter struct {
+	fn    func(Progress)
+	mu    sync.Mutex // serializes calls to fn
+	done  uint64
+	total uint64
+}
+
+func newProgressMeter(fn func(Progress), total uint64) *progressMeter {
+	if fn == nil {
+		return nil
+	}
+	return &progressMeter{fn: fn, total: total}
+}
+
+// add reports n more bytes processed for entry.
+func (m *progressMeter) add(entry string, n uint64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.done += n
+	m.fn(Progress{Entry: entry, Bytes: m.done, Total: m.total})
+}
+
+// reader returns r wrapped to report the bytes read through it as
+// processed for entry.
+func (m *progressMeter) reader(r io.Reader, entry string) io.Reader {
+	if m == nil {
+		return r
+	}
+	return &progressReader{r: r, m: m, entry: entry}
+}
+
+type progressReader struct {
+	r     io.Reader
+	m     *progressMeter
+	entry string
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.m.add(p.entry, uint64(n))
+	}
+	return n, err //nolint:wrapcheck // Pass-through reader.
+}
@@ -0,0 +1,43 @@
+package ziplib
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), strings.Repeat("a", 100000))
+	writeFile(t, filepath.Join(src, "b.txt"), "b")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+
+	var zipped []Progress
+	opts := ZipOptions{CompressionLevel: -1, OnProgress: func(p Progress) { zipped = append(zipped, p) }}
+	if err := Zip(zipPath, []string{"a.txt", "b.txt"}, opts); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if len(zipped) < 2 {
+		t.Fatalf("got %d progress reports, want at least 2", len(zipped))
+	}
+	if last := zipped[len(zipped)-1]; last.Bytes != 100001 || last.Entry != "b.txt" || last.Total != 0 {
+		t.Errorf("last zip progress = %+v, want 100001 bytes of b.txt", last)
+	}
+
+	var extracted []Progress
+	err := Unzip(zipPath, UnzipOptions{OutputDir: t.TempDir(), Concurrency: 2, OnProgress: func(p Progress) {
+		extracted = append(extracted, p)
+	}})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for i, p := range extracted {
+		if p.Total != 100001 || i > 0 && p.Bytes < extracted[i-1].Bytes {
+			t.Fatalf("unzip progress %d = %+v, want a growing count of 100001", i, p)
+		}
+	}
+	if last := extracted[len(extracted)-1]; last.Bytes != 100001 {
+		t.Errorf("last unzip progress = %+v, want all bytes", last)
+	}
+}
@@ -0,0 +1,77 @@
+package ziplib
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// rateBurst is how far a rate limiter lets transfers run ahead after a
+// pause, so an idle stretch does not turn into an unthrottled burst.
+const rateBurst = time.Second
+
+// rateLimiter paces a stream of bytes to a fixed number per second. It is
+// safe for concurrent use, so entries extracted in parallel share one
+// budget. A nil *rateLimiter does not limit.
+type rateLimiter struct {
+	rate  int64
+	mu    sync.Mutex
+	start time.Time
+	n     int64 // bytes accounted since start
+}
+
+// newRateLimiter returns a limiter of rate bytes per second, or nil if rate
+// is zero or less.
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: rate}
+}
+
+// wait accounts for n bytes and sleeps until they are within the rate.
+func (l *rateLimiter) wait(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.start.IsZero() || now.Sub(l.due()) > rateBurst {
+		l.start, l.n = now, 0
+	}
+	l.n += int64(n)
+	due := l.due()
+	l.mu.Unlock()
+	if d := time.Until(due); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// due returns when the bytes accounted so far may have been transferred.
+func (l *rateLimiter) due() time.Time {
+	return l.start.Add(time.Duration(float64(l.n) / float64(l.rate) * float64(time.Second)))
+}
+
+// throttledWriter paces the writes to w with a rate limiter.
+type throttledWriter struct {
+	w io.Writer
+	l *rateLimiter
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	t.l.wait(n)
+	return n, err //nolint:wrapcheck // Pass-through writer.
+}
+
+// throttledReaderAt paces the reads from r with a rate limiter.
+type throttledReaderAt struct {
+	r io.ReaderAt
+	l *rateLimiter
+}
+
+func (t *throttledReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := t.r.ReadAt(p, off)
+	t.l.wait(n)
+	return n, err //nolint:wrapcheck // Pass-through reader.
+}
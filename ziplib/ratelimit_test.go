@@ -0,0 +1,61 @@
+package ziplib
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPaces(t *testing.T) {
+	l := newRateLimiter(1 << 20)
+	var buf bytes.Buffer
+	w := &throttledWriter{w: &buf, l: l}
+	start := time.Now()
+	for range 8 {
+		if _, err := w.Write(make([]byte, 32<<10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 256 KiB at 1 MiB/s takes a quarter of a second.
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("wrote 256 KiB in %v, want at least 250ms", elapsed)
+	}
+	if buf.Len() != 256<<10 {
+		t.Errorf("wrote %d bytes", buf.Len())
+	}
+	if newRateLimiter(0) != nil {
+		t.Error("a zero rate should not limit")
+	}
+}
+
+func TestZipUnzipRateLimit(t *testing.T) {
+	src := t.TempDir()
+	content := strings.Repeat("x", 1000)
+	writeFile(t, filepath.Join(src, "a.txt"), content)
+	t.Chdir(src)
+
+	var archive bytes.Buffer
+	start := time.Now()
+	if err := ZipTo(&archive, []string{"a.txt"}, ZipOptions{CompressionLevel: 0, RateLimit: 4 << 10}); err != nil {
+		t.Fatalf("ZipTo: %v", err)
+	}
+	// The stored entry alone is 1000 bytes, a quarter second at 4 KiB/s.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("zipped in %v, want it throttled", elapsed)
+	}
+
+	dest := t.TempDir()
+	start = time.Now()
+	err := UnzipReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()), UnzipOptions{OutputDir: dest, RateLimit: 4 << 10})
+	if err != nil {
+		t.Fatalf("UnzipReader: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("unzipped in %v, want it throttled", elapsed)
+	}
+	if got := readFile(t, filepath.Join(dest, "a.txt")); got != content {
+		t.Errorf("a.txt = %d bytes, want %d", len(got), len(content))
+	}
+}
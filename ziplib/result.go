@@ -0,0 +1,29 @@
+package ziplib
+
+import "archive/zip"
+
+// Result summarizes what one Zip or Unzip call did. It is filled in even
+// when the call fails, covering the entries processed before the failure.
+type Result struct {
+	// Entries is the number of entries added or extracted, including
+	// directories and symlinks.
+	Entries int
+	// Skipped is the number of entries left out: excluded or non-recursive
+	// paths for Zip, unsupported entries for Unzip, and in an Unzip dry run
+	// entries that would collide with existing files.
+	Skipped int
+	// UncompressedBytes is the total size of the file contents processed.
+	UncompressedBytes uint64
+	// CompressedBytes is the stored size of the extracted entries for
+	// Unzip, or the size of the written archive for Zip.
+	CompressedBytes uint64
+}
+
+// record counts the successfully extracted entry f in the result.
+func (x *extractor) record(f *zip.File) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.result.Entries++
+	x.result.UncompressedBytes += f.UncompressedSize64
+	x.result.CompressedBytes += f.CompressedSize64
+}
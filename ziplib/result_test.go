@@ -0,0 +1,38 @@
+package ziplib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipUnzipResult(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "result.zip")
+
+	var zres Result
+	err := Zip(zipPath, []string{src}, ZipOptions{
+		Recursive:       true,
+		ExcludePatterns: []string{"*.go"},
+		Result:          &zres,
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	fi, err := os.Stat(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Result{Entries: 2, Skipped: 1, UncompressedBytes: 27, CompressedBytes: uint64(fi.Size())}
+	if zres != want {
+		t.Errorf("Zip result = %+v, want %+v", zres, want)
+	}
+
+	var ures Result
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: t.TempDir(), Result: &ures}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if ures.Entries != 2 || ures.Skipped != 0 || ures.UncompressedBytes != 27 || ures.CompressedBytes == 0 {
+		t.Errorf("Unzip result = %+v", ures)
+	}
+}
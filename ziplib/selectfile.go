@@ -0,0 +1,20 @@
+package ziplib
+
+import "os"
+
+// inDateRange reports whether the modification time of info is within
+// ModifiedAfter and ModifiedBefore.
+func (a *archiver) inDateRange(info os.FileInfo) bool {
+	mtime := info.ModTime()
+	if !a.opts.ModifiedAfter.IsZero() && mtime.Before(a.opts.ModifiedAfter) {
+		return false
+	}
+	return a.opts.ModifiedBefore.IsZero() || mtime.Before(a.opts.ModifiedBefore)
+}
+
+// inSizeRange reports whether the size of info is within MinSize and
+// MaxSize.
+func (a *archiver) inSizeRange(info os.FileInfo) bool {
+	size := info.Size()
+	return size >= a.opts.MinSize && (a.opts.MaxSize <= 0 || size <= a.opts.MaxSize)
+}
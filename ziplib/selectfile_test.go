@@ -0,0 +1,49 @@
+package ziplib
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestZipDateRange(t *testing.T) {
+	src := t.TempDir()
+	for name, date := range map[string]string{"old.txt": "2023-12-31", "jan.txt": "2024-01-15", "jul.txt": "2024-07-01"} {
+		p := filepath.Join(src, name)
+		writeFile(t, p, name)
+		mtime, _ := time.Parse(time.DateOnly, date)
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(src)
+
+	var res Result
+	names := zipNames(t, []string{"."}, ZipOptions{
+		Recursive:      true,
+		ModifiedAfter:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ModifiedBefore: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		Result:         &res,
+	})
+	if !slices.Equal(names, []string{"jan.txt"}) {
+		t.Errorf("names = %v, want [jan.txt]", names)
+	}
+	if res.Skipped != 2 {
+		t.Errorf("skipped = %d, want 2", res.Skipped)
+	}
+}
+
+func TestZipSizeRange(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "empty.txt"), "")
+	writeFile(t, filepath.Join(src, "small.txt"), "small")
+	writeFile(t, filepath.Join(src, "large.txt"), "larger than ten bytes")
+	t.Chdir(src)
+
+	names := zipNames(t, []string{"."}, ZipOptions{Recursive: true, MinSize: 1, MaxSize: 10})
+	if !slices.Equal(names, []string{"small.txt"}) {
+		t.Errorf("names = %v, want [small.txt]", names)
+	}
+}
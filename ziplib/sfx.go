@@ -0,0 +1,59 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WriteSFX creates a self-extracting archive at sfxPath by prepending the
+// executable stub at stubPath to the entries of the archive at zipPath.
+// The stub must be built for the target platform, e.g. with
+//
+//	GOOS=windows GOARCH=amd64 go build github.com/jaeyeom/gozip/cmd/gozipsfx
+//
+// Entry offsets are rewritten to account for the stub, so the result is
+// still a valid zip archive that standard tools can list and extract.
+func WriteSFX(sfxPath, zipPath, stubPath string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer r.Close()
+
+	stub, err := os.Open(stubPath)
+	if err != nil {
+		return fmt.Errorf("open stub: %w", err)
+	}
+	defer stub.Close()
+
+	f, err := os.OpenFile(sfxPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o755) //nolint:gosec // The result is an executable.
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, stub)
+	if err != nil {
+		return fmt.Errorf("write stub: %w", err)
+	}
+
+	w := zip.NewWriter(f)
+	w.SetOffset(n)
+	for _, zf := range r.File {
+		if err := w.Copy(zf); err != nil {
+			return fmt.Errorf("copy %s: %w", zf.Name, err)
+		}
+	}
+	if err := w.SetComment(r.Comment); err != nil {
+		return fmt.Errorf("set comment: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", sfxPath, err)
+	}
+	return nil
+}
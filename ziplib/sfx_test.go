@@ -0,0 +1,45 @@
+package ziplib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSFX(t *testing.T) {
+	src := setupTestDir(t)
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "plain.zip")
+	if err := Zip(zipPath, []string{filepath.Join(src, "hello.txt")}, ZipOptions{}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	stub := bytes.Repeat([]byte("not really an executable\n"), 100)
+	stubPath := filepath.Join(dir, "stub")
+	if err := os.WriteFile(stubPath, stub, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sfxPath := filepath.Join(dir, "archive.sfx")
+	if err := WriteSFX(sfxPath, zipPath, stubPath); err != nil {
+		t.Fatalf("WriteSFX: %v", err)
+	}
+
+	b, err := os.ReadFile(sfxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, stub) {
+		t.Error("self-extractor does not start with the stub")
+	}
+	report, err := Diff(zipPath, sfxPath)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if !report.Empty() {
+		t.Errorf("payload differs from archive: %+v", report)
+	}
+	if err := Verify(sfxPath, VerifyOptions{}); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"math/bits"
+	"os"
+	"path/filepath"
+)
+
+// SpaceError is returned by Unzip when the destination filesystem does not
+// have room for the entries to be extracted. It is reported before anything
+// is written.
+type SpaceError struct {
+	// Dir is the destination directory that was checked.
+	Dir string
+	// Need is the total uncompressed size of the entries to extract.
+	Need uint64
+	// Avail is the space available on the destination filesystem.
+	Avail uint64
+}
+
+func (e *SpaceError) Error() string {
+	return fmt.Sprintf("insufficient space in %s: need %d bytes, %d available (use force to extract anyway)",
+		e.Dir, e.Need, e.Avail)
+}
+
+// checkFreeSpace returns a *SpaceError if the filesystem holding dir cannot
+// fit the uncompressed contents of files. If the available space cannot be
+// determined, the check passes.
+func checkFreeSpace(dir string, files []*zip.File) error {
+	need := totalSize(files)
+	if need == 0 {
+		return nil
+	}
+	avail, ok := freeSpace(existingAncestor(dir))
+	if !ok || need <= avail {
+		return nil
+	}
+	return &SpaceError{Dir: dir, Need: need, Avail: avail}
+}
+
+// totalSize returns the total uncompressed size of files, or the largest
+// uint64 if the sum overflows, as forged sizes can make it.
+func totalSize(files []*zip.File) uint64 {
+	var total uint64
+	for _, f := range files {
+		sum, carry := bits.Add64(total, f.UncompressedSize64, 0)
+		if carry != 0 {
+			return math.MaxUint64
+		}
+		total = sum
+	}
+	return total
+}
+
+// existingAncestor returns dir or its closest ancestor that exists, since
+// the output directory may not have been created yet.
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
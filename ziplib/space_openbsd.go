@@ -0,0 +1,13 @@
+package ziplib
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem containing path.
+func freeSpace(path string) (uint64, bool) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.F_bavail) * uint64(st.F_bsize), true //nolint:gosec // Available blocks are never negative.
+}
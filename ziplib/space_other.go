@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || dragonfly || aix || openbsd || netbsd || solaris || windows)
+
+package ziplib
+
+// freeSpace reports that free space is unknown on this platform.
+func freeSpace(string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd || dragonfly || aix
+
+package ziplib
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem containing path.
+func freeSpace(path string) (uint64, bool) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), true //nolint:gosec,unconvert // Field types vary by platform.
+}
@@ -0,0 +1,15 @@
+//go:build netbsd || solaris
+
+package ziplib
+
+import "golang.org/x/sys/unix"
+
+// freeSpace returns the bytes available to unprivileged users on the
+// filesystem containing path.
+func freeSpace(path string) (uint64, bool) {
+	var st unix.Statvfs_t
+	if err := unix.Statvfs(path, &st); err != nil {
+		return 0, false
+	}
+	return st.Bavail * st.Frsize, true
+}
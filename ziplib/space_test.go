@@ -0,0 +1,88 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeHugeClaimZip creates an archive with one stored entry whose header
+// claims an uncompressed size far larger than any real filesystem.
+func writeHugeClaimZip(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "huge.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	fw, err := w.CreateRaw(&zip.FileHeader{
+		Name:               "huge.bin",
+		Method:             zip.Store,
+		CompressedSize64:   4,
+		UncompressedSize64: 1 << 62,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte("tiny")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnzipFreeSpacePreflight(t *testing.T) {
+	if _, ok := freeSpace(t.TempDir()); !ok {
+		t.Skip("free space is not available on this platform")
+	}
+	zipPath := writeHugeClaimZip(t)
+	extractDir := filepath.Join(t.TempDir(), "not", "yet", "created")
+
+	err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir})
+	var spaceErr *SpaceError
+	if !errors.As(err, &spaceErr) {
+		t.Fatalf("expected *SpaceError, got: %v", err)
+	}
+	if spaceErr.Need != 1<<62 {
+		t.Errorf("Need = %d, want %d", spaceErr.Need, uint64(1<<62))
+	}
+	if _, err := os.Stat(extractDir); !os.IsNotExist(err) {
+		t.Errorf("output dir created before preflight failed: %v", err)
+	}
+
+	err = Unzip(zipPath, UnzipOptions{OutputDir: extractDir, Force: true})
+	if errors.As(err, &spaceErr) {
+		t.Errorf("Force still returned *SpaceError: %v", err)
+	}
+}
+
+func TestExistingAncestor(t *testing.T) {
+	dir := t.TempDir()
+	if got := existingAncestor(filepath.Join(dir, "a", "b")); got != dir {
+		t.Errorf("existingAncestor = %q, want %q", got, dir)
+	}
+	if got := existingAncestor(dir); got != dir {
+		t.Errorf("existingAncestor = %q, want %q", got, dir)
+	}
+}
+
+func TestTotalSizeSaturates(t *testing.T) {
+	files := []*zip.File{
+		{FileHeader: zip.FileHeader{UncompressedSize64: math.MaxUint64 - 1}},
+		{FileHeader: zip.FileHeader{UncompressedSize64: 2}},
+		{FileHeader: zip.FileHeader{UncompressedSize64: 5}},
+	}
+	if got := totalSize(files); got != math.MaxUint64 {
+		t.Errorf("totalSize = %d, want %d", got, uint64(math.MaxUint64))
+	}
+	if got := totalSize(files[1:]); got != 7 {
+		t.Errorf("totalSize = %d, want 7", got)
+	}
+}
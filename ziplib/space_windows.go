@@ -0,0 +1,17 @@
+package ziplib
+
+import "golang.org/x/sys/windows"
+
+// freeSpace returns the bytes available to the caller on the volume
+// containing path.
+func freeSpace(path string) (uint64, bool) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, false
+	}
+	var avail uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &avail, nil, nil); err != nil {
+		return 0, false
+	}
+	return avail, true
+}
@@ -0,0 +1,66 @@
+//go:build unix
+
+package ziplib
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestZipSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.Mkdir("tree", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "tree/file.txt", "data")
+	if err := unix.Mkfifo("tree/pipe", 0o644); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+
+	var res Result
+	zipPath := filepath.Join(t.TempDir(), "skip.zip")
+	if err := Zip(zipPath, []string{"tree"}, ZipOptions{Recursive: true, Result: &res}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if res.Entries != 1 || res.Skipped != 1 {
+		t.Errorf("result = %+v, want the FIFO skipped", res)
+	}
+
+	zipPath = filepath.Join(t.TempDir(), "store.zip")
+	if err := Zip(zipPath, []string{"tree"}, ZipOptions{Recursive: true, StoreSpecialFiles: true}); err != nil {
+		t.Fatalf("Zip with StoreSpecialFiles: %v", err)
+	}
+	r, err := openArchive(zipPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var found bool
+	for _, f := range r.File {
+		if f.Name == "tree/pipe" {
+			found = true
+			if f.Mode()&os.ModeNamedPipe == 0 || f.UncompressedSize64 != 0 {
+				t.Errorf("pipe entry mode %v, size %d; want an empty named pipe", f.Mode(), f.UncompressedSize64)
+			}
+		}
+	}
+	if !found {
+		t.Error("FIFO not stored")
+	}
+}
+
+func TestProcessUmask(t *testing.T) {
+	old := unix.Umask(0o027)
+	defer unix.Umask(old)
+	if got := processUmask(); runtime.GOOS == "linux" && got != 0o027 {
+		t.Errorf("processUmask() = %o, want 027", got)
+	}
+	if got := unix.Umask(0o027); got != 0o027 {
+		t.Errorf("umask changed to %o", got)
+	}
+}
@@ -0,0 +1,132 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// statsLargest is the number of entries listed in ArchiveStats.Largest.
+const statsLargest = 5
+
+// ArchiveStats summarizes the entries of an archive.
+type ArchiveStats struct {
+	// Entries is the number of entries, of which Dirs are directories.
+	Entries int
+	Dirs    int
+	// UncompressedBytes and CompressedBytes are the total sizes of the
+	// entry data.
+	UncompressedBytes uint64
+	CompressedBytes   uint64
+	// Methods breaks the totals down by compression method, in order of
+	// method ID.
+	Methods []MethodStats
+	// Largest lists the largest files by uncompressed size, largest first.
+	Largest []ListEntry
+}
+
+// MethodStats holds the totals of the entries using one compression method.
+type MethodStats struct {
+	// Method is the method ID stored in the archive and Name its name,
+	// such as "deflate".
+	Method            uint16
+	Name              string
+	Entries           int
+	UncompressedBytes uint64
+	CompressedBytes   uint64
+}
+
+// Ratio returns the fraction of the uncompressed size saved by
+// compression, as shown by unzip -v: 0.75 means the data compressed to a
+// quarter of its size. It is zero for empty archives and negative if
+// compression grew the data.
+func (s ArchiveStats) Ratio() float64 {
+	return savedRatio(s.UncompressedBytes, s.CompressedBytes)
+}
+
+// Ratio is like ArchiveStats.Ratio for the entries using the method.
+func (s MethodStats) Ratio() float64 {
+	return savedRatio(s.UncompressedBytes, s.CompressedBytes)
+}
+
+func savedRatio(uncompressed, compressed uint64) float64 {
+	if uncompressed == 0 {
+		return 0
+	}
+	return 1 - float64(compressed)/float64(uncompressed)
+}
+
+// Stats returns a summary of the entries of the archive at zipPath.
+func Stats(zipPath string) (ArchiveStats, error) {
+	r, err := openArchive(zipPath, "")
+	if err != nil {
+		return ArchiveStats{}, err
+	}
+	defer r.Close()
+	return archiveStats(r.File), nil
+}
+
+// StatsReader is like Stats for an archive of the given size read from r.
+func StatsReader(r io.ReaderAt, size int64) (ArchiveStats, error) {
+	zr, err := openReader(r, size, "")
+	if err != nil {
+		return ArchiveStats{}, err
+	}
+	return archiveStats(zr.File), nil
+}
+
+func archiveStats(files []*zip.File) ArchiveStats {
+	var s ArchiveStats
+	methods := make(map[uint16]*MethodStats)
+	for _, f := range files {
+		s.Entries++
+		s.UncompressedBytes += f.UncompressedSize64
+		s.CompressedBytes += f.CompressedSize64
+		if f.FileInfo().IsDir() {
+			s.Dirs++
+		} else {
+			s.Largest = append(s.Largest, listEntry(&f.FileHeader))
+		}
+		m := methods[f.Method]
+		if m == nil {
+			m = &MethodStats{Method: f.Method, Name: methodName(f.Method)}
+			methods[f.Method] = m
+		}
+		m.Entries++
+		m.UncompressedBytes += f.UncompressedSize64
+		m.CompressedBytes += f.CompressedSize64
+	}
+	for _, m := range methods {
+		s.Methods = append(s.Methods, *m)
+	}
+	slices.SortFunc(s.Methods, func(x, y MethodStats) int { return cmp.Compare(x.Method, y.Method) })
+	slices.SortStableFunc(s.Largest, func(x, y ListEntry) int { return cmp.Compare(y.UncompressedSize, x.UncompressedSize) })
+	s.Largest = slices.Clip(s.Largest[:min(len(s.Largest), statsLargest)])
+	return s
+}
+
+// methodName returns the common name of the compression method id.
+func methodName(id uint16) string {
+	switch id {
+	case zip.Store:
+		return "store"
+	case zip.Deflate:
+		return "deflate"
+	case 9:
+		return "deflate64"
+	case methodBzip2:
+		return "bzip2"
+	case methodLZMA:
+		return "lzma"
+	case 93:
+		return "zstd"
+	case methodXZ:
+		return "xz"
+	case 99:
+		return "aes"
+	default:
+		return fmt.Sprintf("method %d", id)
+	}
+}
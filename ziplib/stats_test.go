@@ -0,0 +1,60 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	src := t.TempDir()
+	t.Chdir(src)
+	if err := os.Mkdir("dir", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, "dir/big.txt", strings.Repeat("a", 1000))
+	writeFile(t, "small.txt", "hi")
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+	err := Zip(zipPath, []string{"dir", "small.txt"}, ZipOptions{
+		Recursive:        true,
+		CompressionLevel: -1,
+		LevelOverrides:   []LevelOverride{{Pattern: "small.txt", Level: 0}},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	s, err := Stats(zipPath)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if s.Entries != 2 || s.Dirs != 0 || s.UncompressedBytes != 1002 {
+		t.Errorf("stats = %+v, want 2 files of 1002 bytes", s)
+	}
+	if len(s.Methods) != 2 || s.Methods[0].Name != "store" || s.Methods[1].Name != "deflate" {
+		t.Fatalf("methods = %+v, want store and deflate", s.Methods)
+	}
+	if m := s.Methods[0]; m.Entries != 1 || m.UncompressedBytes != 2 || m.Ratio() != 0 {
+		t.Errorf("store = %+v, want 1 uncompressed entry of 2 bytes", m)
+	}
+	if r := s.Ratio(); r <= 0.9 || r >= 1 {
+		t.Errorf("ratio = %v, want most of the repeated data saved", r)
+	}
+	if len(s.Largest) != 2 || s.Largest[0].Name != "dir/big.txt" {
+		t.Errorf("largest = %+v, want dir/big.txt first", s.Largest)
+	}
+}
+
+func TestStatsEmptyArchive(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "empty.zip")
+	writeTestZip(t, zipPath, "", &zip.FileHeader{Name: "d/", Method: zip.Store})
+	s, err := Stats(zipPath)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if s.Entries != 1 || s.Dirs != 1 || len(s.Largest) != 0 || s.Ratio() != 0 {
+		t.Errorf("stats = %+v, want one directory and no ratio", s)
+	}
+}
@@ -0,0 +1,36 @@
+package ziplib
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// UnzipStream is like Unzip for an archive read from r, such as standard
+// input or an HTTP response body. The central directory listing the
+// entries is at the end of an archive, so r is first copied to a temporary
+// file, which is removed before UnzipStream returns. With
+// opts.MaxArchiveSize set, copying stops past that size and a *LimitError
+// is returned.
+//
+// Entries whose sizes and CRC-32 only follow their data in a data
+// descriptor, as written by streaming writers such as Java's
+// ZipOutputStream, are extracted like any other, using the values
+// recorded in the central directory.
+func UnzipStream(r io.Reader, opts UnzipOptions) error {
+	f, err := os.CreateTemp("", "gozip-stream-*.zip")
+	if err != nil {
+		return fmt.Errorf("buffer archive: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if limit := opts.MaxArchiveSize; limit > 0 {
+		// One byte more than allowed is enough to tell it is too large.
+		r = io.LimitReader(r, limit+1)
+	}
+	size, err := io.Copy(f, r)
+	if err != nil {
+		return fmt.Errorf("buffer archive: %w", err)
+	}
+	return UnzipReader(f, size, opts)
+}
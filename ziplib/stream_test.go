@@ -0,0 +1,148 @@
+package ziplib
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+// descriptorZip builds a deflated single-entry archive the way streaming
+// writers such as Java's ZipOutputStream do: the local header carries no
+// sizes or CRC, which follow the data in a data descriptor. The descriptor
+// signature is optional, and a Zip64 local extra field makes the
+// descriptor sizes 8 bytes wide.
+func descriptorZip(t *testing.T, name, content string, signature, zip64 bool) []byte {
+	t.Helper()
+	var data bytes.Buffer
+	fw, err := flate.NewWriter(&data, flate.DefaultCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	crc := crc32.ChecksumIEEE([]byte(content))
+	csize, usize := uint32(data.Len()), uint32(len(content)) //nolint:gosec // Test data is small.
+
+	le := binary.LittleEndian
+	var extra []byte
+	if zip64 {
+		extra = le.AppendUint16(extra, extraZip64)
+		extra = le.AppendUint16(extra, 16)
+		extra = append(extra, make([]byte, 16)...)
+	}
+	var b []byte
+	b = le.AppendUint32(b, localHeaderSig)
+	b = le.AppendUint16(b, 45)
+	b = le.AppendUint16(b, flagDataDescriptor)
+	b = le.AppendUint16(b, 8)
+	b = le.AppendUint32(b, 0)                  // Modification time and date.
+	b = le.AppendUint32(b, 0)                  // CRC-32.
+	b = le.AppendUint32(b, 0)                  // Compressed size.
+	b = le.AppendUint32(b, 0)                  // Uncompressed size.
+	b = le.AppendUint16(b, uint16(len(name)))  //nolint:gosec // Test names are short.
+	b = le.AppendUint16(b, uint16(len(extra))) //nolint:gosec // Test extras are short.
+	b = append(b, name...)
+	b = append(b, extra...)
+	b = append(b, data.Bytes()...)
+	if signature {
+		b = le.AppendUint32(b, dataDescriptorSig)
+	}
+	b = le.AppendUint32(b, crc)
+	if zip64 {
+		b = le.AppendUint64(b, uint64(csize))
+		b = le.AppendUint64(b, uint64(usize))
+	} else {
+		b = le.AppendUint32(b, csize)
+		b = le.AppendUint32(b, usize)
+	}
+
+	cdOff := len(b)
+	b = le.AppendUint32(b, 0x02014b50)
+	b = le.AppendUint16(b, 45) // Version made by.
+	b = le.AppendUint16(b, 45) // Version needed.
+	b = le.AppendUint16(b, flagDataDescriptor)
+	b = le.AppendUint16(b, 8)
+	b = le.AppendUint32(b, 0)
+	b = le.AppendUint32(b, crc)
+	b = le.AppendUint32(b, csize)
+	b = le.AppendUint32(b, usize)
+	b = le.AppendUint16(b, uint16(len(name))) //nolint:gosec // Test names are short.
+	b = append(b, make([]byte, 12)...)        // Extra and comment lengths, disk, attributes.
+	b = le.AppendUint32(b, 0)                 // Local header offset.
+	b = append(b, name...)
+	cdLen := len(b) - cdOff
+
+	b = le.AppendUint32(b, 0x06054b50)
+	b = le.AppendUint32(b, 0) // Disk numbers.
+	b = le.AppendUint16(b, 1)
+	b = le.AppendUint16(b, 1)
+	b = le.AppendUint32(b, uint32(cdLen)) //nolint:gosec // Test archives are small.
+	b = le.AppendUint32(b, uint32(cdOff)) //nolint:gosec // Test archives are small.
+	return le.AppendUint16(b, 0)
+}
+
+func TestUnzipStreamDataDescriptors(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		signature, zip64 bool
+	}{
+		{"signature", true, false},
+		{"no signature", false, false},
+		{"zip64", true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := descriptorZip(t, "a.txt", "streamed content\n", tt.signature, tt.zip64)
+			dest := t.TempDir()
+			var res Result
+			if err := UnzipStream(bytes.NewBuffer(archive), UnzipOptions{OutputDir: dest, Result: &res}); err != nil {
+				t.Fatalf("UnzipStream: %v", err)
+			}
+			if got := readFile(t, filepath.Join(dest, "a.txt")); got != "streamed content\n" {
+				t.Errorf("a.txt = %q", got)
+			}
+			if res.Entries != 1 {
+				t.Errorf("entries = %d, want 1", res.Entries)
+			}
+		})
+	}
+}
+
+func TestUnzipStreamMaxArchiveSize(t *testing.T) {
+	archive := descriptorZip(t, "a.txt", "streamed content\n", true, false)
+	size := int64(len(archive))
+
+	var limitErr *LimitError
+	r := &countingReader{r: bytes.NewReader(append(archive, make([]byte, 1<<20)...))}
+	err := UnzipStream(r, UnzipOptions{OutputDir: t.TempDir(), MaxArchiveSize: size})
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxArchiveSize" {
+		t.Fatalf("UnzipStream = %v, want a MaxArchiveSize *LimitError", err)
+	}
+	if r.n > size+1 {
+		t.Errorf("read %d bytes, want at most %d", r.n, size+1)
+	}
+
+	if err := UnzipStream(bytes.NewReader(archive), UnzipOptions{OutputDir: t.TempDir(), MaxArchiveSize: size}); err != nil {
+		t.Errorf("UnzipStream at the limit: %v", err)
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
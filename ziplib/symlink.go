@@ -0,0 +1,260 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxLinkTarget bounds the size of a symlink target read from an archive.
+const maxLinkTarget = 4096
+
+// pendingLink is a symlink entry to be created, or with
+// MaterializeSymlinks copied from its target, once all other entries have
+// been extracted.
+type pendingLink struct {
+	name     string
+	destPath string
+	target   string
+}
+
+// readLinkTarget returns the target stored as the content of a symlink entry.
+func readLinkTarget(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("open entry %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(io.LimitReader(rc, maxLinkTarget+1))
+	if err != nil {
+		return "", fmt.Errorf("read link %s: %w", f.Name, err)
+	}
+	if len(b) > maxLinkTarget {
+		return "", fmt.Errorf("link target too long: %s", f.Name)
+	}
+	return string(b), nil
+}
+
+// withinDir reports whether the absolute path p is dir or inside it.
+func withinDir(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+string(os.PathSeparator))
+}
+
+// linkTargetWithin reports whether the link target, relative to the
+// directory dir, stays inside the output directory. The target is walked
+// one element at a time rather than cleaned, since "a/.." is not dir if a
+// is a link; stepping into or out of a link, whether already on disk or
+// queued to be created, is refused.
+func (x *extractor) linkTargetWithin(dir, target string) bool {
+	p := dir
+	for _, elem := range strings.FieldsFunc(target, func(c rune) bool { return c == '/' || c == os.PathSeparator }) {
+		if elem == "." {
+			continue
+		}
+		if x.isLink(p) {
+			return false
+		}
+		if elem == ".." {
+			p = filepath.Dir(p)
+		} else {
+			p = filepath.Join(p, elem)
+		}
+		if !withinDir(p, x.absOutputDir) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLink reports whether the absolute path p is a symlink on disk or one
+// of the links queued to be created.
+func (x *extractor) isLink(p string) bool {
+	if x.linkDests[p] {
+		return true
+	}
+	fi, err := os.Lstat(p)
+	return err == nil && fi.Mode()&os.ModeSymlink != 0
+}
+
+// checkParents rejects the entry name extracted to the absolute path
+// absDest if a directory between the output directory and it is a
+// symlink, whether extracted earlier from this archive or left by another
+// one. Otherwise a chain of links that each point inside the output
+// directory, such as sub -> . and sub/esc -> .., could carry later
+// entries out of it.
+func (x *extractor) checkParents(name, absDest string) error {
+	rel, err := filepath.Rel(x.absOutputDir, filepath.Dir(absDest))
+	if err != nil || rel == "." {
+		return nil // Directly in the output directory.
+	}
+	p := x.absOutputDir
+	for _, elem := range strings.Split(rel, string(os.PathSeparator)) {
+		p = filepath.Join(p, elem)
+		fi, err := os.Lstat(longPath(p))
+		if err != nil {
+			return nil // Missing directories are created as real ones.
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("illegal path through symlink: %s", name)
+		}
+	}
+	return nil
+}
+
+// extractSymlink queues the symlink entry f to be created at destPath by
+// createLinks. Targets that point outside the output directory are
+// rejected here already, so that OnError sees them with their entry.
+func (x *extractor) extractSymlink(f *zip.File, destPath string) error {
+	target, err := readLinkTarget(f)
+	if err != nil {
+		return err
+	}
+	absDest, err := filepath.Abs(destPath)
+	if err != nil {
+		return fmt.Errorf("resolve path: %w", err)
+	}
+	if filepath.IsAbs(target) || !x.linkTargetWithin(filepath.Dir(absDest), target) {
+		return fmt.Errorf("illegal symlink target: %s -> %s", f.Name, target)
+	}
+	x.mu.Lock()
+	x.pendingLinks = append(x.pendingLinks, pendingLink{name: f.Name, destPath: destPath, target: target})
+	x.mu.Unlock()
+	return nil
+}
+
+// createLinks creates the queued symlinks one at a time, after every
+// other entry has been extracted, so that no file is ever written through
+// a link from the archive. Each link is checked again against all the
+// queued ones, since a link created later could otherwise redirect the
+// target of an earlier one, as sub -> . does with f -> sub/../victim.
+func (x *extractor) createLinks() error {
+	if len(x.pendingLinks) == 0 {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(x.absOutputDir)
+	if err != nil {
+		return fmt.Errorf("resolve output dir: %w", err)
+	}
+	x.linkDests = make(map[string]bool, len(x.pendingLinks))
+	for _, l := range x.pendingLinks {
+		if absDest, err := filepath.Abs(l.destPath); err == nil {
+			x.linkDests[absDest] = true
+		}
+	}
+	for _, l := range x.pendingLinks {
+		if err := x.createLink(root, l); err != nil {
+			if err := x.linkFailed(l.name, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createLink creates the queued link l. Its directory, with all symlinks
+// resolved, must be inside root, the resolved output directory. If the
+// platform cannot create the link, the target is written to a regular
+// file instead.
+func (x *extractor) createLink(root string, l pendingLink) error {
+	absDest, err := filepath.Abs(l.destPath)
+	if err != nil {
+		return fmt.Errorf("resolve path: %w", err)
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(absDest))
+	if err != nil {
+		return fmt.Errorf("resolve path: %w", err)
+	}
+	if !withinDir(dir, root) {
+		return fmt.Errorf("illegal path through symlink: %s", l.name)
+	}
+	if !x.linkTargetWithin(filepath.Dir(absDest), l.target) {
+		return fmt.Errorf("illegal symlink target: %s -> %s", l.name, l.target)
+	}
+
+	// The conflict policy has already allowed replacing an existing file.
+	if _, err := os.Lstat(l.destPath); err == nil {
+		if err := os.Remove(l.destPath); err != nil {
+			return fmt.Errorf("remove %s: %w", l.destPath, err)
+		}
+	}
+
+	if x.opts.MaterializeSymlinks {
+		return x.materializeLink(l)
+	}
+	if err := os.Symlink(l.target, l.destPath); err != nil {
+		if werr := os.WriteFile(l.destPath, []byte(l.target), x.modes.fileMode); werr != nil {
+			return fmt.Errorf("write link %s: %w", l.destPath, werr)
+		}
+		x.degrade(Degradation{
+			Name:   l.name,
+			Kind:   DegradedSymlink,
+			Detail: fmt.Sprintf("stored target %q as a file: %v", l.target, err),
+		})
+		return nil
+	}
+	x.log.emit(slog.LevelInfo, "linking", fmt.Sprintf("    linking: %s -> %s\n", l.destPath, l.target),
+		entryAttr(l.name), slog.String("path", l.destPath), slog.String("target", l.target))
+	return nil
+}
+
+// linkFailed handles the failure err of the queued link name as OnError
+// says. The entry can no longer be retried, so only ErrorSkip goes on; it
+// returns nil then, and err if extraction must stop.
+func (x *extractor) linkFailed(name string, err error) error {
+	if x.opts.OnError == nil || x.opts.OnError(name, err) != ErrorSkip {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.failures = append(x.failures, EntryError{Entry: name, Err: err})
+	x.result.Entries-- // Counted when it was queued.
+	x.result.Skipped++
+	return nil
+}
+
+// materializeLink replaces the queued link l with a copy of its target
+// file. Targets that are missing or are not regular files are reported as
+// degraded and stored as text, as when links cannot be created.
+func (x *extractor) materializeLink(l pendingLink) error {
+	src := filepath.Join(filepath.Dir(l.destPath), l.target)
+	fi, err := os.Stat(src)
+	if err != nil || !fi.Mode().IsRegular() {
+		if werr := os.WriteFile(l.destPath, []byte(l.target), x.modes.fileMode); werr != nil {
+			return fmt.Errorf("write link %s: %w", l.destPath, werr)
+		}
+		x.degrade(Degradation{
+			Name:   l.name,
+			Kind:   DegradedSymlink,
+			Detail: fmt.Sprintf("target %q is not an extracted file; stored as text", l.target),
+		})
+		return nil
+	}
+	if err := copyFile(src, l.destPath, fi.Mode().Perm()); err != nil {
+		return err
+	}
+	x.log.emit(slog.LevelInfo, "copying", fmt.Sprintf("    copying: %s -> %s\n", l.destPath, l.target),
+		entryAttr(l.name), slog.String("path", l.destPath), slog.String("target", l.target))
+	return nil
+}
+
+// copyFile copies the regular file src to dst with the given mode.
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", src, err)
+	}
+	defer in.Close()
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	defer w.Close()
+	if _, err := io.Copy(w, in); err != nil {
+		return fmt.Errorf("copy %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// symlinkHeader returns a header for a symlink entry; its content is the target.
+func symlinkHeader(name string) *zip.FileHeader {
+	h := &zip.FileHeader{Name: name}
+	h.SetMode(os.ModeSymlink | 0o777)
+	return h
+}
+
+// writeLinkZip creates an archive holding data.txt and a link to it.
+func writeLinkZip(t *testing.T, target string) string {
+	t.Helper()
+	zipPath := filepath.Join(t.TempDir(), "links.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, e := range []struct {
+		h       *zip.FileHeader
+		content string
+	}{
+		{&zip.FileHeader{Name: "dir/data.txt"}, "payload"},
+		{symlinkHeader("dir/link"), target},
+	} {
+		fw, err := w.CreateHeader(e.h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zipPath
+}
+
+func TestUnzipSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks may require privileges on windows")
+	}
+	zipPath := writeLinkZip(t, "data.txt")
+	extractDir := t.TempDir()
+
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+
+	link := filepath.Join(extractDir, "dir", "link")
+	target, err := os.Readlink(link)
+	if err != nil {
+		t.Fatalf("expected symlink: %v", err)
+	}
+	if target != "data.txt" {
+		t.Errorf("link target = %q, want %q", target, "data.txt")
+	}
+}
+
+func TestUnzipMaterializeSymlinks(t *testing.T) {
+	zipPath := writeLinkZip(t, "data.txt")
+	extractDir := t.TempDir()
+
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, MaterializeSymlinks: true}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+
+	link := filepath.Join(extractDir, "dir", "link")
+	fi, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.Mode().IsRegular() {
+		t.Errorf("expected regular file, got mode %v", fi.Mode())
+	}
+	if got := readFile(t, link); got != "payload" {
+		t.Errorf("materialized content = %q, want %q", got, "payload")
+	}
+}
+
+func TestUnzipMaterializeMissingTargetDegrades(t *testing.T) {
+	zipPath := writeLinkZip(t, "missing.txt")
+
+	var degraded []Degradation
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir:           t.TempDir(),
+		MaterializeSymlinks: true,
+		OnDegraded:          func(d Degradation) { degraded = append(degraded, d) },
+	})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if len(degraded) != 1 || degraded[0].Kind != DegradedSymlink || degraded[0].Name != "dir/link" {
+		t.Errorf("degraded = %+v", degraded)
+	}
+}
+
+func TestUnzipRejectsEscapingSymlink(t *testing.T) {
+	for _, target := range []string{"../../outside", "/etc"} {
+		t.Run(target, func(t *testing.T) {
+			zipPath := writeLinkZip(t, target)
+			err := Unzip(zipPath, UnzipOptions{OutputDir: t.TempDir()})
+			if err == nil || !strings.Contains(err.Error(), "illegal symlink target") {
+				t.Fatalf("expected illegal symlink target error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnzipRejectsChainedSymlinkEscape(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs privileges on Windows")
+	}
+	entries := []struct {
+		h       *zip.FileHeader
+		content string
+	}{
+		{symlinkHeader("sub"), "."},
+		{symlinkHeader("sub/esc"), ".."},
+		{&zip.FileHeader{Name: "esc/pwned.txt"}, "pwned"},
+	}
+	writeZip := func(path string, from, to int) {
+		t.Helper()
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		w := zip.NewWriter(f)
+		for _, e := range entries[from:to] {
+			fw, err := w.CreateHeader(e.h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("one archive", func(t *testing.T) {
+		parent := t.TempDir()
+		outDir := filepath.Join(parent, "out")
+		zipPath := filepath.Join(t.TempDir(), "chain.zip")
+		writeZip(zipPath, 0, len(entries))
+		if err := Unzip(zipPath, UnzipOptions{OutputDir: outDir, Concurrency: 1}); err == nil {
+			t.Error("expected an error for a chain of links leaving the output directory")
+		}
+		if _, err := os.Stat(filepath.Join(parent, "pwned.txt")); err == nil {
+			t.Error("pwned.txt was written outside the output directory")
+		}
+	})
+	t.Run("two archives", func(t *testing.T) {
+		parent := t.TempDir()
+		outDir := filepath.Join(parent, "out")
+		first, second := filepath.Join(t.TempDir(), "first.zip"), filepath.Join(t.TempDir(), "second.zip")
+		writeZip(first, 0, 1)
+		writeZip(second, 1, len(entries))
+		if err := Unzip(first, UnzipOptions{OutputDir: outDir}); err != nil {
+			t.Fatalf("Unzip first: %v", err)
+		}
+		if err := Unzip(second, UnzipOptions{OutputDir: outDir, Concurrency: 1}); err == nil {
+			t.Error("expected an error for a link under a link of the earlier archive")
+		}
+		if _, err := os.Stat(filepath.Join(parent, "pwned.txt")); err == nil {
+			t.Error("pwned.txt was written outside the output directory")
+		}
+	})
+}
+
+func TestUnzipRejectsLinkRedirectedByLaterLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks needs privileges on Windows")
+	}
+	zipPath := filepath.Join(t.TempDir(), "redirect.zip")
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for _, e := range []struct {
+		h       *zip.FileHeader
+		content string
+	}{
+		{symlinkHeader("f.gounzip-tmp"), "sub/../victim"},
+		{symlinkHeader("sub"), "."},
+		{&zip.FileHeader{Name: "f"}, "pwned"},
+	} {
+		fw, err := w.CreateHeader(e.h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", n), func(t *testing.T) {
+			parent := t.TempDir()
+			outDir := filepath.Join(parent, "out")
+			err := Unzip(zipPath, UnzipOptions{OutputDir: outDir, Concurrency: n})
+			if err == nil || !strings.Contains(err.Error(), "illegal symlink target") {
+				t.Errorf("expected illegal symlink target error, got: %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(parent, "victim")); err == nil {
+				t.Error("victim was written outside the output directory")
+			}
+			if got := readFile(t, filepath.Join(outDir, "f")); got != "pwned" {
+				t.Errorf("f = %q, want the entry contents", got)
+			}
+		})
+	}
+}
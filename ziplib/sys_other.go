@@ -0,0 +1,23 @@
+//go:build !unix
+
+package ziplib
+
+import (
+	"os"
+	"time"
+)
+
+// fileOwner reports no owner on platforms without Unix UIDs and GIDs.
+func fileOwner(string) (uid, gid uint32, ok bool) {
+	return 0, 0, false
+}
+
+// fileTimes reports no access or creation times on non-Unix platforms.
+func fileTimes(string) (atime, btime time.Time, ok bool) {
+	return time.Time{}, time.Time{}, false
+}
+
+// processUmask reports an empty mask on platforms without a umask.
+func processUmask() os.FileMode {
+	return 0
+}
@@ -0,0 +1,79 @@
+//go:build unix
+
+package ziplib
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileOwner returns the UID and GID of the file at path.
+func fileOwner(path string) (uid, gid uint32, ok bool) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return 0, 0, false
+	}
+	return st.Uid, st.Gid, true
+}
+
+// fileTimes returns the access time of the file at path and its
+// creation time, which is zero where the system does not record it.
+func fileTimes(path string) (atime, btime time.Time, ok bool) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	atime = time.Unix(int64(st.Atim.Sec), int64(st.Atim.Nsec)) //nolint:unconvert // Field types vary by platform.
+	btime, _ = birthTime(path, &st)
+	return atime, btime, true
+}
+
+// umaskOnce guards umask, the mask captured by processUmask where it
+// cannot be read without changing it.
+var (
+	umaskOnce sync.Once
+	umask     os.FileMode
+)
+
+// processUmask returns the current file mode creation mask. Linux reports
+// it in /proc/self/status. Elsewhere it can only be read by setting it, so
+// it is briefly changed and restored, once per process: a file created by
+// another goroutine in that moment gets the mask 022, and later changes
+// to the mask are not seen.
+func processUmask() os.FileMode {
+	if mask, ok := procUmask(); ok {
+		return mask
+	}
+	umaskOnce.Do(func() {
+		old := unix.Umask(0o022)
+		unix.Umask(old)
+		umask = os.FileMode(old) //nolint:gosec // The mask only holds permission bits.
+	})
+	return umask
+}
+
+// procUmask reads the file mode creation mask from the Umask line of
+// /proc/self/status, which Linux has since 4.7.
+func procUmask() (os.FileMode, bool) {
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		v, ok := strings.CutPrefix(line, "Umask:")
+		if !ok {
+			continue
+		}
+		mask, err := strconv.ParseUint(strings.TrimSpace(v), 8, 32)
+		if err != nil {
+			return 0, false
+		}
+		return os.FileMode(mask).Perm(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,242 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+
+	"golang.org/x/text/transform"
+)
+
+// LineEnding selects the line endings text files are converted to.
+type LineEnding int
+
+const (
+	// LineEndingKeep leaves files as they are.
+	LineEndingKeep LineEnding = iota
+	// LineEndingCRLF converts LF line endings to CR LF, like zip -l.
+	LineEndingCRLF
+	// LineEndingLF converts CR LF line endings to LF, like zip -ll.
+	LineEndingLF
+)
+
+// TextConversion selects the entries Unzip converts as text.
+type TextConversion int
+
+const (
+	// TextConvertNone extracts every entry as it is stored.
+	TextConvertNone TextConversion = iota
+	// TextConvertMarked converts the entries marked as text in their
+	// internal attributes, like unzip -a.
+	TextConvertMarked
+	// TextConvertAll converts every file, like unzip -aa.
+	TextConvertAll
+)
+
+// nativeLineEnding is the line ending of text files on this system.
+func nativeLineEnding() LineEnding {
+	if runtime.GOOS == "windows" {
+		return LineEndingCRLF
+	}
+	return LineEndingLF
+}
+
+// textSampleLen is how much of a file is looked at to decide whether it
+// is text.
+const textSampleLen = 4096
+
+// attrText is the bit of the internal file attributes that marks an
+// entry as text.
+const attrText = 1
+
+// isText reports whether sample looks like the start of a text file: it
+// is not empty and holds no control characters other than those common
+// in text (BEL, BS, TAB, LF, VT, FF, CR and ESC), as Info-ZIP decides.
+// Bytes above 0x7f are taken as text, so UTF-8 and legacy code pages are.
+func isText(sample []byte) bool {
+	if len(sample) == 0 {
+		return false
+	}
+	for _, c := range sample {
+		if c < 7 || (c > 13 && c < 32 && c != 27) {
+			return false
+		}
+	}
+	return true
+}
+
+// textReader samples r and, if it is text and opts ask for line endings
+// to be converted, returns it converted and true. Otherwise the returned
+// reader yields r unchanged.
+func (a *archiver) textReader(r io.Reader) (io.Reader, bool, error) {
+	to := a.opts.ConvertLineEndings
+	if to == LineEndingKeep {
+		return r, false, nil
+	}
+	sample := make([]byte, textSampleLen)
+	n, err := io.ReadFull(r, sample)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, false, err //nolint:wrapcheck // Callers wrap with the file name.
+	}
+	r = io.MultiReader(bytes.NewReader(sample[:n]), r)
+	if !isText(sample[:n]) {
+		return r, false, nil
+	}
+	return newLineEndingReader(r, to), true, nil
+}
+
+// lineEndingReader converts the line endings of what it reads, as
+// lineEndings does.
+type lineEndingReader struct {
+	r    io.Reader
+	conv lineEndings
+	in   []byte
+	out  []byte
+	off  int
+	err  error
+}
+
+func newLineEndingReader(r io.Reader, to LineEnding) *lineEndingReader {
+	return &lineEndingReader{r: r, conv: lineEndings{crlf: to == LineEndingCRLF}, in: make([]byte, 32<<10)}
+}
+
+func (l *lineEndingReader) Read(p []byte) (int, error) {
+	for l.off == len(l.out) {
+		if l.err != nil {
+			return 0, l.err
+		}
+		var n int
+		n, l.err = l.r.Read(l.in)
+		l.out, l.off = l.conv.convert(l.out[:0], l.in[:n]), 0
+		if l.err != nil {
+			l.out = l.conv.flush(l.out)
+		}
+	}
+	n := copy(p, l.out[l.off:])
+	l.off += n
+	return n, nil
+}
+
+// lineEndingWriter converts the line endings of what is written to it, as
+// lineEndings does, before passing it on to w. Close writes what is held
+// back, without closing w.
+type lineEndingWriter struct {
+	w    io.Writer
+	conv lineEndings
+	out  []byte
+}
+
+func newLineEndingWriter(w io.Writer, to LineEnding) *lineEndingWriter {
+	return &lineEndingWriter{w: w, conv: lineEndings{crlf: to == LineEndingCRLF}}
+}
+
+func (l *lineEndingWriter) Write(p []byte) (int, error) {
+	l.out = l.conv.convert(l.out[:0], p)
+	if _, err := l.w.Write(l.out); err != nil {
+		return 0, err //nolint:wrapcheck // Pass-through writer.
+	}
+	return len(p), nil
+}
+
+func (l *lineEndingWriter) Close() error {
+	_, err := l.w.Write(l.conv.flush(l.out[:0]))
+	return err //nolint:wrapcheck // Pass-through writer.
+}
+
+// lineEndings converts line endings in a stream given in pieces: CR is
+// added before a line feed that has none, or every CR directly before a
+// line feed is removed. Converting text again leaves it unchanged, which
+// Deduplicate relies on, as it matches files by their stored contents.
+type lineEndings struct {
+	crlf bool
+	prev byte // last byte seen, for LF to CR LF
+	crs  int  // CRs held back until the next byte, for CR LF to LF
+}
+
+// convert appends b, converted, to out.
+func (l *lineEndings) convert(out, b []byte) []byte {
+	for _, c := range b {
+		switch {
+		case l.crlf:
+			if c == '\n' && l.prev != '\r' {
+				out = append(out, '\r')
+			}
+			out = append(out, c)
+			l.prev = c
+		case c == '\r':
+			l.crs++
+		case c == '\n':
+			l.crs = 0
+			out = append(out, c)
+		default:
+			for ; l.crs > 0; l.crs-- {
+				out = append(out, '\r')
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// flush appends the CRs held back at the end of the stream to out.
+func (l *lineEndings) flush(out []byte) []byte {
+	for ; l.crs > 0; l.crs-- {
+		out = append(out, '\r')
+	}
+	return out
+}
+
+// markText sets the text bit in the internal attributes of the records
+// of dir, a central directory, whose names are in text.
+func markText(dir []byte, text map[string]bool) error {
+	if len(text) == 0 {
+		return nil
+	}
+	return centralRecords(dir, func(name string, rec []byte) {
+		if text[name] {
+			rec[36] |= attrText
+		}
+	})
+}
+
+// markText records the entry just created as name as converted text, if
+// text is set.
+func (a *archiver) markText(name string, text bool) {
+	if !text {
+		return
+	}
+	if a.text == nil {
+		a.text = make(map[string]bool)
+	}
+	a.text[name] = true
+}
+
+// textWriter returns w wrapped to convert the contents of f as text, if
+// ConvertText selects it: from the Encoding code page to UTF-8, if one is
+// set, and to the line endings of this system. The returned function
+// flushes what the conversion holds back; it does not close w.
+func (x *extractor) textWriter(f *zip.File, w io.Writer) (io.Writer, func() error) {
+	switch x.opts.ConvertText {
+	case TextConvertMarked:
+		if x.central[f].internalAttrs&attrText == 0 {
+			return w, func() error { return nil }
+		}
+	case TextConvertAll:
+	default:
+		return w, func() error { return nil }
+	}
+	lw := newLineEndingWriter(w, nativeLineEnding())
+	if x.textCharset == nil {
+		return lw, lw.Close
+	}
+	dw := transform.NewWriter(lw, x.textCharset.NewDecoder())
+	return dw, func() error {
+		if err := dw.Close(); err != nil {
+			return fmt.Errorf("decode %s: %w", f.Name, err)
+		}
+		return lw.Close()
+	}
+}
@@ -0,0 +1,215 @@
+package ziplib
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		sample string
+		want   bool
+	}{
+		{"", false},
+		{"hello\r\n\tworld\f\n", true},
+		{"\x1b[1mbold\x1b[0m\n", true},
+		{"caf\xc3\xa9\n", true},
+		{"a\x00b", false},
+		{"\x89PNG\r\n\x1a\n", false},
+	}
+	for _, tt := range tests {
+		if got := isText([]byte(tt.sample)); got != tt.want {
+			t.Errorf("isText(%q) = %v, want %v", tt.sample, got, tt.want)
+		}
+	}
+}
+
+func TestLineEndingReader(t *testing.T) {
+	tests := []struct {
+		in   string
+		to   LineEnding
+		want string
+	}{
+		{"a\nb\r\nc\n", LineEndingCRLF, "a\r\nb\r\nc\r\n"},
+		{"\n\n", LineEndingCRLF, "\r\n\r\n"},
+		{"a\r\nb\nc\r\n", LineEndingLF, "a\nb\nc\n"},
+		{"a\rb\r\r\nc\r", LineEndingLF, "a\rb\nc\r"},
+	}
+	for _, tt := range tests {
+		for _, r := range []io.Reader{strings.NewReader(tt.in), iotest.OneByteReader(strings.NewReader(tt.in))} {
+			b, err := io.ReadAll(newLineEndingReader(r, tt.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("convert %q to %d = %q, want %q", tt.in, tt.to, b, tt.want)
+			}
+			// Converting again changes nothing.
+			again, err := io.ReadAll(newLineEndingReader(strings.NewReader(tt.want), tt.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != tt.want {
+				t.Errorf("convert %q again to %d = %q", tt.want, tt.to, again)
+			}
+		}
+	}
+}
+
+func TestZipConvertLineEndings(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "unix.txt"), "one\ntwo\n")
+	writeFile(t, filepath.Join(src, "dos.txt"), "one\r\ntwo\r\n")
+	writeFile(t, filepath.Join(src, "data.bin"), "one\ntwo\x00\n")
+	t.Chdir(src)
+	files := []string{"unix.txt", "dos.txt", "data.bin"}
+
+	tests := []struct {
+		to   LineEnding
+		want map[string]string
+	}{
+		{LineEndingCRLF, map[string]string{"unix.txt": "one\r\ntwo\r\n", "dos.txt": "one\r\ntwo\r\n", "data.bin": "one\ntwo\x00\n"}},
+		{LineEndingLF, map[string]string{"unix.txt": "one\ntwo\n", "dos.txt": "one\ntwo\n", "data.bin": "one\ntwo\x00\n"}},
+	}
+	for _, tt := range tests {
+		for _, opts := range []ZipOptions{{}, {Concurrency: 4}, {Deduplicate: true}} {
+			opts.ConvertLineEndings = tt.to
+			zipPath := filepath.Join(t.TempDir(), "text.zip")
+			if err := Zip(zipPath, files, opts); err != nil {
+				t.Fatalf("Zip: %v", err)
+			}
+			got, _ := zipContents(t, zipPath)
+			for name, want := range tt.want {
+				if got[name] != want {
+					t.Errorf("%+v: %s = %q, want %q", opts, name, got[name], want)
+				}
+			}
+			text := internalText(t, zipPath)
+			if !text["unix.txt"] || !text["dos.txt"] || text["data.bin"] {
+				t.Errorf("%+v: text entries %v, want unix.txt and dos.txt", opts, text)
+			}
+		}
+	}
+}
+
+func TestGrowConvertLineEndings(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a\n")
+	writeFile(t, filepath.Join(src, "b.txt"), "b\n")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "grow.zip")
+	if err := Zip(zipPath, []string{"a.txt"}, ZipOptions{}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	if err := Grow(zipPath, []string{"b.txt"}, ZipOptions{ConvertLineEndings: LineEndingCRLF}); err != nil {
+		t.Fatalf("Grow: %v", err)
+	}
+	got, _ := zipContents(t, zipPath)
+	if got["a.txt"] != "a\n" || got["b.txt"] != "b\r\n" {
+		t.Errorf("contents %q", got)
+	}
+	if text := internalText(t, zipPath); text["a.txt"] || !text["b.txt"] {
+		t.Errorf("text entries %v, want b.txt", text)
+	}
+}
+
+func TestListText(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "a\n")
+	writeFile(t, filepath.Join(src, "b.bin"), "b\x00")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "list.zip")
+	if err := Zip(zipPath, []string{"a.txt", "b.bin"}, ZipOptions{ConvertLineEndings: LineEndingLF}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, e := range entries {
+		if want := e.Name == "a.txt"; e.Text != want {
+			t.Errorf("%s: Text = %v, want %v", e.Name, e.Text, want)
+		}
+		if !e.HasExtra {
+			t.Errorf("%s: HasExtra = false, want the timestamp extra", e.Name)
+		}
+	}
+}
+
+func TestUnzipConvertText(t *testing.T) {
+	src := t.TempDir()
+	writeFile(t, filepath.Join(src, "a.txt"), "one\r\ntwo\r\n")
+	writeFile(t, filepath.Join(src, "ko.txt"), "\xc7\xd1\xb1\xdb\r\n") // "한글" in cp949.
+	writeFile(t, filepath.Join(src, "b.bin"), "b\x00\r\n")
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "text.zip")
+	if err := Zip(zipPath, []string{"a.txt", "ko.txt", "b.bin"}, ZipOptions{ConvertLineEndings: LineEndingCRLF}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	eol := "\n"
+	if runtime.GOOS == "windows" {
+		eol = "\r\n"
+	}
+	tests := []struct {
+		opts UnzipOptions
+		want map[string]string
+	}{
+		{UnzipOptions{}, map[string]string{"a.txt": "one\r\ntwo\r\n", "ko.txt": "\xc7\xd1\xb1\xdb\r\n", "b.bin": "b\x00\r\n"}},
+		{UnzipOptions{ConvertText: TextConvertMarked}, map[string]string{"a.txt": "one" + eol + "two" + eol, "ko.txt": "\xc7\xd1\xb1\xdb" + eol, "b.bin": "b\x00\r\n"}},
+		{UnzipOptions{ConvertText: TextConvertMarked, Encoding: "cp949"}, map[string]string{"ko.txt": "한글" + eol, "b.bin": "b\x00\r\n"}},
+		{UnzipOptions{ConvertText: TextConvertAll}, map[string]string{"a.txt": "one" + eol + "two" + eol, "b.bin": "b\x00" + eol}},
+	}
+	for _, tt := range tests {
+		for _, concurrency := range []int{1, 4} {
+			opts := tt.opts
+			opts.OutputDir = t.TempDir()
+			opts.Concurrency = concurrency
+			if err := Unzip(zipPath, opts); err != nil {
+				t.Fatalf("Unzip(%+v): %v", tt.opts, err)
+			}
+			for name, want := range tt.want {
+				if got := readFile(t, filepath.Join(opts.OutputDir, name)); got != want {
+					t.Errorf("%+v: %s = %q, want %q", tt.opts, name, got, want)
+				}
+			}
+		}
+	}
+}
+
+// internalText returns the names of the entries of the archive at
+// zipPath marked as text in their internal attributes.
+func internalText(t *testing.T, zipPath string) map[string]bool {
+	t.Helper()
+	f, err := os.Open(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := centralDirectory(f, fi.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := make([]byte, d.length)
+	if _, err := f.ReadAt(dir, d.off); err != nil {
+		t.Fatal(err)
+	}
+	text := make(map[string]bool)
+	if err := centralRecords(dir, func(name string, rec []byte) {
+		if rec[36]&attrText != 0 {
+			text[name] = true
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
@@ -0,0 +1,181 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Flags of the extended timestamp extra field (0x5455).
+const (
+	extTimeMod    = 1 << 0
+	extTimeAccess = 1 << 1
+	extTimeCreate = 1 << 2
+)
+
+// dosEpoch is the earliest time representable as an MS-DOS timestamp.
+var dosEpoch = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// encodeExtTime builds the body of an extended timestamp extra field
+// from the modification, access and creation times, leaving out those
+// that are zero or outside the field's range of 1970 to 2106. It returns
+// nil if none is left.
+func encodeExtTime(mtime, atime, btime time.Time) []byte {
+	b := []byte{0}
+	for i, t := range []time.Time{mtime, atime, btime} {
+		if t.IsZero() || t.Unix() < 0 || t.Unix() > math.MaxUint32 {
+			continue
+		}
+		b[0] |= 1 << i
+		b = binary.LittleEndian.AppendUint32(b, uint32(t.Unix()))
+	}
+	if b[0] == 0 {
+		return nil
+	}
+	return b
+}
+
+// centralExtTimes returns dir, a central directory, with the extended
+// timestamp fields of its records cut down to the modification time, as
+// the central copy of the field carries no other time.
+func centralExtTimes(dir []byte) ([]byte, error) {
+	le := binary.LittleEndian
+	out := make([]byte, 0, len(dir))
+	err := centralRecords(dir, func(_ string, rec []byte) {
+		nameLen, extraLen := int(le.Uint16(rec[28:])), int(le.Uint16(rec[30:]))
+		start := centralHeaderLen + nameLen
+		var extra []byte
+		for _, f := range parseExtra(rec[start : start+extraLen]) {
+			if f.tag == extraExtTime && len(f.data) > 0 {
+				n := 1
+				if f.data[0]&extTimeMod != 0 {
+					n = min(5, len(f.data))
+				}
+				f.data = f.data[:n]
+			}
+			extra = appendExtra(extra, f.tag, f.data)
+		}
+		out = append(out, rec[:start]...)
+		le.PutUint16(out[len(out)-start+30:], uint16(len(extra))) //nolint:gosec // No longer than the original.
+		out = append(out, extra...)
+		out = append(out, rec[start+extraLen:]...)
+	})
+	return out, err
+}
+
+// decodeExtTime parses the body of an extended timestamp extra field.
+// Times that are not present are returned as zero values.
+func decodeExtTime(data []byte) (mtime, atime, btime time.Time, ok bool) {
+	if len(data) < 1 {
+		return time.Time{}, time.Time{}, time.Time{}, false
+	}
+	flags := data[0]
+	data = data[1:]
+	var times [3]time.Time
+	for i := range times {
+		if flags&(1<<i) == 0 {
+			continue
+		}
+		// The central directory copy may carry only the mtime even if the
+		// flags announce more.
+		if len(data) < 4 {
+			break
+		}
+		times[i] = time.Unix(int64(binary.LittleEndian.Uint32(data)), 0)
+		data = data[4:]
+	}
+	return times[0], times[1], times[2], flags&extTimeMod != 0 && !times[0].IsZero()
+}
+
+// timeToDOS converts t to MS-DOS date and time fields in t's location.
+// Times before 1980 are clamped to the DOS epoch.
+func timeToDOS(t time.Time) (date, tm uint16) {
+	if t.Before(dosEpoch) {
+		t = dosEpoch
+	}
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9) //nolint:gosec // Bounded by the DOS field layout.
+	tm = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)        //nolint:gosec // Bounded by the DOS field layout.
+	return date, tm
+}
+
+// setTimestamps records mtime in header as both an MS-DOS timestamp and an
+// extended timestamp extra field. When extended is true, the access time
+// of path is stored as well, with its creation time where the system
+// records one.
+//
+// The extra field is written here rather than by archive/zip, which only
+// stores the mtime, so header.Modified is cleared to keep the writer from
+// adding a second copy.
+func setTimestamps(header *zip.FileHeader, path string, mtime time.Time, extended bool) {
+	var atime, btime time.Time
+	if extended {
+		atime, btime, _ = fileTimes(path)
+	}
+	header.Modified = time.Time{}
+	header.ModifiedDate, header.ModifiedTime = timeToDOS(mtime) //nolint:staticcheck // Set directly to control the extra field.
+	if data := encodeExtTime(mtime, atime, btime); data != nil {
+		header.Extra = appendExtra(header.Extra, extraExtTime, data)
+	}
+}
+
+// entryTimes returns the access and modification times to restore for f.
+// The access time falls back to the modification time when the archive
+// does not record one.
+func entryTimes(f *zip.File) (atime, mtime time.Time) {
+	mtime = f.Modified
+	atime = mtime
+	if data, ok := findExtra(f.Extra, extraExtTime); ok {
+		if _, a, _, ok := decodeExtTime(data); ok && !a.IsZero() {
+			atime = a
+		}
+	}
+	return atime, mtime
+}
+
+// entryTimes is like the function entryTimes, but takes the access time
+// from the local header when the central copy of the extended timestamp
+// announces one without carrying it.
+func (x *extractor) entryTimes(f *zip.File) (atime, mtime time.Time) {
+	atime, mtime = entryTimes(f)
+	info, ok := x.central[f]
+	if !ok || !atime.Equal(mtime) || !announcesAccess(f) {
+		return atime, mtime
+	}
+	extra, err := localExtra(x.ra, info.offset)
+	if err != nil {
+		return atime, mtime
+	}
+	if data, ok := findExtra(extra, extraExtTime); ok {
+		if _, a, _, ok := decodeExtTime(data); ok && !a.IsZero() {
+			atime = a
+		}
+	}
+	return atime, mtime
+}
+
+// announcesAccess reports whether the extended timestamp of f announces
+// an access time.
+func announcesAccess(f *zip.File) bool {
+	data, ok := findExtra(f.Extra, extraExtTime)
+	return ok && len(data) > 0 && data[0]&extTimeAccess != 0
+}
+
+// localExtra reads the extra field of the local file header at off in r.
+func localExtra(r io.ReaderAt, off int64) ([]byte, error) {
+	var h [localHeaderLen]byte
+	if _, err := r.ReadAt(h[:], off); err != nil {
+		return nil, fmt.Errorf("read local header: %w", err)
+	}
+	if binary.LittleEndian.Uint32(h[:]) != localHeaderSig {
+		return nil, fmt.Errorf("read local header: %w", zip.ErrFormat)
+	}
+	nameLen := int64(binary.LittleEndian.Uint16(h[26:28]))
+	extra := make([]byte, binary.LittleEndian.Uint16(h[28:30]))
+	if _, err := r.ReadAt(extra, off+localHeaderLen+nameLen); err != nil {
+		return nil, fmt.Errorf("read local header: %w", err)
+	}
+	return extra, nil
+}
@@ -0,0 +1,175 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestExtTimeRoundTrip(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	atime := time.Unix(1700000100, 0)
+
+	tests := []struct {
+		name         string
+		atime, btime time.Time
+		size         int
+	}{
+		{"mtime only", time.Time{}, time.Time{}, 5},
+		{"mtime and atime", atime, time.Time{}, 9},
+		{"all times", atime, atime, 13},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeExtTime(mtime, tt.atime, tt.btime)
+			if len(data) != tt.size {
+				t.Errorf("encoded size = %d, want %d", len(data), tt.size)
+			}
+			m, a, b, ok := decodeExtTime(data)
+			if !ok || !m.Equal(mtime) || !a.Equal(tt.atime) || !b.Equal(tt.btime) {
+				t.Errorf("decodeExtTime = %v, %v, %v, %v", m, a, b, ok)
+			}
+		})
+	}
+}
+
+func TestEncodeExtTimeRange(t *testing.T) {
+	late := time.Unix(math.MaxUint32, 0)
+	tests := []struct {
+		name                string
+		mtime, atime, btime time.Time
+		want                []byte
+	}{
+		{"before 1970", time.Unix(-1, 0), time.Time{}, time.Time{}, nil},
+		{"after 2106", late.Add(time.Second), time.Time{}, time.Time{}, nil},
+		{"last second", late, time.Time{}, time.Time{}, []byte{extTimeMod, 0xff, 0xff, 0xff, 0xff}},
+		{"atime only in range", time.Unix(-1, 0), time.Unix(1, 0), time.Time{}, []byte{extTimeAccess, 1, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if got := encodeExtTime(tt.mtime, tt.atime, tt.btime); !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: encodeExtTime = %x, want %x", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeExtTimeCentralCopy(t *testing.T) {
+	// The central directory copy announces atime but only carries mtime.
+	data := []byte{extTimeMod | extTimeAccess, 0x00, 0xf1, 0x53, 0x65}
+	m, a, _, ok := decodeExtTime(data)
+	if !ok || m.Unix() != 0x6553f100 || !a.IsZero() {
+		t.Errorf("decodeExtTime = %v, %v, %v", m, a, ok)
+	}
+}
+
+func TestTimeToDOS(t *testing.T) {
+	date, tm := timeToDOS(time.Date(2024, 3, 15, 13, 45, 31, 0, time.UTC))
+	if want := uint16(15 + 3<<5 + 44<<9); date != want {
+		t.Errorf("date = %#x, want %#x", date, want)
+	}
+	if want := uint16(15 + 45<<5 + 13<<11); tm != want {
+		t.Errorf("time = %#x, want %#x", tm, want)
+	}
+
+	date, tm = timeToDOS(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC))
+	if date != 1<<5|1 || tm != 0 {
+		t.Errorf("pre-1980 time not clamped: date=%#x time=%#x", date, tm)
+	}
+}
+
+func TestZipWritesExtendedTimestamp(t *testing.T) {
+	src := setupTestDir(t)
+	path := filepath.Join(src, "hello.txt")
+	mtime := time.Date(2021, 6, 7, 8, 9, 11, 0, time.UTC)
+	atime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, atime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "times.zip")
+	if err := Zip(zipPath, []string{path}, ZipOptions{ExtendedTimestamps: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	f := r.File[0]
+	if !f.Modified.Equal(mtime) {
+		t.Errorf("Modified = %v, want %v", f.Modified, mtime)
+	}
+	count := 0
+	for _, field := range parseExtra(f.Extra) {
+		if field.tag == extraExtTime {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected exactly one extended timestamp field, got %d", count)
+	}
+	if data, _ := findExtra(f.Extra, extraExtTime); len(data) != 5 {
+		t.Errorf("central extended timestamp = %x, want the mtime only", data)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// The access time is only in the local header.
+	af, err := os.Open(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+	fi, err := af.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := centralInfos(af, fi.Size(), r.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := &extractor{central: infos, ra: af}
+	if gotAtime, _ := x.entryTimes(f); !gotAtime.Equal(atime) {
+		t.Errorf("atime = %v, want %v", gotAtime, atime)
+	}
+}
+
+func TestZipStripExtras(t *testing.T) {
+	src := setupTestDir(t)
+	path := filepath.Join(src, "hello.txt")
+	mtime := time.Date(2021, 6, 7, 8, 9, 10, 0, time.Local)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "stripped.zip")
+	opts := ZipOptions{StripExtras: true, ExtendedTimestamps: true}
+	if err := Zip(zipPath, []string{path}, opts); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	f := r.File[0]
+	if len(f.Extra) != 0 {
+		t.Errorf("unexpected extra fields %x", f.Extra)
+	}
+	// Without the extended timestamp, archive/zip reads the local MS-DOS
+	// time as if it were UTC.
+	want := time.Date(2021, 6, 7, 8, 9, 10, 0, time.UTC)
+	if !f.Modified.Equal(want) {
+		t.Errorf("Modified = %v, want %v", f.Modified, want)
+	}
+}
@@ -0,0 +1,140 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+// flagUTF8 is the general purpose bit flag (EFS) marking UTF-8 names.
+const flagUTF8 = 0x800
+
+// unicodePathVersion is the only version of the 0x7075 field.
+const unicodePathVersion = 1
+
+// setNameEncoding converts a non-ASCII name to the legacy code page enc,
+// if non-nil, and keeps the original name in a Unicode Path extra field
+// for readers that understand it. Other names are left to archive/zip,
+// which marks them as UTF-8 when they are.
+func setNameEncoding(header *zip.FileHeader, enc encoding.Encoding) error {
+	if enc != nil && !isASCII(header.Name) && utf8.ValidString(header.Name) {
+		return encodeLegacyName(header, enc)
+	}
+	return nil
+}
+
+// encodeLegacyName stores the header name in enc and records the UTF-8
+// name in a Unicode Path extra field.
+func encodeLegacyName(header *zip.FileHeader, enc encoding.Encoding) error {
+	encoded, err := enc.NewEncoder().String(header.Name)
+	if err != nil {
+		return fmt.Errorf("name %q is not representable in the requested charset: %w", header.Name, err)
+	}
+	header.Extra = appendExtra(header.Extra, extraUnicodePath, encodeUnicodePath(encoded, header.Name))
+	header.Name = encoded
+	header.NonUTF8 = true
+	return nil
+}
+
+// utf8Flag returns the UTF-8 flag archive/zip sets in the record of
+// header: set for a name with non-ASCII characters that is valid UTF-8,
+// unless the header is marked NonUTF8.
+func utf8Flag(header *zip.FileHeader) uint16 {
+	if header.NonUTF8 || isASCII(header.Name) || !utf8.ValidString(header.Name) {
+		return 0
+	}
+	return flagUTF8
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeUnicodePath builds the body of a Unicode Path extra field giving
+// name as the UTF-8 form of an entry stored as rawName.
+func encodeUnicodePath(rawName, name string) []byte {
+	data := []byte{unicodePathVersion}
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE([]byte(rawName)))
+	return append(data, name...)
+}
+
+// unicodePathName returns the UTF-8 name stored in an Info-ZIP Unicode Path
+// extra field. The field is ignored if its CRC does not match the header
+// name, which means the name was changed by a tool unaware of the field.
+func unicodePathName(f *zip.FileHeader) (string, bool) {
+	data, ok := findExtra(f.Extra, extraUnicodePath)
+	if !ok || len(data) < 5 || data[0] != unicodePathVersion {
+		return "", false
+	}
+	if binary.LittleEndian.Uint32(data[1:5]) != crc32.ChecksumIEEE([]byte(f.Name)) {
+		return "", false
+	}
+	name := string(data[5:])
+	if !utf8.ValidString(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// decodeNames replaces entry names with their Unicode form, so every later
+// step sees the same name. Names flagged as UTF-8 are kept; otherwise the
+// Unicode Path extra field is preferred, then conversion from enc if set.
+func decodeNames(files []*zip.File, enc encoding.Encoding) {
+	for _, f := range files {
+		decodeName(&f.FileHeader, enc)
+	}
+}
+
+// decodeName replaces the name of h with its Unicode form; see decodeNames.
+func decodeName(h *zip.FileHeader, enc encoding.Encoding) {
+	if h.Flags&flagUTF8 != 0 {
+		return
+	}
+	if name, ok := unicodePathName(h); ok {
+		h.Name = name
+	} else if enc != nil {
+		h.Name = decodeCharset(h.Name, enc)
+	}
+}
+
+// openArchive opens the zip file at path and decodes its entry names,
+// converting legacy names from the named charset if one is given.
+func openArchive(path, charset string) (*zip.ReadCloser, error) {
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	registerDecompressors(&r.Reader)
+	decodeNames(r.File, enc)
+	return r, nil
+}
+
+// openReader is like openArchive for an archive of the given size read
+// from ra.
+func openReader(ra io.ReaderAt, size int64, charset string) (*zip.Reader, error) {
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	registerDecompressors(r)
+	decodeNames(r.File, enc)
+	return r, nil
+}
@@ -0,0 +1,156 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetNameEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		wantUTF8 bool
+	}{
+		{"ascii", "hello.txt", false},
+		{"korean", "한글.txt", true},
+		{"emoji", "dir/🎉.txt", true},
+		{"invalid utf8", "caf\xe9.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &zip.FileHeader{Name: tt.entry}
+			if err := setNameEncoding(h, nil); err != nil {
+				t.Fatal(err)
+			}
+			if h.NonUTF8 || h.Flags != 0 {
+				t.Errorf("header changed: NonUTF8 = %v, flags = %#x", h.NonUTF8, h.Flags)
+			}
+			if got := utf8Flag(h) != 0; got != tt.wantUTF8 {
+				t.Errorf("utf8Flag = %v, want %v", got, tt.wantUTF8)
+			}
+
+			// archive/zip sets the flag as utf8Flag predicts.
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			if _, err := zw.CreateHeader(h); err != nil {
+				t.Fatal(err)
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.File[0].Flags&flagUTF8 != 0; got != tt.wantUTF8 {
+				t.Errorf("written UTF-8 flag = %v, want %v", got, tt.wantUTF8)
+			}
+		})
+	}
+}
+
+// unicodePathExtra builds a 0x7075 field for an entry stored as rawName.
+func unicodePathExtra(rawName, name string) []byte {
+	return appendExtra(nil, extraUnicodePath, encodeUnicodePath(rawName, name))
+}
+
+func TestUnzipUnicodePathExtra(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "unicode.zip")
+	extractDir := t.TempDir()
+
+	// A legacy tool stored the name in CP949 and added the Unicode form.
+	raw := "\xc7\xd1\xb1\xdb.txt"
+	good := &zip.FileHeader{Name: raw, Method: zip.Deflate, NonUTF8: true}
+	good.Extra = unicodePathExtra(raw, "한글.txt")
+	// A stale field whose CRC no longer matches must be ignored.
+	stale := &zip.FileHeader{Name: "renamed.txt", Method: zip.Deflate}
+	stale.Extra = unicodePathExtra("original.txt", "원본.txt")
+	writeTestZip(t, zipPath, "data", good, stale)
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if entries[0].Name != "한글.txt" || entries[1].Name != "renamed.txt" {
+		t.Errorf("names = %q, %q; want %q, %q", entries[0].Name, entries[1].Name, "한글.txt", "renamed.txt")
+	}
+
+	if err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir}); err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, "한글.txt")); err != nil {
+		t.Errorf("expected Unicode name on disk: %v", err)
+	}
+}
+
+func TestZipSetsUTF8Flag(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "日本語.txt")
+	writeFile(t, path, "こんにちは\n")
+	zipPath := filepath.Join(t.TempDir(), "utf8.zip")
+
+	if err := Zip(zipPath, []string{path}, ZipOptions{}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.File[0].Flags&flagUTF8 == 0 {
+		t.Error("expected UTF-8 flag on non-ASCII name")
+	}
+}
+
+func TestZipNameEncoding(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "한글.txt")
+	writeFile(t, path, "data\n")
+	zipPath := filepath.Join(t.TempDir(), "legacy.zip")
+
+	if err := Zip(zipPath, []string{path}, ZipOptions{NameEncoding: "cp949"}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	f := r.File[0]
+	if f.Flags&flagUTF8 != 0 {
+		t.Error("UTF-8 flag should not be set for legacy names")
+	}
+	if got := filepath.Base(f.Name); got != "\xc7\xd1\xb1\xdb.txt" {
+		t.Errorf("raw name = %q, want CP949 bytes", got)
+	}
+	if name, ok := unicodePathName(&f.FileHeader); !ok || filepath.Base(name) != "한글.txt" {
+		t.Errorf("Unicode Path name = %q, %v", name, ok)
+	}
+
+	// Readers that honor the Unicode Path field see the original name.
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := filepath.Base(entries[0].Name); got != "한글.txt" {
+		t.Errorf("listed name = %q, want %q", got, "한글.txt")
+	}
+}
+
+func TestZipNameEncodingUnrepresentable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "🎉.txt")
+	writeFile(t, path, "data\n")
+
+	err := Zip(filepath.Join(t.TempDir(), "x.zip"), []string{path}, ZipOptions{NameEncoding: "cp437"})
+	if err == nil {
+		t.Fatal("expected error for name not representable in cp437")
+	}
+}
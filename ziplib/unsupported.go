@@ -0,0 +1,70 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// flagEncrypted is the general purpose bit flag marking an encrypted entry.
+const flagEncrypted = 0x1
+
+// UnsupportedEntry describes an archive entry that could not be extracted
+// because it uses a compression method or feature this package does not
+// support.
+type UnsupportedEntry struct {
+	// Name is the entry name as stored in the archive.
+	Name string
+	// Method is the compression method ID of the entry.
+	Method uint16
+	// ReaderVersion is the "version needed to extract" field, e.g. 20 for 2.0.
+	ReaderVersion uint16
+	// Reason is a short human-readable description of what is unsupported.
+	Reason string
+}
+
+// UnsupportedError is returned by Unzip when one or more entries were
+// skipped because they could not be extracted. All other entries are
+// extracted before it is returned.
+type UnsupportedError struct {
+	Entries []UnsupportedEntry
+}
+
+func (e *UnsupportedError) Error() string {
+	names := make([]string, len(e.Entries))
+	for i, u := range e.Entries {
+		names[i] = u.Name
+	}
+	return fmt.Sprintf("%d unsupported entries skipped: %s", len(e.Entries), strings.Join(names, ", "))
+}
+
+// checkSupported reports whether f can be extracted, judging from its
+// flags and compression method without reading it. It returns nil if the
+// entry is supported, or a description of the unsupported entry otherwise.
+func checkSupported(f *zip.File) *UnsupportedEntry {
+	var reason string
+	switch {
+	case f.Flags&flagEncrypted != 0:
+		reason = "encrypted entry"
+	case !canDecompress(f.Method):
+		reason = fmt.Sprintf("unsupported compression method %d", f.Method)
+	default:
+		return nil
+	}
+	return &UnsupportedEntry{
+		Name:          f.Name,
+		Method:        f.Method,
+		ReaderVersion: f.ReaderVersion,
+		Reason:        reason,
+	}
+}
+
+// writeUnsupportedReport reports each skipped entry to l.
+func writeUnsupportedReport(l eventLog, entries []UnsupportedEntry) {
+	for _, u := range entries {
+		l.emit(slog.LevelWarn, "skipping", fmt.Sprintf("   skipping: %-22s %s (need version %d.%d)\n",
+			u.Name, u.Reason, u.ReaderVersion/10, u.ReaderVersion%10),
+			entryAttr(u.Name), slog.String("reason", u.Reason), slog.Int("method", int(u.Method)))
+	}
+}
@@ -0,0 +1,104 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnzipReportsUnsupportedEntries(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "mixed.zip")
+	extractDir := t.TempDir()
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for _, name := range []string{"first.txt", "odd.bin", "last.txt"} {
+		if name == "odd.bin" {
+			// Method 99 (AE-x) is never registered, so it stands in for any
+			// method the reader does not understand.
+			h := &zip.FileHeader{Name: name, Method: 99, CompressedSize64: 4, UncompressedSize64: 4}
+			fw, err := w.CreateRaw(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fw.Write([]byte("????")); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte("data\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	var buf bytes.Buffer
+	err = Unzip(zipPath, UnzipOptions{OutputDir: extractDir, Output: &buf})
+
+	var uerr *UnsupportedError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected *UnsupportedError, got: %v", err)
+	}
+	if len(uerr.Entries) != 1 {
+		t.Fatalf("expected 1 unsupported entry, got %d", len(uerr.Entries))
+	}
+	if got := uerr.Entries[0]; got.Name != "odd.bin" || got.Method != 99 {
+		t.Errorf("unexpected entry: %+v", got)
+	}
+	if !strings.Contains(buf.String(), "skipping: odd.bin") {
+		t.Errorf("expected skip report, got: %s", buf.String())
+	}
+
+	for _, name := range []string{"first.txt", "last.txt"} {
+		if _, err := os.Stat(filepath.Join(extractDir, name)); err != nil {
+			t.Errorf("expected %s to be extracted: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(extractDir, "odd.bin")); !os.IsNotExist(err) {
+		t.Errorf("odd.bin should not be extracted")
+	}
+}
+
+func TestCheckSupportedEncrypted(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "enc.zip")
+	writeTestZip(t, zipPath, "x", &zip.FileHeader{Name: "secret.txt", Method: zip.Store, Flags: flagEncrypted})
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	u := checkSupported(r.File[0])
+	if u == nil {
+		t.Fatal("expected encrypted entry to be unsupported")
+	}
+	if u.Reason != "encrypted entry" {
+		t.Errorf("Reason = %q, want %q", u.Reason, "encrypted entry")
+	}
+}
+
+func TestCheckSupportedMethods(t *testing.T) {
+	for method, want := range map[uint16]bool{
+		zip.Store: true, zip.Deflate: true, methodBzip2: true, methodLZMA: true, methodXZ: true, 9: false, 99: false,
+	} {
+		f := &zip.File{FileHeader: zip.FileHeader{Name: "a", Method: method}}
+		if got := checkSupported(f) == nil; got != want {
+			t.Errorf("method %d: supported = %v, want %v", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// Verify tests the integrity of entries in a zip archive by decompressing
+// them and checking their CRC-32, without writing anything to disk. It
+// returns an error joining one error per failed entry; checksum mismatches
+// are reported as *CRCError. If opts.FilePatterns match no entry, Verify
+// tests nothing and returns an error.
+func Verify(zipPath string, opts VerifyOptions) error {
+	out := opts.Output
+	if out == nil {
+		out = io.Discard
+	}
+
+	af, err := openArchiveFile(zipPath, false)
+	if err != nil {
+		return err
+	}
+	defer af.Close()
+	r, err := openReader(af, af.size, opts.Encoding)
+	if err != nil {
+		return err
+	}
+
+	files, err := selectVerified(r.File, opts)
+	if err != nil {
+		return err
+	}
+	if opts.SampleFraction > 0 && opts.SampleFraction < 1 {
+		seed := opts.Seed
+		if seed == 0 {
+			seed = rand.Uint64() //nolint:gosec // Sampling does not need a secure source.
+		}
+		infos, err := centralInfos(af, af.size, r.File)
+		if err != nil {
+			return err
+		}
+		total := len(files)
+		files = sampleEntries(files, infos, opts.SampleFraction, seed)
+		fmt.Fprintf(out, "sampling %d of %d entries (seed %d)\n", len(files), total, seed)
+	}
+
+	var errs []error
+	for _, f := range files {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := verifyEntry(f); err != nil {
+			fmt.Fprintf(out, "    testing: %-22s %v\n", f.Name, err)
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Fprintf(out, "    testing: %-22s OK\n", f.Name)
+	}
+
+	if len(errs) > 0 {
+		fmt.Fprintf(out, "%d errors detected in %s\n", len(errs), zipPath)
+		return errors.Join(errs...)
+	}
+	fmt.Fprintf(out, "No errors detected in compressed data of %s.\n", zipPath)
+	return nil
+}
+
+// selectVerified returns the entries of files selected by the patterns of
+// opts.
+func selectVerified(files []*zip.File, opts VerifyOptions) ([]*zip.File, error) {
+	if len(opts.FilePatterns) == 0 && len(opts.ExcludePatterns) == 0 {
+		return files, nil
+	}
+	newMatcher := NewMatcher
+	if opts.CaseInsensitive {
+		newMatcher = NewCaseInsensitiveMatcher
+	}
+	include, err := newMatcher(opts.MatchSyntax, opts.FilePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("file patterns: %w", err)
+	}
+	exclude, err := newMatcher(opts.MatchSyntax, opts.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("exclude patterns: %w", err)
+	}
+	var selected []*zip.File
+	for _, f := range files {
+		isDir := f.FileInfo().IsDir()
+		if len(opts.FilePatterns) > 0 && !include.Match(f.Name, isDir) || exclude.Match(f.Name, isDir) {
+			continue
+		}
+		selected = append(selected, f)
+	}
+	if len(selected) == 0 && len(opts.FilePatterns) > 0 {
+		return nil, fmt.Errorf("filename not matched: %s", strings.Join(opts.FilePatterns, " "))
+	}
+	return selected, nil
+}
+
+// verifyEntry reads f to the end and checks its CRC-32.
+func verifyEntry(f *zip.File) error {
+	if u := checkSupported(f); u != nil {
+		return fmt.Errorf("%s: %s", f.Name, u.Reason)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open entry %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return copyVerified(io.Discard, rc, f)
+}
+
+// sampleEntries picks about fraction of the non-directory files using a
+// generator seeded with seed. The largest entry and the entry stored last,
+// as told by the local header offsets in infos, are always included, since
+// they are the likeliest to be damaged by truncation or size-related bugs.
+// The result keeps archive order.
+func sampleEntries(files []*zip.File, infos map[*zip.File]centralInfo, fraction float64, seed uint64) []*zip.File {
+	var candidates []int
+	for i, f := range files {
+		if !f.FileInfo().IsDir() {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	picked := make(map[int]bool)
+	largest := slices.MaxFunc(candidates, func(a, b int) int {
+		return cmp.Compare(files[a].UncompressedSize64, files[b].UncompressedSize64)
+	})
+	last := slices.MaxFunc(candidates, func(a, b int) int {
+		return cmp.Compare(infos[files[a]].offset, infos[files[b]].offset)
+	})
+	picked[largest] = true
+	picked[last] = true
+
+	want := int(math.Ceil(fraction * float64(len(candidates))))
+	rng := rand.New(rand.NewPCG(seed, seed)) //nolint:gosec // Sampling does not need a secure source.
+	for _, j := range rng.Perm(len(candidates)) {
+		if len(picked) >= want {
+			break
+		}
+		picked[candidates[j]] = true
+	}
+
+	sample := make([]*zip.File, 0, len(picked))
+	for i, f := range files {
+		if picked[i] {
+			sample = append(sample, f)
+		}
+	}
+	return sample
+}
@@ -0,0 +1,117 @@
+package ziplib
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "ok.zip")
+	if err := Zip(zipPath, []string{src}, ZipOptions{Recursive: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Verify(zipPath, VerifyOptions{Output: &buf}); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No errors detected") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestVerifyCRCMismatch(t *testing.T) {
+	zipPath, _, _ := writeBadCRCZip(t)
+
+	err := Verify(zipPath, VerifyOptions{})
+	var crcErr *CRCError
+	if !errors.As(err, &crcErr) {
+		t.Fatalf("expected *CRCError, got: %v", err)
+	}
+}
+
+func TestVerifyPatterns(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "members.zip")
+	writeTestZip(t, zipPath, "x", &zip.FileHeader{Name: "a.txt"}, &zip.FileHeader{Name: "b.go"}, &zip.FileHeader{Name: "c.txt"})
+
+	var buf bytes.Buffer
+	opts := VerifyOptions{FilePatterns: []string{"*.txt"}, ExcludePatterns: []string{"c.*"}, Output: &buf}
+	if err := Verify(zipPath, opts); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "testing: a.txt") || strings.Contains(out, "b.go") || strings.Contains(out, "c.txt") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	if err := Verify(zipPath, VerifyOptions{FilePatterns: []string{"*.md"}}); err == nil {
+		t.Error("expected an error for unmatched file patterns")
+	}
+}
+
+func TestSampleEntries(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "many.zip")
+	var headers []*zip.FileHeader
+	for i := range 100 {
+		headers = append(headers, &zip.FileHeader{Name: fmt.Sprintf("f%03d.txt", i)})
+	}
+	headers = append(headers, &zip.FileHeader{Name: "dir/"})
+	writeTestZip(t, zipPath, "x", headers...)
+
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	f, err := os.Open(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := centralInfos(f, fi.Size(), r.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make an entry in the middle the largest.
+	r.File[42].UncompressedSize64 = 1 << 30
+
+	names := func(files []*zip.File) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.Name)
+		}
+		return out
+	}
+
+	sample := names(sampleEntries(r.File, infos, 0.05, 7))
+	if len(sample) != 5 {
+		t.Errorf("sample size = %d, want 5", len(sample))
+	}
+	for _, want := range []string{"f042.txt", "f099.txt"} {
+		if !slices.Contains(sample, want) {
+			t.Errorf("sample %v missing %s", sample, want)
+		}
+	}
+	if slices.Contains(sample, "dir/") {
+		t.Error("directories should not be sampled")
+	}
+	if !slices.IsSorted(sample) {
+		t.Errorf("sample should keep archive order: %v", sample)
+	}
+
+	if again := names(sampleEntries(r.File, infos, 0.05, 7)); !slices.Equal(sample, again) {
+		t.Errorf("same seed gave different samples: %v vs %v", sample, again)
+	}
+}
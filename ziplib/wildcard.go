@@ -0,0 +1,119 @@
+package ziplib
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// matchWildcard reports whether name matches the wildcard pattern as
+// Info-ZIP zip and unzip match one path element:
+//
+//   - "*" matches any run of characters and "?" any one character;
+//   - "[...]" matches one character of a set of characters and ranges
+//     such as "a-z", negated by a leading "!" or "^", where a "]" right
+//     after the opening bracket is part of the set;
+//   - a backslash makes the next character literal, even a wildcard;
+//   - a "[" without a closing bracket matches itself.
+//
+// Unlike path.Match, no pattern is malformed: a pattern that path.Match
+// would reject matches literally where Info-ZIP's does.
+func matchWildcard(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			return matchStar(strings.TrimLeft(pattern, "*"), name)
+		case '?':
+			if name == "" {
+				return false
+			}
+			_, n := utf8.DecodeRuneInString(name)
+			pattern, name = pattern[1:], name[n:]
+		case '[':
+			var ok bool
+			if pattern, name, ok = matchBracket(pattern, name); !ok {
+				return false
+			}
+		default:
+			c, pn := literal(pattern)
+			r, n := utf8.DecodeRuneInString(name)
+			if name == "" || r != c {
+				return false
+			}
+			pattern, name = pattern[pn:], name[n:]
+		}
+	}
+	return name == ""
+}
+
+// matchStar reports whether name matches pattern after a "*", which may
+// take any number of leading characters.
+func matchStar(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	for i := 0; ; {
+		if matchWildcard(pattern, name[i:]) {
+			return true
+		}
+		if i == len(name) {
+			return false
+		}
+		_, n := utf8.DecodeRuneInString(name[i:])
+		i += n
+	}
+}
+
+// matchBracket matches the start of name against the character class
+// that starts pattern, or against "[" if the class is not closed, and
+// returns the rest of both. ok is false if they do not match.
+func matchBracket(pattern, name string) (patternRest, nameRest string, ok bool) {
+	if name == "" {
+		return "", "", false
+	}
+	r, n := utf8.DecodeRuneInString(name)
+	matched, rest, closed := matchClass(pattern[1:], r)
+	if !closed {
+		// An unclosed bracket is an ordinary character.
+		return pattern[1:], name[1:], name[0] == '['
+	}
+	return rest, name[n:], matched
+}
+
+// matchClass matches r against the character class that follows an
+// opening bracket in pattern, returning the pattern after the closing
+// bracket. ok is false if the class is not closed.
+func matchClass(pattern string, r rune) (matched bool, rest string, ok bool) {
+	negate := false
+	if pattern != "" && (pattern[0] == '!' || pattern[0] == '^') {
+		negate = true
+		pattern = pattern[1:]
+	}
+	for first := true; ; first = false {
+		if pattern == "" {
+			return false, "", false
+		}
+		if pattern[0] == ']' && !first {
+			return matched != negate, pattern[1:], true
+		}
+		lo, n := literal(pattern)
+		pattern = pattern[n:]
+		hi := lo
+		if len(pattern) > 1 && pattern[0] == '-' && pattern[1] != ']' {
+			hi, n = literal(pattern[1:])
+			pattern = pattern[1+n:]
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+}
+
+// literal returns the character at the start of pattern, taking a
+// backslash to escape the next one, and the number of bytes it spans.
+func literal(pattern string) (rune, int) {
+	if pattern[0] == '\\' && len(pattern) > 1 {
+		r, n := utf8.DecodeRuneInString(pattern[1:])
+		return r, 1 + n
+	}
+	return utf8.DecodeRuneInString(pattern)
+}
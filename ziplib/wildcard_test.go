@@ -0,0 +1,43 @@
+package ziplib
+
+import "testing"
+
+// TestMatchWildcard checks the wildcards against what Info-ZIP zip -x and
+// unzip select for the same pattern and name.
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.txt", "a.txt", true},
+		{"*.txt", "a.txt.gz", false},
+		{"*", "", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"?.go", "é.go", true},
+		{"?.go", ".go", false},
+		{"[abc].go", "b.go", true},
+		{"[a-c].go", "c.go", true},
+		{"[a-c].go", "d.go", false},
+		{"[!abc].go", "d.go", true},
+		{"[!abc].go", "a.go", false},
+		{"[^abc].go", "a.go", false},
+		{"[]x].go", "].go", true},
+		{"[a-].go", "-.go", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+		{`file\[1\].txt`, "file[1].txt", true},
+		{`[\]].txt`, "].txt", true},
+		// zip takes a bracket it cannot close literally, where path.Match
+		// reports a malformed pattern.
+		{"[invalid", "[invalid", true},
+		{"[invalid", "i", false},
+		{"a[", "a[", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchWildcard(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
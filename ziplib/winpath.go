@@ -0,0 +1,70 @@
+package ziplib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReservedNamePolicy selects what Unzip does on Windows with entries whose
+// path contains a reserved device name such as CON, NUL or COM1, which
+// would open the device instead of creating a file.
+type ReservedNamePolicy int
+
+const (
+	// ReservedSanitize prefixes each reserved path element with an
+	// underscore, so "aux.c" is extracted as "_aux.c", as Info-ZIP unzip
+	// does. It is the default.
+	ReservedSanitize ReservedNamePolicy = iota
+	// ReservedError fails the entry.
+	ReservedError
+)
+
+// ParseReservedNamePolicy converts a policy name ("sanitize" or "error")
+// to a ReservedNamePolicy.
+func ParseReservedNamePolicy(s string) (ReservedNamePolicy, error) {
+	switch s {
+	case "sanitize", "":
+		return ReservedSanitize, nil
+	case "error":
+		return ReservedError, nil
+	default:
+		return 0, fmt.Errorf("invalid reserved name policy %q: must be sanitize or error", s)
+	}
+}
+
+// isReservedName reports whether the path element is a Windows device
+// name. Windows ignores the extension and trailing dots and spaces, so
+// "nul.txt" and "CON ." are reserved too.
+func isReservedName(elem string) bool {
+	base, _, _ := strings.Cut(strings.TrimRight(elem, ". "), ".")
+	base = strings.ToUpper(strings.TrimRight(base, " "))
+	switch base {
+	case "CON", "PRN", "AUX", "NUL", "CONIN$", "CONOUT$":
+		return true
+	}
+	if len(base) == 4 && (strings.HasPrefix(base, "COM") || strings.HasPrefix(base, "LPT")) {
+		return base[3] >= '0' && base[3] <= '9'
+	}
+	return false
+}
+
+// checkReservedNames applies policy to the reserved elements of the
+// slash-separated name, returning the name to extract to.
+func checkReservedNames(name string, policy ReservedNamePolicy) (string, error) {
+	elems := strings.Split(name, "/")
+	changed := false
+	for i, elem := range elems {
+		if !isReservedName(elem) {
+			continue
+		}
+		if policy == ReservedError {
+			return "", fmt.Errorf("reserved device name %q in %s", elem, name)
+		}
+		elems[i] = "_" + elem
+		changed = true
+	}
+	if !changed {
+		return name, nil
+	}
+	return strings.Join(elems, "/"), nil
+}
@@ -0,0 +1,54 @@
+package ziplib
+
+import "testing"
+
+func TestIsReservedName(t *testing.T) {
+	tests := []struct {
+		elem string
+		want bool
+	}{
+		{"CON", true},
+		{"con", true},
+		{"nul.txt", true},
+		{"Aux.tar.gz", true},
+		{"COM1", true},
+		{"lpt9.log", true},
+		{"CON .", true},
+		{"CONOUT$", true},
+		{"COM", false},
+		{"COM10", false},
+		{"console", false},
+		{"icon", false},
+		{"_con", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isReservedName(tt.elem); got != tt.want {
+			t.Errorf("isReservedName(%q) = %v, want %v", tt.elem, got, tt.want)
+		}
+	}
+}
+
+func TestCheckReservedNames(t *testing.T) {
+	got, err := checkReservedNames("src/aux/nul.txt", ReservedSanitize)
+	if err != nil || got != "src/_aux/_nul.txt" {
+		t.Errorf("sanitize = %q, %v, want src/_aux/_nul.txt", got, err)
+	}
+	if got, err := checkReservedNames("src/main.go", ReservedError); err != nil || got != "src/main.go" {
+		t.Errorf("unreserved = %q, %v", got, err)
+	}
+	if _, err := checkReservedNames("dir/CON", ReservedError); err == nil {
+		t.Error("expected an error for a reserved name")
+	}
+}
+
+func TestParseReservedNamePolicy(t *testing.T) {
+	for s, want := range map[string]ReservedNamePolicy{"": ReservedSanitize, "sanitize": ReservedSanitize, "error": ReservedError} {
+		if got, err := ParseReservedNamePolicy(s); err != nil || got != want {
+			t.Errorf("ParseReservedNamePolicy(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseReservedNamePolicy("rename"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
@@ -0,0 +1,19 @@
+package ziplib
+
+import "strings"
+
+// maxPath is the length from which paths need the \\?\ prefix. It is
+// MAX_PATH less room for an 8.3 file name, the limit for directories.
+const maxPath = 248
+
+// longPath returns the absolute path p with the \\?\ prefix if it is too
+// long for the Windows APIs without it.
+func longPath(p string) string {
+	if len(p) < maxPath || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+	if strings.HasPrefix(p, `\\`) {
+		return `\\?\UNC\` + p[2:]
+	}
+	return `\\?\` + p
+}
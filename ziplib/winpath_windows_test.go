@@ -0,0 +1,23 @@
+package ziplib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	long := `C:\` + strings.Repeat(`d\`, 150) + "file.txt"
+	tests := []struct {
+		p, want string
+	}{
+		{`C:\short\file.txt`, `C:\short\file.txt`},
+		{long, `\\?\` + long},
+		{`\\?\` + long, `\\?\` + long},
+		{`\\server\share\` + long[3:], `\\?\UNC\server\share\` + long[3:]},
+	}
+	for _, tt := range tests {
+		if got := longPath(tt.p); got != tt.want {
+			t.Errorf("longPath(%.40q...) = %.60q..., want %.60q...", tt.p, got, tt.want)
+		}
+	}
+}
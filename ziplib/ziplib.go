@@ -2,103 +2,474 @@ package ziplib
 
 import (
 	"archive/zip"
-	"compress/flate"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"iter"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/encoding"
 )
 
 // Zip creates a zip archive at zipPath containing the given files.
 // Directories are included recursively only if opts.Recursive is true;
 // otherwise a warning is printed and the directory is skipped.
+//
+// The archive is written to a temporary file beside zipPath and renamed
+// into place only once complete, so a failed Zip never leaves a partial
+// archive behind. If zipPath already exists, Zip fails with an error
+// matching fs.ErrExist unless opts.Overwrite is set. An archive being
+// replaced holds an exclusive advisory lock (flock or LockFileEx) until
+// then, so concurrent Zip calls replacing the same path, from this or
+// other processes, run one after another.
+//
+// If no file is added, Zip returns ErrNothingToDo and leaves zipPath
+// alone, like zip.
+//
+// If opts.DryRun is set, Zip only reports the entries it would add and the
+// paths it would exclude; the archive is neither created nor modified.
 func Zip(zipPath string, files []string, opts ZipOptions) error {
+	a, err := newArchiver(opts)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return a.dryRun(files)
+	}
+
+	old, perm, err := openReplaced(zipPath, opts.Overwrite)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		defer old.Close()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(zipPath), "."+filepath.Base(zipPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	defer os.Remove(f.Name()) // Fails harmlessly once the archive is installed.
+	defer f.Close()
+	if err := f.Chmod(perm); err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	if a.self, err = f.Stat(); err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	if err := a.write(f, files); err != nil {
+		return err
+	}
+	if a.empty() {
+		return ErrNothingToDo
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	if old != nil {
+		old.Close() // Windows cannot replace a file that is open.
+	}
+	if err := installFile(f.Name(), zipPath, opts.Overwrite); err != nil {
+		return err
+	}
+	if opts.LatestTime {
+		if err := SetLatestTime(zipPath); err != nil {
+			return err
+		}
+	}
+	return a.removeSources()
+}
+
+// openReplaced opens and locks the archive at zipPath that Zip replaces,
+// if there is one, opening it again if another writer replaced it while
+// waiting, and returns the permissions of the new archive: those of the
+// old one, or the default under the umask. It is an error for the archive
+// to exist unless overwrite is set.
+func openReplaced(zipPath string, overwrite bool) (old *os.File, perm fs.FileMode, err error) {
+	perm = 0o666 &^ processUmask()
+	for {
+		old, err = os.Open(zipPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil, perm, nil
+		case err != nil:
+			return nil, 0, fmt.Errorf("open archive: %w", err)
+		}
+		if !overwrite {
+			old.Close()
+			return nil, 0, fmt.Errorf("creating archive: %w", &fs.PathError{Op: "create", Path: zipPath, Err: fs.ErrExist})
+		}
+		current, err := lockCurrent(old, zipPath)
+		if err == nil && current {
+			break
+		}
+		old.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	if fi, err := old.Stat(); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	return old, perm, nil
+}
+
+// ZipTo is like Zip but writes the archive to w, which need not be
+// seekable, e.g. standard output or a network connection. Entry sizes and
+// checksums follow each entry in data descriptors, and the central
+// directory is kept in memory until all entries are written. If no file
+// is added, the archive written is empty; ZipTo does not return
+// ErrNothingToDo.
+func ZipTo(w io.Writer, files []string, opts ZipOptions) error {
+	a, err := newArchiver(opts)
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return a.dryRun(files)
+	}
+	if err := a.write(w, files); err != nil {
+		return err
+	}
+	return a.removeSources()
+}
+
+// ErrNothingToDo is returned by Zip and Grow when no file was added, e.g.
+// because every input was excluded or, in an incremental backup,
+// unchanged.
+var ErrNothingToDo = errors.New("nothing to do")
+
+// empty reports whether the archive has nothing to record: no files were
+// added, and no deletions either in an incremental backup.
+func (a *archiver) empty() bool {
+	return a.result.Entries == 0 && (a.manifest == nil || len(a.manifest.Deleted) == 0)
+}
+
+// newArchiver loads and compiles the patterns and settings of opts.
+func newArchiver(opts ZipOptions) (*archiver, error) {
 	out := opts.Output
 	if out == nil {
 		out = io.Discard
 	}
 
-	f, err := os.Create(zipPath)
+	excludes, err := readPatternFiles(opts.ExcludePatterns, opts.ExcludeFrom)
 	if err != nil {
-		return fmt.Errorf("creating archive: %w", err)
+		return nil, err
+	}
+	includes, err := readPatternFiles(opts.IncludePatterns, opts.IncludeFrom)
+	if err != nil {
+		return nil, err
+	}
+	opts.ExcludePatterns, opts.IncludePatterns = excludes, includes
+	exclude, err := NewMatcher(opts.MatchSyntax, opts.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("exclude patterns: %w", err)
+	}
+	include, err := NewMatcher(opts.MatchSyntax, opts.IncludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("include patterns: %w", err)
+	}
+	nameEnc, err := lookupCharset(opts.NameEncoding)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := compileLevels(opts.MatchSyntax, opts.LevelOverrides)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Comment) > maxDirEndComment {
+		return nil, errCommentTooLong
 	}
-	defer f.Close()
 
-	w := zip.NewWriter(f)
-	defer w.Close()
+	a := &archiver{
+		opts:     opts,
+		log:      eventLog{logger: opts.Logger, out: out, min: opts.Verbosity.minLevel()},
+		exclude:  exclude,
+		include:  include,
+		nameEnc:  nameEnc,
+		levels:   levels,
+		result:   resultOrNew(opts.Result),
+		hooks:    entryHooks{start: opts.OnEntryStart, done: opts.OnEntryDone, skip: opts.OnSkip},
+		dedup:    newDedupSet(opts.Deduplicate),
+		manifest: newManifest(opts),
+		progress: newProgressMeter(opts.OnProgress, 0),
+	}
+	if opts.UseIgnoreFiles {
+		a.ignores = make(ignoreSet)
+	}
+	if opts.Concurrency > 1 && !opts.Deduplicate {
+		a.workers = make(chan struct{}, opts.Concurrency)
+	}
+	if opts.BaseDir != "" {
+		if a.base, err = filepath.Abs(opts.BaseDir); err != nil {
+			return nil, fmt.Errorf("resolve base dir: %w", err)
+		}
+	}
+	return a, nil
+}
 
-	// Register custom compressor for the requested level.
-	level := opts.CompressionLevel
-	if level < -1 || level > 9 {
-		level = -1
+// write writes an archive of files to w.
+func (a *archiver) write(w io.Writer, files []string) error {
+	if l := newRateLimiter(a.opts.RateLimit); l != nil {
+		w = &throttledWriter{w: w, l: l}
+	}
+	cw := &countingWriter{w: w}
+	hw := &holdWriter{w: cw}
+	zw := zip.NewWriter(hw)
+	defer zw.Close()
+	if err := zw.SetComment(a.opts.Comment); err != nil {
+		return fmt.Errorf("set comment: %w", err)
 	}
-	w.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
-		return flate.NewWriter(out, level)
-	})
+	a.w = zw
 
-	for _, name := range files {
-		if err := addToZip(w, name, opts, out); err != nil {
+	if err := a.addAll(files); err != nil {
+		return err
+	}
+	if a.dedup != nil || len(a.text) > 0 || a.extendedTimes() {
+		// The central directory written on close only lists the entries
+		// with data, without text bits and with every stored time; it is
+		// held back to complete.
+		hw.held = new(bytes.Buffer)
+		if err := a.finish(cw, zw, hw.held, cw.n); err != nil {
 			return err
 		}
+	} else if err := zw.Close(); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
 	}
+	a.result.CompressedBytes = uint64(cw.n) //nolint:gosec // Byte counts are never negative.
 	return nil
 }
 
-func addToZip(w *zip.Writer, path string, opts ZipOptions, out io.Writer) error {
+// finish closes zw, whose output from offset start on is held back in
+// held, and writes that output to w with its central directory completed
+// by directory.
+func (a *archiver) finish(w io.Writer, zw *zip.Writer, held *bytes.Buffer, start int64) error {
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	b := held.Bytes()
+	d, err := centralDirectory(dirTail{b: b, start: start}, start+int64(len(b)))
+	if err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	at := d.off - start
+	dir, count, err := a.directory(b[at : at+d.length])
+	if err != nil {
+		return err
+	}
+	out := append(b[:at:at], dir...)
+	out = appendDirEnd(out, count, int64(len(dir)), d.off, d.comment)
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	return nil
+}
+
+// directory completes dir, the central directory written by zip.Writer,
+// with what archive/zip cannot record: the text bit of converted files,
+// the records of duplicates and extended timestamps holding only the
+// modification time. It returns the directory with the number of records
+// it holds.
+func (a *archiver) directory(dir []byte) ([]byte, uint64, error) {
+	if err := markText(dir, a.text); err != nil {
+		return nil, 0, err
+	}
+	dir, count, err := a.dedup.extend(dir)
+	if err != nil || !a.extendedTimes() {
+		return dir, count, err
+	}
+	dir, err = centralExtTimes(dir)
+	return dir, count, err
+}
+
+// extendedTimes reports whether entries get extended timestamps with
+// more than the modification time.
+func (a *archiver) extendedTimes() bool {
+	return a.opts.ExtendedTimestamps && !a.opts.StripExtras && !a.opts.Deterministic
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err //nolint:wrapcheck // Pass-through writer.
+}
+
+// resultOrNew resets and returns r, or a new Result if r is nil.
+func resultOrNew(r *Result) *Result {
+	if r == nil {
+		return new(Result)
+	}
+	*r = Result{}
+	return r
+}
+
+// archiver holds the state shared by all files of one Zip call.
+type archiver struct {
+	w        *zip.Writer
+	opts     ZipOptions
+	log      eventLog
+	base     string // absolute BaseDir, or empty
+	exclude  Matcher
+	include  Matcher
+	ignores  ignoreSet // nil unless UseIgnoreFiles is set
+	nameEnc  encoding.Encoding
+	levels   []levelRule
+	result   *Result
+	hooks    entryHooks
+	pending  []pendingFile
+	junked   map[string]string // JunkPaths entry names to the paths stored under them
+	self     os.FileInfo       // the archive being written, if it is a file
+	moved    []string          // files to delete in Move mode
+	dirs     []string          // directories walked in Move mode, parents first
+	dedup    *dedupSet         // nil unless Deduplicate is set
+	text     map[string]bool   // names of the entries converted as text
+	manifest *Manifest         // nil unless Manifest or BaseManifest is set
+	progress *progressMeter    // nil unless OnProgress is set
+	workers  chan struct{}     // one token per file compressing in the background; nil without Concurrency
+	jobs     []*compressJob    // files compressed in the background, in archive order
+}
+
+// addAll adds each of files, then any entries queued for sorting and
+// the manifest.
+func (a *archiver) addAll(files []string) error {
+	defer a.waitJobs()
+	for _, name := range files {
+		if err := a.add(name); err != nil {
+			return err
+		}
+	}
+	if err := a.writePending(); err != nil {
+		return err
+	}
+	if err := a.flushJobs(true); err != nil {
+		return err
+	}
+	return a.writeManifest()
+}
+
+func (a *archiver) add(path string) error {
+	path = a.fsPath(path)
 	info, err := os.Stat(path)
 	if err != nil {
 		return fmt.Errorf("stat %s: %w", path, err)
 	}
 
 	if info.IsDir() {
-		if !opts.Recursive {
-			fmt.Fprintf(out, "  adding: %s/ (skipped, not recursive)\n", path)
+		if !a.opts.Recursive {
+			a.log.emit(slog.LevelInfo, "skipping", fmt.Sprintf("  adding: %s/ (skipped, not recursive)\n", path),
+				slog.String("path", path), slog.String("reason", "not recursive"))
+			a.skipped(path, true, "not recursive")
 			return nil
 		}
-		err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if matchesAny(p, opts.ExcludePatterns) {
-				if fi.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-			if fi.IsDir() {
-				return nil
-			}
-			return writeFileToZip(w, p, fi, opts, out)
-		})
-		if err != nil {
+		if err := filepath.Walk(path, a.visit); err != nil {
 			return fmt.Errorf("walk %s: %w", path, err)
 		}
 		return nil
 	}
 
-	if matchesAny(path, opts.ExcludePatterns) {
+	if a.macMetadata(path) {
+		a.planExcluded(path, false)
+		a.skipped(path, false, "mac metadata")
 		return nil
 	}
-	return writeFileToZip(w, path, info, opts, out)
+	if a.exclude.Match(a.relPath(path), false) || !a.included(path) {
+		a.planExcluded(path, false)
+		a.skipped(path, false, "excluded")
+		return nil
+	}
+	return a.addFile(path, info)
 }
 
-func writeFileToZip(w *zip.Writer, path string, info os.FileInfo, opts ZipOptions, out io.Writer) error {
-	header, err := zip.FileInfoHeader(info)
+// visit adds p, found walking a directory, unless it is excluded.
+func (a *archiver) visit(p string, fi os.FileInfo, err error) error {
 	if err != nil {
-		return fmt.Errorf("file header %s: %w", path, err)
+		return err
+	}
+	isDir := fi.IsDir()
+	switch {
+	case a.macMetadata(p):
+		return a.excluded(p, isDir, "mac metadata")
+	case a.exclude.Match(a.relPath(p), isDir) || a.ignores.match(p, isDir):
+		return a.excluded(p, isDir, "excluded")
+	case isDir:
+		a.addedDir(p)
+		if a.ignores != nil {
+			return a.ignores.load(p)
+		}
+		return nil
+	case !a.included(p):
+		return a.excluded(p, false, "not included")
 	}
-	header.Name = filepath.ToSlash(path)
+	return a.addFile(p, fi)
+}
 
-	if opts.CompressionLevel == 0 {
-		header.Method = zip.Store
-	} else {
-		header.Method = zip.Deflate
+// excluded records that p, found walking a directory, is excluded for
+// reason, and skips its contents if it is a directory.
+func (a *archiver) excluded(p string, isDir bool, reason string) error {
+	a.planExcluded(p, isDir)
+	a.skipped(p, isDir, reason)
+	if isDir {
+		return filepath.SkipDir
 	}
+	return nil
+}
+
+// isSpecial reports whether mode is that of a FIFO, socket or device,
+// whose contents cannot be archived.
+func isSpecial(mode os.FileMode) bool {
+	return mode&(os.ModeNamedPipe|os.ModeSocket|os.ModeDevice|os.ModeCharDevice) != 0
+}
 
-	fw, err := w.CreateHeader(header)
+// included reports whether the file at path passes the include patterns.
+func (a *archiver) included(path string) bool {
+	return len(a.opts.IncludePatterns) == 0 || a.include.Match(a.relPath(path), false)
+}
+
+// writeFile adds the file at path to the archive as name.
+func (a *archiver) writeFile(path, name string, info os.FileInfo) (err error) {
+	if a.opts.DryRun {
+		return a.planFile(path, name, info)
+	}
+	if a.concurrent(info) {
+		return a.submit(path, name, info)
+	}
+	// Files compressed concurrently so far come first.
+	if err := a.flushJobs(true); err != nil {
+		return err
+	}
+	ev := EntryEvent{Name: name, Path: path, Size: uint64(info.Size())} //nolint:gosec // File sizes are never negative.
+	a.hooks.started(ev)
+	start := time.Now()
+	defer func() { a.hooks.finished(ev, start, err) }()
+	header, err := a.fileHeader(path, name, info)
 	if err != nil {
-		return fmt.Errorf("create header %s: %w", path, err)
+		return err
+	}
+
+	// Compressors are looked up when each entry is created, so registering
+	// them here applies the file's own level.
+	level, method := a.entryMethod(path)
+	var comp *entryCompressor
+	track := a.tracking(&comp)
+	registerCompressors(a.w, compressors(level), track, a.sealer(header))
+
+	if isSpecial(info.Mode()) {
+		return a.writeSpecial(path, name, info, header, start)
 	}
 
 	f, err := os.Open(path)
@@ -107,16 +478,280 @@ func writeFileToZip(w *zip.Writer, path string, info os.FileInfo, opts ZipOption
 	}
 	defer f.Close()
 
-	if _, err := io.Copy(fw, f); err != nil {
+	if target, ok, err := a.dedup.find(f, info.Size()); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	} else if ok {
+		a.writeDuplicate(path, name, info, header, target, start)
+		return nil
+	}
+
+	data, text, err := a.textReader(f)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	bp := copyBufs.Get().(*[]byte)
+	defer copyBufs.Put(bp)
+	data, method, err = a.chooseMethod(data, method, *bp)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	method.setHeader(header)
+	if a.opts.Password != "" {
+		header.Flags |= flagEncrypted
+	}
+
+	fw, err := a.w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("create header %s: %w", path, err)
+	}
+	a.markText(header.Name, text)
+	data, h := a.hashing(a.progress.reader(data, name))
+	n, err := copyBuffer(fw, data)
+	if err != nil {
 		return fmt.Errorf("write %s: %w", path, err)
 	}
+	var sum []byte
+	if h != nil {
+		sum = h.Sum(nil)
+		a.dedup.written(path, n, sum)
+	}
+	return a.fileAdded(path, name, info, header, method, n, sum, comp, start)
+}
+
+// writeSpecial writes the entry of a special file, which only records
+// its mode: opening a FIFO would block, and devices have no contents to
+// store.
+func (a *archiver) writeSpecial(path, name string, info os.FileInfo, header *zip.FileHeader, start time.Time) error {
+	MethodStore.setHeader(header)
+	if _, err := a.w.CreateHeader(header); err != nil {
+		return fmt.Errorf("create header %s: %w", path, err)
+	}
+	a.dedup.created()
+	a.recordFile(name, info, nil)
+	a.result.Entries++
+	a.added(path)
+	a.log.emit(slog.LevelInfo, "adding", fmt.Sprintf("  adding: %s (special file)\n", path),
+		entryAttr(header.Name), sizeAttr(0), slog.String("method", MethodStore.String()), durationAttr(start))
+	return nil
+}
+
+// writeDuplicate writes the entry of a file with the same contents as
+// target as a reference to target's data.
+func (a *archiver) writeDuplicate(path, name string, info os.FileInfo, header *zip.FileHeader, target dedupFile, start time.Time) {
+	size := uint64(info.Size()) //nolint:gosec // File sizes are never negative.
+	a.dedup.reference(header, target.index)
+	a.recordFile(name, info, target.sum[:])
+	a.progress.add(name, size)
+	a.result.Entries++
+	a.result.UncompressedBytes += size
+	a.added(path)
+	a.log.emit(slog.LevelInfo, "adding", fmt.Sprintf("  adding: %s (duplicate of %s)\n", path, target.path),
+		entryAttr(header.Name), sizeAttr(size), slog.String("duplicate", target.path), durationAttr(start))
+	if len(a.dedup.refs) == 1 {
+		a.log.emit(slog.LevelWarn, "overlapping",
+			"  zip warning: duplicates share their data, so Info-ZIP unzip rejects the archive (\"overlapped components\")\n",
+			entryAttr(header.Name))
+	}
+}
+
+// fileHeader returns the header of the file at path, stored as name.
+func (a *archiver) fileHeader(path, name string, info os.FileInfo) (*zip.FileHeader, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, fmt.Errorf("file header %s: %w", path, err)
+	}
+	header.Name = name
+	if err := setNameEncoding(header, a.nameEnc); err != nil {
+		return nil, err
+	}
+	if a.opts.Deterministic {
+		normalizeHeader(header, a.opts.DeterministicTime)
+	} else {
+		if a.opts.StripExtras {
+			header.Modified = time.Time{}
+			header.ModifiedDate, header.ModifiedTime = timeToDOS(info.ModTime()) //nolint:staticcheck // Set directly to keep archive/zip from adding an extra field.
+		} else {
+			setTimestamps(header, path, info.ModTime(), a.opts.ExtendedTimestamps)
+			addOwnerExtra(header, path)
+		}
+		header.ExternalAttrs |= fileDOSAttrs(path)
+	}
+	return header, nil
+}
 
-	fmt.Fprintf(out, "  adding: %s\n", path)
+// entryMethod returns the compression level and method for the file at
+// path.
+func (a *archiver) entryMethod(path string) (int, Method) {
+	level := levelFor(a.levels, a.relPath(path), a.opts.CompressionLevel)
+	if level == 0 {
+		return level, MethodStore
+	}
+	return level, a.opts.Method
+}
+
+// chooseMethod settles the method of the file read from f before its
+// header is written: with AutoStore, a sample is read into buf to see
+// whether it compresses, and the returned reader yields it ahead of the
+// rest.
+func (a *archiver) chooseMethod(f io.Reader, method Method, buf []byte) (io.Reader, Method, error) {
+	if !a.opts.AutoStore || method == MethodStore {
+		return f, method, nil
+	}
+	n, ok, err := sampleCompresses(f, buf)
+	if err != nil {
+		return nil, method, err
+	}
+	if !ok {
+		method = MethodStore
+	}
+	return io.MultiReader(bytes.NewReader(buf[:n]), f), method, nil
+}
+
+// tracking returns comp if the compressed size of entries is reported,
+// for registerCompressors to track it, or nil.
+func (a *archiver) tracking(comp **entryCompressor) **entryCompressor {
+	if a.opts.Verbosity != VerbosityVerbose && a.opts.Logger == nil {
+		return nil
+	}
+	return comp
+}
+
+// fileAdded records the file at path, written as name with n bytes of
+// contents whose checksum is sum, and reports it. comp is the tracked
+// compressor of the entry, if any.
+func (a *archiver) fileAdded(path, name string, info os.FileInfo, header *zip.FileHeader, method Method, n int64, sum []byte, comp *entryCompressor, start time.Time) error {
+	a.recordFile(name, info, sum)
+	a.result.Entries++
+	a.result.UncompressedBytes += uint64(n) //nolint:gosec // Byte counts are never negative.
+	a.added(path)
+
+	text := fmt.Sprintf("  adding: %s\n", path)
+	attrs := []slog.Attr{entryAttr(header.Name), sizeAttr(uint64(n)), slog.String("method", method.String()), durationAttr(start)} //nolint:gosec // Byte counts are never negative.
+	if a.tracking(&comp) != nil {
+		in, out := uint64(n), uint64(n) //nolint:gosec // Byte counts are never negative.
+		if comp != nil {
+			if err := comp.Close(); err != nil {
+				return fmt.Errorf("write %s: %w", path, err)
+			}
+			out = uint64(comp.out.n) //nolint:gosec // Byte counts are never negative.
+		}
+		text = fmt.Sprintf("  adding: %s\t(in=%d) (out=%d) (%s %d%%)\n", path, in, out, method.verb(), savedPercent(in, out))
+		attrs = append(attrs, slog.Uint64("compressed", out))
+	}
+	a.log.emit(slog.LevelInfo, "adding", text, attrs...)
 	return nil
 }
 
 // Unzip extracts the contents of a zip archive.
+//
+// The archive is rejected with a *LimitError if it exceeds any of the
+// resource limits in opts. Unless opts.Force is set, Unzip then checks that
+// the destination filesystem has room for the uncompressed entries and
+// returns a *SpaceError without extracting anything if it does not.
+//
+// Entries that use an unsupported compression method or feature are skipped
+// and reported once every other entry has been extracted; in that case the
+// returned error is an *UnsupportedError listing them.
+//
+// An entry that fails to extract stops extraction unless opts.OnError
+// says to skip or retry it. Skipped failures are reported at the end as an
+// *ExtractError, joined with any *UnsupportedError.
+//
+// If opts.DryRun is set, Unzip only reports which entries would be
+// extracted, skipped or collide with existing files; nothing is written.
 func Unzip(zipPath string, opts UnzipOptions) error {
+	f, err := openArchiveFile(zipPath, opts.Mmap)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return UnzipReader(f, f.size, opts)
+}
+
+// UnzipReader is like Unzip for an archive of the given size read from ra,
+// such as a bytes.Reader over an archive held in memory.
+func UnzipReader(ra io.ReaderAt, size int64, opts UnzipOptions) error {
+	limits := newResourceLimits(opts)
+	if err := limits.checkArchive(size); err != nil {
+		return err
+	}
+	sel, err := newEntrySelector(opts)
+	if err != nil {
+		return err
+	}
+	x, r, err := newExtractor(ra, size, opts)
+	if err != nil {
+		return err
+	}
+
+	selected, unsupported := x.selectEntries(orderEntries(r.File, opts.Order, sel.priority), sel)
+	selected, err = x.resolveCaseCollisions(selected, caseInsensitiveDir(x.absOutputDir))
+	if err != nil {
+		return err
+	}
+	if err := limits.check(selected); err != nil {
+		return err
+	}
+	if !opts.Force {
+		if err := checkFreeSpace(x.absOutputDir, selected); err != nil {
+			return err
+		}
+	}
+	if opts.DryRun {
+		x.plan(selected)
+		writeUnsupportedReport(x.log, unsupported)
+		return nil
+	}
+	return x.extractSelected(selected, unsupported)
+}
+
+// entrySelector selects the entries Unzip extracts, and those it
+// extracts first.
+type entrySelector struct {
+	opts     UnzipOptions
+	include  Matcher
+	exclude  Matcher
+	priority Matcher
+}
+
+// newEntrySelector compiles the patterns of opts.
+func newEntrySelector(opts UnzipOptions) (*entrySelector, error) {
+	newMatcher := NewMatcher
+	if opts.CaseInsensitive {
+		newMatcher = NewCaseInsensitiveMatcher
+	}
+	s := &entrySelector{opts: opts}
+	var err error
+	if s.include, err = newMatcher(opts.MatchSyntax, opts.FilePatterns); err != nil {
+		return nil, fmt.Errorf("file patterns: %w", err)
+	}
+	if s.exclude, err = newMatcher(opts.MatchSyntax, opts.ExcludePatterns); err != nil {
+		return nil, fmt.Errorf("exclude patterns: %w", err)
+	}
+	if len(opts.PriorityPatterns) > 0 {
+		if s.priority, err = NewMatcher(opts.MatchSyntax, opts.PriorityPatterns); err != nil {
+			return nil, fmt.Errorf("priority patterns: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// match reports whether f is selected by the file and exclude patterns
+// and the macOS metadata and manifest options.
+func (s *entrySelector) match(f *zip.File) bool {
+	if s.opts.SkipManifest && f.Name == ManifestName {
+		return false
+	}
+	isDir := f.FileInfo().IsDir()
+	if len(s.opts.FilePatterns) > 0 && !s.include.Match(f.Name, isDir) || s.exclude.Match(f.Name, isDir) {
+		return false
+	}
+	return !s.opts.ExcludeMacMetadata || !IsMacMetadata(f.Name)
+}
+
+// newExtractor opens the archive of the given size read from ra for
+// extraction with opts.
+func newExtractor(ra io.ReaderAt, size int64, opts UnzipOptions) (*extractor, *zip.Reader, error) {
 	out := opts.Output
 	if out == nil {
 		out = io.Discard
@@ -129,110 +764,409 @@ func Unzip(zipPath string, opts UnzipOptions) error {
 
 	absOutputDir, err := filepath.Abs(outputDir)
 	if err != nil {
-		return fmt.Errorf("resolve output dir: %w", err)
+		return nil, nil, fmt.Errorf("resolve output dir: %w", err)
 	}
 
-	r, err := zip.OpenReader(zipPath)
+	hook, err := newExecHook(opts.ExecCommand, opts.ExecParallel, out)
 	if err != nil {
-		return fmt.Errorf("open archive: %w", err)
+		return nil, nil, err
+	}
+	if hook != nil {
+		out = hook.out
+	} else if opts.Concurrency > 1 {
+		out = &lockedWriter{w: out}
 	}
-	defer r.Close()
 
-	for _, f := range r.File {
-		if err := extractEntry(f, outputDir, absOutputDir, opts, out); err != nil {
-			return err
+	if l := newRateLimiter(opts.RateLimit); l != nil {
+		ra = &throttledReaderAt{r: ra, l: l}
+	}
+	r, err := openReader(ra, size, opts.Encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	x := &extractor{
+		opts:         opts,
+		log:          eventLog{logger: opts.Logger, out: out, min: opts.Verbosity.minLevel()},
+		outputDir:    outputDir,
+		absOutputDir: absOutputDir,
+		hook:         hook,
+		modes:        newModePolicy(opts),
+		result:       resultOrNew(opts.Result),
+		hooks:        entryHooks{start: opts.OnEntryStart, done: opts.OnEntryDone, skip: opts.OnSkip},
+		names:        make(map[*zip.File]string),
+	}
+	if opts.ConvertText != TextConvertNone {
+		if x.textCharset, err = lookupCharset(opts.Encoding); err != nil {
+			return nil, nil, err
 		}
 	}
-	return nil
+	if opts.ConvertText == TextConvertMarked || slices.ContainsFunc(r.File, announcesAccess) {
+		if x.central, err = centralInfos(ra, size, r.File); err != nil {
+			return nil, nil, err
+		}
+		x.ra = ra
+	}
+	return x, r, nil
 }
 
-func extractEntry(f *zip.File, outputDir, absOutputDir string, opts UnzipOptions, out io.Writer) error {
-	if len(opts.FilePatterns) > 0 && !matchesAny(f.Name, opts.FilePatterns) {
-		return nil
+// selectEntries returns the entries of files chosen by sel that can be
+// extracted, in order, and those skipped as unsupported.
+func (x *extractor) selectEntries(files []*zip.File, sel *entrySelector) (selected []*zip.File, unsupported []UnsupportedEntry) {
+	for _, f := range files {
+		if !sel.match(f) || !x.rename(f) {
+			continue
+		}
+		if u := checkSupported(f); u != nil {
+			unsupported = append(unsupported, *u)
+			x.skipped(f, "", u.Reason)
+			continue
+		}
+		selected = append(selected, f)
 	}
+	return selected, unsupported
+}
 
-	name := f.Name
-	if opts.JunkPaths {
+// extractSelected extracts the selected entries and reports those skipped
+// as unsupported.
+func (x *extractor) extractSelected(selected []*zip.File, unsupported []UnsupportedEntry) error {
+	var total uint64
+	for _, f := range selected {
+		total += f.UncompressedSize64
+	}
+	x.progress = newProgressMeter(x.opts.OnProgress, total)
+	if err := x.extractAll(selected, x.opts.Concurrency); err != nil {
+		return errors.Join(err, x.hook.wait())
+	}
+	if err := x.hook.wait(); err != nil {
+		return err
+	}
+	if err := x.createLinks(); err != nil {
+		return err
+	}
+	if err := x.restoreDirs(); err != nil {
+		return err
+	}
+	writeDegradationReport(x.log, x.degraded)
+
+	var errs []error
+	if len(x.failures) > 0 {
+		errs = append(errs, &ExtractError{Failures: x.failures})
+	}
+	if len(unsupported) > 0 {
+		writeUnsupportedReport(x.log, unsupported)
+		errs = append(errs, &UnsupportedError{Entries: unsupported})
+	}
+	return errors.Join(errs...)
+}
+
+// extractor holds the state shared by all entries of one Unzip call.
+type extractor struct {
+	opts         UnzipOptions
+	log          eventLog
+	outputDir    string
+	absOutputDir string
+	hook         *execHook
+	modes        modePolicy
+	progress     *progressMeter // nil unless OnProgress is set
+	hooks        entryHooks
+	names        map[*zip.File]string      // extraction names, filled before extraction starts
+	central      map[*zip.File]centralInfo // for text bits and access times, which the central records lack
+	ra           io.ReaderAt               // the archive, for the local headers of entries in central
+	textCharset  encoding.Encoding         // code page of text entries, for ConvertText
+
+	claimed map[string]*zip.File // destinations of entries extracted so far, guarded by mu
+	decided ConflictPolicy       // policy chosen for all later files by ConflictPrompt, guarded by mu
+
+	mu           sync.Mutex // guards pendingLinks, pendingDirs, degraded, failures and result
+	pendingLinks []pendingLink
+	linkDests    map[string]bool // absolute destinations of pendingLinks, once extraction is done
+	pendingDirs  []pendingDir
+	degraded     []Degradation
+	failures     []EntryError
+	result       *Result
+}
+
+// destination returns the path f is extracted to, rejecting names that
+// would escape the output directory. On Windows, reserved device names are
+// handled as ReservedNames says and long paths get the \\?\ prefix.
+func (x *extractor) destination(f *zip.File) (string, error) {
+	name, ok := x.names[f]
+	if !ok {
+		name = f.Name
+	}
+	if x.opts.JunkPaths {
 		name = filepath.Base(name)
 	}
+	if runtime.GOOS == "windows" {
+		var err error
+		if name, err = checkReservedNames(name, x.opts.ReservedNames); err != nil {
+			return "", err
+		}
+	}
 
-	destPath := filepath.Join(outputDir, name) //nolint:gosec // Zip-slip prevention follows.
+	destPath := filepath.Join(x.outputDir, name) //nolint:gosec // Zip-slip prevention follows.
 
 	// Zip-slip prevention.
 	absDest, err := filepath.Abs(destPath)
 	if err != nil {
-		return fmt.Errorf("resolve path: %w", err)
+		return "", fmt.Errorf("resolve path: %w", err)
+	}
+	if !strings.HasPrefix(absDest, x.absOutputDir+string(os.PathSeparator)) && absDest != x.absOutputDir {
+		return "", fmt.Errorf("illegal file path: %s", f.Name)
+	}
+	if err := x.checkParents(f.Name, absDest); err != nil {
+		return "", err
+	}
+	if long := longPath(absDest); long != absDest {
+		return long, nil
 	}
-	if !strings.HasPrefix(absDest, absOutputDir+string(os.PathSeparator)) && absDest != absOutputDir {
-		return fmt.Errorf("illegal file path: %s", f.Name)
+	return destPath, nil
+}
+
+// stripComponents removes the first n elements from the slash-separated
+// entry name. It reports false if nothing is left.
+func stripComponents(name string, n int) (string, bool) {
+	if n <= 0 {
+		return name, true
+	}
+	parts := strings.Split(cleanName(name), "/")
+	if len(parts) <= n {
+		return "", false
+	}
+	rest := strings.Join(parts[n:], "/")
+	if strings.HasSuffix(name, "/") {
+		rest += "/"
+	}
+	return rest, true
+}
+
+func (x *extractor) extractEntry(f *zip.File) error {
+	destPath, err := x.destination(f)
+	if err != nil {
+		return err
+	}
+	if err := x.checkFreshen(f, destPath); err != nil {
+		return err
+	}
+	if !f.FileInfo().IsDir() {
+		if destPath, err = x.resolveConflict(f, destPath); err != nil {
+			return err
+		}
+	}
+
+	if f.Mode()&os.ModeSymlink != 0 {
+		if err := x.makeDirs(filepath.Dir(destPath)); err != nil {
+			return err
+		}
+		return x.extractSymlink(f, destPath)
 	}
 
 	if f.FileInfo().IsDir() {
-		if err := os.MkdirAll(destPath, f.Mode()); err != nil {
-			return fmt.Errorf("mkdir %s: %w", destPath, err)
+		if err := x.makeDirs(destPath); err != nil {
+			return err
 		}
+		x.deferDir(destPath, f)
 		return nil
 	}
 
-	if err := extractFile(f, destPath, opts.Overwrite, out); err != nil {
+	if err := x.makeDirs(filepath.Dir(destPath)); err != nil {
+		return err
+	}
+	if err := x.extractFile(f, destPath); err != nil {
+		return err
+	}
+	if err := x.restoreAttrs(destPath, f); err != nil {
 		return err
 	}
 
-	// Restore modification time.
-	if err := os.Chtimes(destPath, f.Modified, f.Modified); err != nil {
+	// Restore access and modification times.
+	atime, mtime := x.entryTimes(f)
+	if err := os.Chtimes(destPath, atime, mtime); err != nil {
 		return fmt.Errorf("chtimes %s: %w", destPath, err)
 	}
+
+	x.hook.run(destPath)
 	return nil
 }
 
-func extractFile(f *zip.File, destPath string, overwrite bool, out io.Writer) error {
-	if !overwrite {
-		if _, err := os.Stat(destPath); err == nil {
-			return fmt.Errorf("file exists: %s (use overwrite option)", destPath)
+// restoreAttrs applies the archived ownership, mode and DOS attributes of
+// f to path.
+// Ownership is restored first because chown clears the setuid and setgid bits.
+func (x *extractor) restoreAttrs(path string, f *zip.File) error {
+	if x.opts.RestoreOwnership {
+		if err := x.restoreOwner(path, f); err != nil {
+			return err
 		}
 	}
-
-	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
-		return fmt.Errorf("mkdir for %s: %w", destPath, err)
+	mode := x.modes.entryMode(f)
+	x.checkModeFidelity(f.Name, mode)
+	if err := restoreMode(path, mode); err != nil {
+		return err
 	}
+	return restoreDOSAttrs(path, f)
+}
 
+// tempPattern names the temporary file an entry is written to before it
+// is renamed into place.
+const tempPattern = ".gounzip-*"
+
+// extractFile writes the contents of f to destPath. The data goes to a
+// temporary file next to it that is renamed into place once complete, so
+// an interrupted or failed extraction never leaves a truncated file under
+// the final name. The temporary name is random and created exclusively,
+// so no entry or earlier link can stand in for it. If the data is
+// truncated or fails its CRC check, the partial file is removed, unless
+// KeepCorrupt is set, in which case it is still moved to destPath.
+func (x *extractor) extractFile(f *zip.File, destPath string) error {
+	start := time.Now()
 	rc, err := f.Open()
 	if err != nil {
 		return fmt.Errorf("open entry %s: %w", f.Name, err)
 	}
 	defer rc.Close()
 
-	w, err := os.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
+	w, err := os.CreateTemp(filepath.Dir(destPath), tempPattern)
 	if err != nil {
 		return fmt.Errorf("create %s: %w", destPath, err)
 	}
-	defer w.Close()
+	tmpPath := w.Name()
 
-	if _, err := io.Copy(w, rc); err != nil { //nolint:gosec // Extraction tool; size is bounded by the archive.
-		return fmt.Errorf("extract %s: %w", f.Name, err)
+	dst, flush := x.textWriter(f, w)
+	copyErr := copyVerified(dst, x.progress.reader(rc, f.Name), f)
+	if err := flush(); err != nil && copyErr == nil {
+		copyErr = fmt.Errorf("write %s: %w", destPath, err)
+	}
+	if err := w.Close(); err != nil && copyErr == nil {
+		copyErr = fmt.Errorf("close %s: %w", destPath, err)
+	}
+	if copyErr != nil && !x.opts.KeepCorrupt {
+		_ = os.Remove(tmpPath)
+		return copyErr
+	}
+	// CreateTemp makes the file private; restoreAttrs sets the final mode.
+	if err := os.Chmod(tmpPath, x.modes.entryMode(f).Perm()); err != nil {
+		_ = os.Remove(tmpPath)
+		return errors.Join(copyErr, fmt.Errorf("chmod %s: %w", destPath, err))
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return errors.Join(copyErr, fmt.Errorf("rename %s: %w", destPath, err))
+	}
+	if copyErr != nil {
+		return copyErr
 	}
 
-	fmt.Fprintf(out, "  inflating: %s\n", destPath)
+	x.log.emit(slog.LevelInfo, "inflating", fmt.Sprintf("  inflating: %s\n", destPath),
+		entryAttr(f.Name), slog.String("path", destPath), sizeAttr(f.UncompressedSize64), durationAttr(start))
 	return nil
 }
 
-// List returns metadata for all entries in a zip archive.
+// List returns metadata for all entries in a zip archive. Archives with
+// very many entries are better listed with Entries, which does not hold
+// them all in memory.
 func List(zipPath string) ([]ListEntry, error) {
-	r, err := zip.OpenReader(zipPath)
-	if err != nil {
-		return nil, fmt.Errorf("open archive: %w", err)
+	return ListWithOptions(zipPath, ListOptions{})
+}
+
+// ListWithOptions is like List but accepts options controlling how entries
+// are read and which are returned.
+func ListWithOptions(zipPath string, opts ListOptions) ([]ListEntry, error) {
+	return collectEntries(EntriesWithOptions(zipPath, opts))
+}
+
+// ListReader is like List for an archive of the given size read from r.
+func ListReader(r io.ReaderAt, size int64) ([]ListEntry, error) {
+	return ListReaderWithOptions(r, size, ListOptions{})
+}
+
+// ListReaderWithOptions is like ListWithOptions for an archive of the
+// given size read from r.
+func ListReaderWithOptions(r io.ReaderAt, size int64, opts ListOptions) ([]ListEntry, error) {
+	return collectEntries(EntriesReaderWithOptions(r, size, opts))
+}
+
+// collectEntries returns the entries yielded by seq, or its first error.
+func collectEntries(seq iter.Seq2[ListEntry, error]) ([]ListEntry, error) {
+	entries := make([]ListEntry, 0)
+	for e, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
 	}
-	defer r.Close()
+	return entries, nil
+}
+
+// Entries returns an iterator over the entries of the archive at zipPath,
+// in archive order, for callers that process entries one at a time
+// instead of collecting them as List does. Entries are read from the
+// central directory as the loop advances, so memory use stays flat even
+// for archives with millions of entries. If the archive cannot be opened
+// or its central directory is damaged, the iterator yields an error and
+// stops. The archive stays open until the loop ends.
+func Entries(zipPath string) iter.Seq2[ListEntry, error] {
+	return EntriesWithOptions(zipPath, ListOptions{})
+}
+
+// EntriesWithOptions is like Entries but accepts options controlling how
+// entries are read and which are yielded.
+func EntriesWithOptions(zipPath string, opts ListOptions) iter.Seq2[ListEntry, error] {
+	return func(yield func(ListEntry, error) bool) {
+		f, err := openArchiveFile(zipPath, opts.Mmap)
+		if err != nil {
+			yield(ListEntry{}, err)
+			return
+		}
+		defer f.Close()
+		EntriesReaderWithOptions(f, f.size, opts)(yield)
+	}
+}
 
-	entries := make([]ListEntry, 0, len(r.File))
-	for _, f := range r.File {
-		entries = append(entries, ListEntry{
-			Name:             f.Name,
-			UncompressedSize: f.UncompressedSize64,
-			CompressedSize:   f.CompressedSize64,
-			Modified:         f.Modified,
-			IsDir:            f.FileInfo().IsDir(),
+// EntriesReaderWithOptions is like EntriesWithOptions for an archive of
+// the given size read from r.
+func EntriesReaderWithOptions(r io.ReaderAt, size int64, opts ListOptions) iter.Seq2[ListEntry, error] {
+	return func(yield func(ListEntry, error) bool) {
+		enc, err := lookupCharset(opts.Encoding)
+		if err != nil {
+			yield(ListEntry{}, err)
+			return
+		}
+		err = scanCentralDirectory(r, size, enc, func(h *zip.FileHeader, info centralInfo) bool {
+			if opts.SkipManifest && h.Name == ManifestName || !inTimeWindow(h.Modified, opts.Since, opts.Until) {
+				return true
+			}
+			e := listEntry(h)
+			e.Text = info.internalAttrs&attrText != 0
+			return yield(e, nil)
 		})
+		if err != nil {
+			yield(ListEntry{}, err)
+		}
 	}
-	return entries, nil
+}
+
+// listEntry returns the listing metadata of the entry with header f.
+func listEntry(f *zip.FileHeader) ListEntry {
+	return ListEntry{
+		Name:             f.Name,
+		UncompressedSize: f.UncompressedSize64,
+		CompressedSize:   f.CompressedSize64,
+		CRC32:            f.CRC32,
+		Modified:         f.Modified,
+		IsDir:            f.FileInfo().IsDir(),
+		Method:           f.Method,
+		Flags:            f.Flags,
+		CreatorVersion:   f.CreatorVersion,
+		ExternalAttrs:    f.ExternalAttrs,
+		HasExtra:         len(f.Extra) > 0,
+	}
+}
+
+// inTimeWindow reports whether t lies in [since, until). A zero bound is open.
+func inTimeWindow(t, since, until time.Time) bool {
+	if !since.IsZero() && t.Before(since) {
+		return false
+	}
+	return until.IsZero() || t.Before(until)
 }
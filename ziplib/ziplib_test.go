@@ -3,10 +3,15 @@ package ziplib
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 // setupTestDir creates a temporary directory with test files and returns its path.
@@ -172,6 +177,144 @@ func TestZipExcludePatterns(t *testing.T) {
 	}
 }
 
+func TestZipTo(t *testing.T) {
+	src := setupTestDir(t)
+	t.Chdir(src)
+
+	// A pipe is not seekable, unlike the files Zip writes to.
+	pr, pw := io.Pipe()
+	var res Result
+	go func() {
+		pw.CloseWithError(ZipTo(pw, []string{"."}, ZipOptions{Recursive: true, Result: &res}))
+	}()
+	data, err := io.ReadAll(pr)
+	if err != nil {
+		t.Fatalf("ZipTo: %v", err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("read streamed archive: %v", err)
+	}
+	if len(r.File) != 3 {
+		t.Errorf("got %d entries, want 3", len(r.File))
+	}
+	if res.CompressedBytes != uint64(len(data)) {
+		t.Errorf("CompressedBytes = %d, want %d", res.CompressedBytes, len(data))
+	}
+}
+
+func TestZipExcludePathPattern(t *testing.T) {
+	src := setupTestDir(t)
+	writeFile(t, filepath.Join(src, "sub", "keep.go"), "package sub\n")
+	zipPath := filepath.Join(t.TempDir(), "exclude.zip")
+	t.Chdir(src)
+
+	err := Zip(zipPath, []string{"."}, ZipOptions{
+		Recursive:       true,
+		ExcludePatterns: []string{"sub/*.txt"},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	want := []string{"foo.go", "hello.txt", "sub/keep.go"}
+	if !slices.Equal(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+}
+
+func TestZipExcludeGlobstar(t *testing.T) {
+	src := setupTestDir(t)
+	deep := filepath.Join(src, "node_modules", "pkg", "lib")
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(deep, "index.js"), "module.exports = {}\n")
+	zipPath := filepath.Join(t.TempDir(), "globstar.zip")
+	t.Chdir(src)
+
+	err := Zip(zipPath, []string{"."}, ZipOptions{
+		Recursive:       true,
+		ExcludePatterns: []string{"node_modules/**"},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, "node_modules/") {
+			t.Errorf("archive should not contain %s", e.Name)
+		}
+	}
+}
+
+func TestZipExcludeNegation(t *testing.T) {
+	src := setupTestDir(t)
+	build := filepath.Join(src, "build")
+	if err := os.MkdirAll(build, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(build, "app.o"), "object\n")
+	writeFile(t, filepath.Join(build, "release-notes.txt"), "notes\n")
+	zipPath := filepath.Join(t.TempDir(), "negation.zip")
+	t.Chdir(src)
+
+	err := Zip(zipPath, []string{"."}, ZipOptions{
+		Recursive:       true,
+		ExcludePatterns: []string{"build/**", "!build/release-notes.txt"},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	got, _ := zipContents(t, zipPath)
+	if _, ok := got["build/release-notes.txt"]; !ok {
+		t.Error("archive should contain build/release-notes.txt")
+	}
+	if _, ok := got["build/app.o"]; ok {
+		t.Error("archive should not contain build/app.o")
+	}
+	if _, ok := got["hello.txt"]; !ok {
+		t.Error("archive should contain hello.txt")
+	}
+}
+
+func TestZipIncludePatterns(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "include.zip")
+	t.Chdir(src)
+
+	err := Zip(zipPath, []string{"."}, ZipOptions{
+		Recursive:       true,
+		IncludePatterns: []string{"*.txt"},
+		ExcludePatterns: []string{"hello.*"},
+	})
+	if err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	entries, err := List(zipPath)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "sub/nested.txt" {
+		t.Errorf("entries = %+v, want only sub/nested.txt", entries)
+	}
+}
+
 func TestZipCompressionLevels(t *testing.T) {
 	src := setupTestDir(t)
 
@@ -269,6 +412,36 @@ func TestUnzipJunkPaths(t *testing.T) {
 	}
 }
 
+func TestUnzipStripComponents(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "wrapped.zip")
+	writeTestZip(t, zipPath, "data",
+		&zip.FileHeader{Name: "top/", Method: zip.Store},
+		&zip.FileHeader{Name: "top/a.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "top/sub/b.txt", Method: zip.Store},
+		&zip.FileHeader{Name: "loose.txt", Method: zip.Store},
+	)
+	extractDir := t.TempDir()
+
+	var res Result
+	err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, StripComponents: 1, Result: &res})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		if got := readFile(t, filepath.Join(extractDir, name)); got != "data" {
+			t.Errorf("%s = %q, want %q", name, got, "data")
+		}
+	}
+	for _, name := range []string{"top", "loose.txt"} {
+		if _, err := os.Stat(filepath.Join(extractDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after stripping: %v", name, err)
+		}
+	}
+	if res.Entries != 2 {
+		t.Errorf("extracted %d entries, want 2", res.Entries)
+	}
+}
+
 func TestUnzipFilePatterns(t *testing.T) {
 	src := setupTestDir(t)
 	zipPath := filepath.Join(t.TempDir(), "filter.zip")
@@ -301,6 +474,34 @@ func TestUnzipFilePatterns(t *testing.T) {
 	}
 }
 
+func TestUnzipExcludePatterns(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "exclude.zip")
+	extractDir := t.TempDir()
+	t.Chdir(src)
+
+	if err := Zip(zipPath, []string{"."}, ZipOptions{Recursive: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	err := Unzip(zipPath, UnzipOptions{
+		OutputDir:       extractDir,
+		FilePatterns:    []string{"*.txt"},
+		ExcludePatterns: []string{"nested.*"},
+	})
+	if err != nil {
+		t.Fatalf("Unzip: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(extractDir, "hello.txt")); err != nil {
+		t.Errorf("hello.txt should be extracted: %v", err)
+	}
+	for _, name := range []string{"foo.go", "sub/nested.txt"} {
+		if _, err := os.Stat(filepath.Join(extractDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be extracted", name)
+		}
+	}
+}
+
 func TestUnzipZipSlipPrevention(t *testing.T) {
 	// Create a malicious zip with a path traversal entry.
 	zipPath := filepath.Join(t.TempDir(), "evil.zip")
@@ -382,6 +583,13 @@ func TestListEntries(t *testing.T) {
 			if e.UncompressedSize == 0 {
 				t.Error("expected non-zero uncompressed size for hello.txt")
 			}
+			// Too short to shrink, so it is stored.
+			if e.Method != zip.Store {
+				t.Errorf("Method = %d, want %d", e.Method, zip.Store)
+			}
+			if want := crc32.ChecksumIEEE([]byte("hello world\n")); e.CRC32 != want {
+				t.Errorf("CRC32 = %08x, want %08x", e.CRC32, want)
+			}
 		}
 	}
 	if !found {
@@ -395,3 +603,214 @@ func TestListNonexistent(t *testing.T) {
 		t.Fatal("expected error for nonexistent archive")
 	}
 }
+
+// writeTestZip creates an archive at path with one entry per header, each
+// containing content.
+func writeTestZip(t *testing.T, path string, content string, headers ...*zip.FileHeader) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for _, h := range headers {
+		fw, err := w.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Mode().IsDir() {
+			continue
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnzipRestoresMode(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "mode.zip")
+
+	script := &zip.FileHeader{Name: "run.sh", Method: zip.Deflate}
+	script.SetMode(0o755)
+	suid := &zip.FileHeader{Name: "suid", Method: zip.Deflate}
+	suid.SetMode(os.ModeSetuid | 0o755)
+	writeTestZip(t, zipPath, "#!/bin/sh\n", script, suid)
+
+	tests := []struct {
+		name        string
+		allowSetuid bool
+		wantSuid    os.FileMode
+	}{
+		{"setuid stripped by default", false, 0o755},
+		{"setuid allowed", true, os.ModeSetuid | 0o755},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractDir := t.TempDir()
+			err := Unzip(zipPath, UnzipOptions{OutputDir: extractDir, AllowSetuid: tt.allowSetuid})
+			if err != nil {
+				t.Fatalf("Unzip: %v", err)
+			}
+
+			fi, err := os.Stat(filepath.Join(extractDir, "run.sh"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode() != 0o755 {
+				t.Errorf("run.sh mode = %v, want %v", fi.Mode(), os.FileMode(0o755))
+			}
+
+			fi, err = os.Stat(filepath.Join(extractDir, "suid"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode() != tt.wantSuid {
+				t.Errorf("suid mode = %v, want %v", fi.Mode(), tt.wantSuid)
+			}
+		})
+	}
+}
+
+func TestListTimeWindow(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "window.zip")
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	writeTestZip(t, zipPath, "x",
+		&zip.FileHeader{Name: "may.txt", Modified: at(5, 31)},
+		&zip.FileHeader{Name: "june.txt", Modified: at(6, 15)},
+		&zip.FileHeader{Name: "july.txt", Modified: at(7, 1)},
+	)
+
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         []string
+	}{
+		{"no bounds", time.Time{}, time.Time{}, []string{"may.txt", "june.txt", "july.txt"}},
+		{"since only", at(6, 1), time.Time{}, []string{"june.txt", "july.txt"}},
+		{"until exclusive", time.Time{}, at(7, 1), []string{"may.txt", "june.txt"}},
+		{"window", at(6, 1), at(7, 1), []string{"june.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ListWithOptions(zipPath, ListOptions{Since: tt.since, Until: tt.until})
+			if err != nil {
+				t.Fatalf("ListWithOptions: %v", err)
+			}
+			names := make([]string, len(entries))
+			for i, e := range entries {
+				names[i] = e.Name
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("entries = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntries(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "iter.zip")
+	t.Chdir(src)
+	if err := Zip(zipPath, []string{"."}, ZipOptions{Recursive: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+
+	var names []string
+	for e, err := range Entries(zipPath) {
+		if err != nil {
+			t.Fatalf("Entries: %v", err)
+		}
+		names = append(names, e.Name)
+	}
+	want := []string{"foo.go", "hello.txt", "sub/nested.txt"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Entries = %v, want %v", names, want)
+	}
+
+	// Stopping early must not yield further entries.
+	n := 0
+	for range Entries(zipPath) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("break after first entry visited %d entries", n)
+	}
+
+	for _, err := range Entries(filepath.Join(t.TempDir(), "missing.zip")) {
+		if err == nil {
+			t.Error("Entries of a missing archive yielded no error")
+		}
+	}
+}
+
+func TestReaderAt(t *testing.T) {
+	src := setupTestDir(t)
+	zipPath := filepath.Join(t.TempDir(), "mem.zip")
+	t.Chdir(src)
+	if err := Zip(zipPath, []string{"."}, ZipOptions{Recursive: true}); err != nil {
+		t.Fatalf("Zip: %v", err)
+	}
+	data, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(data)
+
+	entries, err := ListReader(r, r.Size())
+	if err != nil {
+		t.Fatalf("ListReader: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("ListReader returned %d entries, want 3", len(entries))
+	}
+
+	extractDir := t.TempDir()
+	if err := UnzipReader(r, r.Size(), UnzipOptions{OutputDir: extractDir}); err != nil {
+		t.Fatalf("UnzipReader: %v", err)
+	}
+	if got := readFile(t, filepath.Join(extractDir, "sub", "nested.txt")); got != "nested content\n" {
+		t.Errorf("nested.txt = %q", got)
+	}
+
+	if _, err := ListReader(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("ListReader accepted invalid data")
+	}
+}
+
+func TestZipNothingToDo(t *testing.T) {
+	src := setupTestDir(t)
+	t.Chdir(src)
+	zipPath := filepath.Join(t.TempDir(), "out.zip")
+
+	err := Zip(zipPath, []string{"hello.txt"}, ZipOptions{ExcludePatterns: []string{"*.txt"}})
+	if !errors.Is(err, ErrNothingToDo) {
+		t.Fatalf("Zip = %v, want ErrNothingToDo", err)
+	}
+	if _, err := os.Stat(zipPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("archive created with nothing to add: %v", err)
+	}
+
+	if err := Zip(zipPath, []string{"foo.go"}, ZipOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Grow(zipPath, []string{"hello.txt"}, ZipOptions{ExcludePatterns: []string{"*.txt"}})
+	if !errors.Is(err, ErrNothingToDo) {
+		t.Fatalf("Grow = %v, want ErrNothingToDo", err)
+	}
+	if after := readFile(t, zipPath); after != string(before) {
+		t.Error("archive changed by Grow with nothing to add")
+	}
+}